@@ -0,0 +1,98 @@
+package main
+
+import (
+	"mime"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// compressionExtensions are the file extensions of bodies kept compressed
+// (-no-decompress), by the algorithm detectCompression reports.
+var compressionExtensions = map[string]string{
+	algoGzip:       ".gz",
+	algoDeflate:    ".zz",
+	algoLZ4:        ".lz4",
+	algoSnappy:     ".sz",
+	algoSnappyRaw:  ".snappy",
+	algoDeflateRaw: ".deflate",
+}
+
+// mediaTypeExtensions are the file extensions of decoded bodies by media type.
+// Types missing here fall back to mime.ExtensionsByType.
+var mediaTypeExtensions = map[string]string{
+	"application/json":                  ".json",
+	"application/x-ndjson":              ".ndjson",
+	"application/xml":                   ".xml",
+	"text/xml":                          ".xml",
+	"text/html":                         ".html",
+	"text/plain":                        ".txt",
+	"text/csv":                          ".csv",
+	"application/x-www-form-urlencoded": ".txt",
+	"application/protobuf":              ".pb",
+	"application/x-protobuf":            ".pb",
+	"application/vnd.google.protobuf":   ".pb",
+	"application/grpc":                  ".pb",
+	"application/octet-stream":          ".bin",
+	"application/pdf":                   ".pdf",
+	"application/zip":                   ".zip",
+	"application/x-gzip":                ".gz",
+	"image/png":                         ".png",
+	"image/jpeg":                        ".jpg",
+	"image/gif":                         ".gif",
+	"image/webp":                        ".webp",
+}
+
+// formatExtensions are the file extensions of the -format outputs that are
+// not plain text.
+var formatExtensions = map[string]string{
+	"carray": ".c",
+	"xml":    ".xml",
+	"yaml":   ".yaml",
+}
+
+// outputExtension returns the file extension (with its dot) that suits the
+// output of res for -auto-ext: the -format or special view when one was
+// used, the compression when the body was kept compressed and otherwise the
+// media type of the body, from its Content-Type header or sniffed. Output
+// with nothing better to go by is ".txt" when it is UTF-8 text and ".bin"
+// otherwise.
+func outputExtension(res *DecodeResult, opts Options) string {
+	keptExt := ""
+	if opts.NoDecompress {
+		algorithm, _ := detectCompression(res.Raw)
+		keptExt = compressionExtensions[algorithm]
+	}
+	switch {
+	case opts.Format != "":
+		if ext, ok := formatExtensions[opts.Format]; ok {
+			return ext
+		}
+		return ".txt"
+	case opts.Offset > 0 || opts.Length > 0, opts.SSE, opts.GRPCWeb, opts.GRPC, opts.Grep != "":
+		return ".txt"
+	case opts.Extract != "":
+		return ".json"
+	case keptExt != "":
+		return keptExt
+	case res.IsJSON:
+		return ".json"
+	default:
+		mediaType := bodyMediaType(res)
+		if ext, ok := mediaTypeExtensions[mediaType]; ok {
+			return ext
+		}
+		if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+			return exts[0]
+		}
+	}
+	if utf8.Valid(res.Output) {
+		return ".txt"
+	}
+	return ".bin"
+}
+
+// withExtension replaces the extension of name, if any, by ext.
+func withExtension(name, ext string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ext
+}
@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+// TestOutputExtension tests the outputExtension function.
+func TestOutputExtension(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	tests := []struct {
+		name     string
+		res      DecodeResult
+		opts     Options
+		expected string
+	}{
+		{"json", DecodeResult{IsJSON: true, Output: []byte("{}")}, Options{}, ".json"},
+		{"json sent as text", DecodeResult{ContentType: "text/plain", IsJSON: true}, Options{}, ".json"},
+		{"protobuf content type", DecodeResult{ContentType: "application/x-protobuf", Output: []byte{0x08, 0x96}}, Options{}, ".pb"},
+		{"content type with parameters", DecodeResult{ContentType: "text/csv; charset=utf-8"}, Options{}, ".csv"},
+		{"sniffed png", DecodeResult{Decompressed: png, Output: png}, Options{}, ".png"},
+		{"sniffed text", DecodeResult{Decompressed: []byte("a=1"), Output: []byte("a=1")}, Options{}, ".txt"},
+		{"unknown binary", DecodeResult{ContentType: "application/x-unknown-thing", Output: []byte{0xff, 0x00}}, Options{}, ".bin"},
+		{"kept gzip", DecodeResult{Raw: []byte{0x1f, 0x8b, 0x08}}, Options{NoDecompress: true}, ".gz"},
+		{"kept uncompressed", DecodeResult{Raw: []byte("x"), Output: []byte("x")}, Options{NoDecompress: true}, ".txt"},
+		{"kept uncompressed json", DecodeResult{Raw: []byte(`{"a":1}`), Decompressed: []byte(`{"a":1}`), IsJSON: true, Output: []byte(`{"a":1}`)}, Options{NoDecompress: true}, ".json"},
+		{"kept uncompressed png", DecodeResult{Raw: png, Decompressed: png, Output: png}, Options{NoDecompress: true}, ".png"},
+		{"yaml format", DecodeResult{IsJSON: true}, Options{Format: "yaml"}, ".yaml"},
+		{"hexstring format", DecodeResult{IsJSON: true}, Options{Format: "hexstring"}, ".txt"},
+		{"extract", DecodeResult{ContentType: "text/plain"}, Options{Extract: "$.a"}, ".json"},
+		{"grep", DecodeResult{IsJSON: true}, Options{Grep: "a"}, ".txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outputExtension(&tt.res, tt.opts); got != tt.expected {
+				t.Errorf("outputExtension() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestWithExtension tests the withExtension function.
+func TestWithExtension(t *testing.T) {
+	tests := []struct {
+		name     string
+		ext      string
+		expected string
+	}{
+		{"decoded_output.txt", ".json", "decoded_output.json"},
+		{"capture", ".gz", "capture.gz"},
+		{"out/capture.json", ".png", "out/capture.png"},
+		{"dir.d/capture", ".pb", "dir.d/capture.pb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withExtension(tt.name, tt.ext); got != tt.expected {
+				t.Errorf("withExtension(%q, %q) = %q; want %q", tt.name, tt.ext, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// decodeB64Command recovers the command text from an -input-format b64cmd
+// input: the whole command base64-encoded, as "share this request" links
+// carry it, with the standard or URL-safe alphabet and with or without
+// padding. When the input is the link itself, the base64 text is taken from
+// its fragment (after the last #), percent-decoded if needed.
+func decodeB64Command(input string) (string, error) {
+	text := strings.TrimSpace(input)
+	if i := strings.LastIndexByte(text, '#'); i >= 0 {
+		text = text[i+1:]
+		if unescaped, err := url.PathUnescape(text); err == nil {
+			text = unescaped
+		}
+	}
+	text = strings.TrimRight(strings.Join(strings.Fields(text), ""), "=")
+	if text == "" {
+		return "", errors.New("no base64 text in the input")
+	}
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(text, "-_") {
+		encoding = base64.RawURLEncoding
+	}
+	decoded, err := encoding.DecodeString(text)
+	if err != nil {
+		return "", fmt.Errorf("input is not base64 text: %w", err)
+	}
+	if !utf8.Valid(decoded) {
+		return "", errors.New("base64 input does not decode to command text")
+	}
+	return string(decoded), nil
+}
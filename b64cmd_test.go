@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+// TestDecodeB64Command tests the decodeB64Command function.
+func TestDecodeB64Command(t *testing.T) {
+	command := "curl 'https://example.com/?a=1' --data-raw $'{\"q\":\"\\xe9?>\"}'"
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{"standard", base64.StdEncoding.EncodeToString([]byte(command)), command, false},
+		{"standard without padding", base64.RawStdEncoding.EncodeToString([]byte(command)), command, false},
+		{"url-safe", base64.URLEncoding.EncodeToString([]byte(command)), command, false},
+		{"url-safe without padding", base64.RawURLEncoding.EncodeToString([]byte(command)), command, false},
+		{"wrapped over lines", "Y3Vy\nbCB1\n", "curl u", false},
+		{"share link fragment", "https://share.example/r#" + base64.URLEncoding.EncodeToString([]byte("curl u")), "curl u", false},
+		{"percent-encoded padding", "https://share.example/r#Y3VybCB1cg%3D%3D", "curl ur", false},
+		{"not base64", "curl 'u'", "", true},
+		{"binary", base64.StdEncoding.EncodeToString([]byte{0xff, 0xfe}), "", true},
+		{"empty", " ", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeB64Command(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("decodeB64Command(%q) error = %v, expectError %v", tt.input, err, tt.expectError)
+			}
+			if got != tt.expected {
+				t.Errorf("decodeB64Command(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunB64Cmd tests that Run decodes the body of a base64-encoded command.
+func TestRunB64Cmd(t *testing.T) {
+	command := "curl 'https://example.com' -H 'Content-Type: text/plain' --data-raw $'" + hexEscape(gzipBytes(t, "shared body")) + "'"
+	input := base64.RawURLEncoding.EncodeToString([]byte(command))
+	got, err := Run(input, Options{InputFormat: inputFormatB64Cmd})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if string(got) != "shared body" {
+		t.Errorf("Run() = %q; want %q", got, "shared body")
+	}
+	if _, err := Run(input, Options{}); err == nil {
+		t.Errorf("Run() without -input-format b64cmd should have failed to find a body")
+	}
+}
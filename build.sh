@@ -4,7 +4,7 @@
 set -e
 
 PROGRAM_NAME="cURLDataExtractor" # Choose your desired program name
-SOURCE_FILE="main.go"
+SOURCE_FILE="." # Build the whole package; main.go is not the only source file
 
 # --- Define the Coder's Name ---
 # Replace "Your Actual Coder Name" with your name or handle.
@@ -33,5 +33,8 @@ CGO_ENABLED=0 GOOS=darwin GOARCH=amd64 go build -ldflags="${LDFLAGS_WITH_CODER_N
 echo "Building for macOS (64-bit ARM - Apple Silicon)..."
 CGO_ENABLED=0 GOOS=darwin GOARCH=arm64 go build -ldflags="${LDFLAGS_WITH_CODER_NAME}" -o "./bin/${PROGRAM_NAME}_darwin_arm64" $SOURCE_FILE
 
+echo "Building for the browser (WebAssembly)..."
+GOOS=js GOARCH=wasm go build -ldflags="${LDFLAGS_WITH_CODER_NAME}" -o "./bin/${PROGRAM_NAME}.wasm" $SOURCE_FILE
+
 echo ""
 echo "Build complete. Executables are in the ./bin directory."
\ No newline at end of file
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// canonicalJSON serializes a value decoded by encoding/json (into interface{})
+// as RFC 8785 (JCS) canonical JSON: object keys sorted by their UTF-16 code
+// units, no insignificant whitespace, numbers in their ECMAScript shortest
+// form and strings with only the mandatory escapes. Semantically equal
+// documents therefore produce byte-identical output suitable for hashing.
+func canonicalJSON(v interface{}) ([]byte, error) {
+	var sb strings.Builder
+	if err := writeCanonical(&sb, v); err != nil {
+		return nil, err
+	}
+	return []byte(sb.String()), nil
+}
+
+func writeCanonical(sb *strings.Builder, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		sb.WriteString("null")
+	case bool:
+		sb.WriteString(strconv.FormatBool(v))
+	case float64:
+		n, err := canonicalNumber(v)
+		if err != nil {
+			return err
+		}
+		sb.WriteString(n)
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return fmt.Errorf("canonicalJSON: invalid number %s: %w", v, err)
+		}
+		return writeCanonical(sb, f)
+	case string:
+		writeCanonicalString(sb, v)
+	case []interface{}:
+		sb.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			if err := writeCanonical(sb, elem); err != nil {
+				return err
+			}
+		}
+		sb.WriteByte(']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+		sb.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeCanonicalString(sb, k)
+			sb.WriteByte(':')
+			if err := writeCanonical(sb, v[k]); err != nil {
+				return err
+			}
+		}
+		sb.WriteByte('}')
+	default:
+		return fmt.Errorf("canonicalJSON: unsupported type %T", v)
+	}
+	return nil
+}
+
+// canonicalNumber formats f like ECMAScript's Number.prototype.toString, as RFC 8785 requires.
+func canonicalNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("canonicalJSON: %v cannot be represented in JSON", f)
+	}
+	if f == 0 {
+		return "0", nil // Also normalises -0.
+	}
+	if abs := math.Abs(f); abs < 1e-6 || abs >= 1e21 {
+		// Exponent form; ECMAScript writes the exponent without leading zeros ("1e-7", not "1e-07").
+		s := strconv.FormatFloat(f, 'e', -1, 64)
+		mantissa, exponent, _ := strings.Cut(s, "e")
+		sign, digits := exponent[:1], strings.TrimLeft(exponent[1:], "0")
+		return mantissa + "e" + sign + digits, nil
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
+
+// writeCanonicalString writes s as a JSON string escaping only '"', '\\' and
+// control characters, using the short escapes where JSON defines them.
+func writeCanonicalString(sb *strings.Builder, s string) {
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(sb, `\u%04x`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+}
+
+// lessUTF16 orders strings by their UTF-16 code units, as RFC 8785 sorts object keys.
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+// canonicalizeURL returns a canonical form of rawURL for comparing and
+// deduplicating captures of the same request: the scheme and host are
+// lowercased, a default port (80 for http, 443 for https) and the fragment are
+// dropped, an empty path becomes "/", and the query parameters are sorted by
+// key, keeping the order of repeated keys but dropping exact duplicate
+// pairs. Percent-encoding is normalized by decoding and re-escaping the path
+// (unless it encodes a "/") and every query key and value.
+func canonicalizeURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && !(u.Scheme == "http" && port == "80" || u.Scheme == "https" && port == "443") {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]" // An IPv6 literal without a port.
+	}
+	u.Host = host
+	u.Fragment, u.RawFragment = "", ""
+	if !strings.Contains(strings.ToUpper(u.RawPath), "%2F") {
+		u.RawPath = ""
+	}
+	if u.Path == "" && u.Host != "" {
+		u.Path = "/"
+	}
+
+	values, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return "", fmt.Errorf("canonicalizeURL: %w", err)
+	}
+	for key, vs := range values {
+		seen := make(map[string]bool, len(vs))
+		deduped := vs[:0]
+		for _, v := range vs {
+			if !seen[v] {
+				seen[v] = true
+				deduped = append(deduped, v)
+			}
+		}
+		values[key] = deduped
+	}
+	u.RawQuery = values.Encode() // Encode sorts by key and keeps each key's value order.
+	u.ForceQuery = false
+	return u.String(), nil
+}
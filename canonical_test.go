@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// TestCanonicalNumber tests the canonicalNumber function against ECMAScript formatting.
+func TestCanonicalNumber(t *testing.T) {
+	tests := []struct {
+		input    float64
+		expected string
+	}{
+		{0, "0"},
+		{math.Copysign(0, -1), "0"},
+		{1, "1"},
+		{-4.5, "-4.5"},
+		{0.002, "0.002"},
+		{0.000001, "0.000001"},
+		{1e-7, "1e-7"},
+		{1e21, "1e+21"},
+		{1e20, "100000000000000000000"},
+		{123456789012345680000, "123456789012345680000"},
+		{333333333.3333333, "333333333.3333333"},
+		{9007199254740992, "9007199254740992"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			got, err := canonicalNumber(tt.input)
+			if err != nil {
+				t.Fatalf("canonicalNumber(%v) returned an unexpected error: %v", tt.input, err)
+			}
+			if got != tt.expected {
+				t.Errorf("canonicalNumber(%v) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := canonicalNumber(math.Inf(1)); err == nil {
+		t.Errorf("canonicalNumber(+Inf) should have returned an error, but got nil")
+	}
+}
+
+// TestCanonicalJSON tests the canonicalJSON function.
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"sorted keys", `{"b": 1, "a": [true, null, "x"]}`, `{"a":[true,null,"x"],"b":1}`},
+		{"nested objects", `{"z": {"y": 1, "x": 2}}`, `{"z":{"x":2,"y":1}}`},
+		{"string escapes", `"a\"b\\c\n\u0001/<>é"`, `"a\"b\\c\n\u0001/<>é"`},
+		{"number forms", `[1.0, 1e2, -0.0, 1E-7]`, `[1,100,0,1e-7]`},
+		// U+1F600 sorts before U+FB01 in UTF-16 (surrogate 0xD83D) although its UTF-8 bytes sort after.
+		{"utf-16 key order", `{"\ufb01": 1, "\ud83d\ude00": 2, "\u0080": 3}`, `{"` + "\u0080" + `":3,"` + "\U0001F600" + `":2,"` + "\ufb01" + `":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(tt.input), &v); err != nil {
+				t.Fatalf("Failed to parse fixture: %v", err)
+			}
+			got, err := canonicalJSON(v)
+			if err != nil {
+				t.Fatalf("canonicalJSON(%s) returned an unexpected error: %v", tt.input, err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("canonicalJSON(%s) = %s; want %s", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunCanonicalIsStable tests that semantically equal bodies produce identical canonical output.
+func TestRunCanonicalIsStable(t *testing.T) {
+	a, err := Run(`curl 'url' --data-raw $'{"id": 10, "tags": ["x"], "meta": {"b": 2.50, "a": 1}}'`, Options{Canonical: true})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	b, err := Run(`curl 'url' --data-raw $'{\n  "meta": {"a": 1.0, "b": 25e-1},\n  "tags": ["x"],\n  "id": 1e1\n}'`, Options{Canonical: true})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if string(a) != string(b) {
+		t.Errorf("canonical outputs differ: %s vs %s", a, b)
+	}
+	if expected := `{"id":10,"meta":{"a":1,"b":2.5},"tags":["x"]}`; string(a) != expected {
+		t.Errorf("Run() = %s; want %s", a, expected)
+	}
+}
+
+// TestCanonicalizeURL tests the canonicalizeURL function.
+func TestCanonicalizeURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{"sorted params", "https://example.com/api?b=2&a=1", "https://example.com/api?a=1&b=2", false},
+		{"repeated keys keep their order", "https://example.com/?tag=z&id=1&tag=a", "https://example.com/?id=1&tag=z&tag=a", false},
+		{"exact duplicates dropped", "https://example.com/?a=1&a=1&a=2", "https://example.com/?a=1&a=2", false},
+		{"scheme and host lowercased", "HTTPS://Example.COM/Path", "https://example.com/Path", false},
+		{"default ports dropped", "http://example.com:80/x?q=1", "http://example.com/x?q=1", false},
+		{"other ports kept", "https://example.com:8443/x", "https://example.com:8443/x", false},
+		{"empty path and fragment", "https://example.com#frag", "https://example.com/", false},
+		{"percent-encoding normalized", "https://example.com/a%7eb/%e4?q=a%20b&r=%7E", "https://example.com/a~b/%E4?q=a+b&r=~", false},
+		{"encoded slash kept", "https://example.com/a%2Fb", "https://example.com/a%2Fb", false},
+		{"empty query dropped", "https://example.com/x?", "https://example.com/x", false},
+		{"ipv6 host", "http://[::1]:80/", "http://[::1]/", false},
+		{"bad query escape", "https://example.com/?a=%zz", "", true},
+		{"bad url", "http://[::1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := canonicalizeURL(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("canonicalizeURL(%q) expected an error, got %q", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("canonicalizeURL(%q) returned an unexpected error: %v", tt.input, err)
+			}
+			if got != tt.expected {
+				t.Errorf("canonicalizeURL(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestCanonicalizeURLReordered tests that URLs differing only in query
+// parameter order and escaping share one canonical form.
+func TestCanonicalizeURLReordered(t *testing.T) {
+	urls := []string{
+		"https://api.example.com/v1/items?limit=10&offset=20&sort=name",
+		"https://API.example.com/v1/items?sort=name&limit=10&offset=20",
+		"https://api.example.com:443/v1/items?offset=20&sort=%6Eame&limit=10#top",
+	}
+	first, err := canonicalizeURL(urls[0])
+	if err != nil {
+		t.Fatalf("canonicalizeURL(%q) returned an unexpected error: %v", urls[0], err)
+	}
+	for _, u := range urls[1:] {
+		got, err := canonicalizeURL(u)
+		if err != nil {
+			t.Fatalf("canonicalizeURL(%q) returned an unexpected error: %v", u, err)
+		}
+		if got != first {
+			t.Errorf("canonicalizeURL(%q) = %q; want %q", u, got, first)
+		}
+	}
+}
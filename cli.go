@@ -0,0 +1,489 @@
+//go:build !(js && wasm)
+
+package main
+
+import (
+	"errors"
+	"flag" // Added for command-line flag parsing
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+func main() {
+	defaultInputFile := "curl_command.txt"
+	defaultOutputFile := "decoded_curl_command.txt" // As per your request for the output filename
+
+	// Define command-line flags
+	inputFile := flag.String("input", defaultInputFile, "Path to the input cURL command file.")
+	outputFile := flag.String("output", defaultOutputFile, "Path to the output file for the decoded data, or - for stdout.")
+	requireJSON := flag.Bool("require-json", false, "Fail with exit code 5 if the processed data is not valid JSON.")
+	disableOctal := flag.Bool("disable-octal", false, "Keep octal escapes (\\0, \\101) verbatim instead of decoding them, to find which escape handling corrupts a body.")
+	disableHex := flag.Bool("disable-hex", false, "Keep hex escapes (\\x41) verbatim instead of decoding them.")
+	disableUnicode := flag.Bool("disable-unicode", false, "Keep unicode escapes (\\u0041, \\U00000041) verbatim instead of decoding them.")
+	onInvalid := flag.String("on-invalid", OnInvalidError, "What to do with invalid UTF-8 bytes in the payload: error, replace (with U+FFFD) or skip.")
+	newline := flag.String("newline", NewlineKeep, "Line endings of text output: keep, lf (CRLF -> LF) or crlf (LF -> CRLF). Binary output is never converted.")
+	indent := flag.String("indent", "2", "Indentation of pretty-printed JSON: a number of spaces (1-16) or tab.")
+	reprWidth := flag.Int("repr-width", 0, "Wrap b'...' previews and output (-format repr, -offset/-length) into lines of at most this many characters; 0 for no wrapping.")
+	offset := flag.Int("offset", 0, "Write only the body bytes from this offset on (in b'...' notation unless -format is set); see -length.")
+	length := flag.Int("length", 0, "Write only this many body bytes from -offset on (0 for all of them). With -no-decompress, decoding stops at the end of the window.")
+	rawInput := flag.Bool("raw-input", false, "Treat the whole input as the content of the $'...' payload, without a surrounding curl command.")
+	percentDecode := flag.Bool("percent-decode", false, "Percent-decode the body (%7B -> {, + -> space) after escape decoding and decompression, for double-encoded form values.")
+	snappyRaw := flag.Bool("snappy-raw", false, "Decompress a body without known magic bytes as a raw (unframed) snappy block.")
+	ignoreGzipCRC := flag.Bool("ignore-gzip-crc", false, "Keep the decompressed gzip data, with a warning, when only the CRC-32 or length in the gzip trailer is wrong.")
+	autoExt := flag.Bool("auto-ext", false, "Replace the extension of -output with one matching the output (.json, .gz, .pb, .png, ...), from -format, the compression kept or the body's content type.")
+	stream := flag.Bool("stream", false, "Write the decompressed body as is, without interpreting it; gzip bodies are streamed to the output without holding them in memory.")
+	decompress := flag.String("decompress", DecompressAuto, "Decompressor to use regardless of the magic bytes: "+strings.Join(decompressorNames(), ", ")+", none (never decompress) or auto (detect it).")
+	noDecompress := flag.Bool("no-decompress", false, "Do not decompress the body; write the decoded, still compressed bytes (e.g. to save a .gz file).")
+	maxDepthFlag := flag.Int("max-depth", defaultMaxDepth, "Maximum depth of the recursive steps (-recurse, -unwrap-json-string); going deeper is an error.")
+	recurse := flag.Int("recurse", 0, "When the decoded body is itself a curl command sending data, decode it too, up to this many levels deep, and append each nested result to the output.")
+	tmpl := flag.String("template", "", "Write the output of this Go text/template, executed against the request and decode result (.Method, .URL, .Headers, .Body, .Algorithm, ...; funcs repr, json, header \"Name\"), instead of the body.")
+	scanSecrets := flag.Bool("scan-secrets", false, "Warn on stderr about likely secrets (JWTs, AWS keys, bearer tokens, high-entropy strings) in the body, with their type and offset.")
+	redact := flag.Bool("redact", false, "Replace likely secrets in the output with [REDACTED]; implies -scan-secrets.")
+	inspect := flag.Bool("inspect", false, "Print a table of the request's method, URL, content type, encoding, body size, JSON-ness and SHA-256 prefix to stdout instead of writing the body.")
+	summary := flag.Bool("summary", false, "Print only a tab-separated <type> <decompressed-bytes> <algorithm> <sha256-prefix> line to stdout instead of writing the body.")
+	digest := flag.String("digest", "", "Print this hash of the processed body: "+strings.Join(digestNames(), ", ")+".")
+	countPattern := flag.String("count-pattern", "", "Count the occurrences of this byte pattern in the processed body and print them with their first offsets: 0x followed by hex digits, or a string (in double quotes to look for a string starting with 0x).")
+	sha256Digest := flag.Bool("sha256", false, "Print the SHA-256 of the processed body; short for -digest sha256.")
+	strictLength := flag.Bool("strict-length", false, "Fail with exit code 2 when a Content-Length header disagrees with the decoded body.")
+	verbose := flag.Bool("verbose", false, "Log extra diagnostics, such as the exact whitespace bytes trimmed from the payload.")
+	noTrim := flag.Bool("no-trim", false, "Do not trim leading/trailing whitespace from the extracted data-raw content.")
+	binaryConcat := flag.Bool("binary-concat", false, "Join the arguments of all data options (--data-raw, -d, ...) byte for byte into the body, for a binary body split over several options.")
+	dialect := flag.String("dialect", DialectPython, "Escape dialect used to decode the payload: "+strings.Join(dialectNames(), ", ")+".")
+	tolerantEscapes := flag.Bool("tolerant-escapes", false, "Accept \\X41 and \\x 41 from buggy generators as \\x41; short for -dialect tolerant.")
+	useMmap := flag.Bool("mmap", false, "Memory-map the input file instead of reading it into memory (falls back to reading when unsupported).")
+	emit := flag.String("emit", "", "Write the request as a snippet instead of the decoded body: "+strings.Join(emitModes(), ", ")+".")
+	color := flag.String("color", colorAuto, "Colorize the stdout previews: auto (when stdout is a terminal), always or never.")
+	canonical := flag.Bool("canonical", false, "Write JSON bodies as RFC 8785 canonical JSON (sorted keys, no whitespace) for hashing.")
+	format := flag.String("format", "", "Write the final body bytes in another representation: "+strings.Join(outputFormatNames(), ", ")+".")
+	cArrayWidth := flag.Int("carray-width", defaultCArrayWidth, "Bytes per line for -format carray.")
+	sse := flag.Bool("sse", false, "Split the body into Server-Sent Events and pretty-print each event's data.")
+	grpc := flag.Bool("grpc", false, "De-frame a gRPC body, gunzipping compressed messages, and hex-dump each message.")
+	grpcWeb := flag.Bool("grpcweb", false, "De-frame a grpc-web body (binary or base64 text) and hex-dump each message.")
+	extract := flag.String("extract", "", "Write only the values matched by this JSONPath in the JSON body (e.g. $.data.token).")
+	grep := flag.String("grep", "", "Write only the body lines matching this regular expression.")
+	env := flag.Bool("env", false, "Substitute $NAME and ${NAME} references outside '...' and $'...' quoting with environment variables.")
+	scan := flag.Bool("scan", false, "Decode every curl command sending data found anywhere in the input, such as a log file, into a JSON array of results with the line each command starts on.")
+	findCurl := flag.Bool("find-curl", false, "Locate the curl command inside a larger text, such as a shell script, instead of treating the whole input as the command.")
+	dataFlag := flag.String("data-flag", "", "Comma-separated extra option names whose value is the body, e.g. --payload for a curl wrapper.")
+	inputFormat := flag.String("input-format", inputFormatCurl, "Format of the input: curl (a cURL command), httpraw (a raw HTTP/1.x request), httpie (an HTTPie command), jsonlist (a JSON array of cURL commands) or b64cmd (a base64-encoded cURL command or a share link carrying one in its fragment).")
+	urlDecodeInput := flag.Bool("urldecode-input", false, "Percent-decode the whole input command before parsing it (for URL-encoded pastes).")
+	logFormat := flag.String("log-format", logFormatText, "Format of the log notices on stderr: text or json.")
+	replay := flag.Bool("replay", false, "Send the reconstructed request and decode the response body instead of the captured one.")
+	retries := flag.Int("retries", 0, "With -replay, retry the request this many times on connection errors and 5xx responses.")
+	retryDelay := flag.Duration("retry-delay", defaultRetryDelay, "With -replay, delay before the first retry; doubles for each following one.")
+	jsonRepair := flag.Bool("json-repair", false, "Repair an almost-JSON body (trailing commas, unquoted keys, single-quoted strings, comments), as copied from a JavaScript object, instead of treating it as plain text.")
+	unwrapJSONString := flag.Bool("unwrap-json-string", false, "Unwrap a JSON body that is a JSON string holding JSON, e.g. \"{\\\"a\\\":1}\".")
+	fields := flag.String("fields", "", "Comma-separated (dotted) keys to keep from JSON bodies, e.g. id,user.name.")
+	recompress := flag.Bool("recompress", false, "Gzip the decoded body again before writing it (see -gzip-level, -keep-gzip-header).")
+	gzipOutputFlag := flag.Bool("gzip-output", false, "Gzip the final output before writing it, adding .gz to the -output file name (the original stream is kept as is with -no-decompress).")
+	gzipLevel := flag.Int("gzip-level", defaultGzipLevel, "Gzip compression level 0-9 used by -recompress and -gzip-output.")
+	keepGzipHeader := flag.Bool("keep-gzip-header", false, "With -recompress, copy the original gzip stream's FNAME, time and OS fields.")
+	fromClipboard := flag.Bool("clipboard", false, "Read the cURL command from the system clipboard instead of the input file.")
+	toClipboard := flag.Bool("clipboard-out", false, "With -clipboard, copy the decoded output back to the clipboard instead of writing the output file.")
+	repl := flag.Bool("repl", false, "Read cURL commands from stdin, separated by blank lines, and print each decoded result until EOF.")
+	list := flag.Bool("list", false, "Print the escape sequences, compression formats, dialects and output formats this build supports, then exit.")
+	edit := flag.Bool("edit", false, "Open the decoded output in $EDITOR (from a temporary file) instead of writing the output file; prints it when $EDITOR is not set.")
+	keep := flag.Bool("keep", false, "With -edit, keep the temporary file after the editor exits.")
+	expect := flag.String("expect", "", "Compare the output with this fixture file and exit with code 7 and a diff when they differ, for golden-file tests.")
+	update := flag.Bool("update", false, "With -expect, overwrite the fixture with the output instead of comparing them.")
+	force := flag.Bool("force", false, "Allow -output to name the input file, overwriting the command with the decoded data.")
+	mode := flag.String("mode", fmt.Sprintf("%04o", defaultOutputMode), "Octal permission bits for the output file, e.g. 0600.")
+	flag.Parse() // Parse the command-line flags
+
+	if *logFormat != logFormatText && *logFormat != logFormatJSON {
+		logger.Error(fmt.Sprintf("invalid -log-format %q (want %s or %s)", *logFormat, logFormatText, logFormatJSON))
+		os.Exit(exitFailure)
+	}
+	logger = newLogger(os.Stderr, *logFormat)
+	if *summary || *inspect {
+		previews = io.Discard // The summary line or table is the only thing printed.
+		*outputFile = stdoutOutput
+	} else if *outputFile == stdoutOutput || *edit {
+		previews = os.Stderr // Keep stdout for the decoded output (or the editor) alone.
+	}
+
+	if *list {
+		if err := listFeatures(os.Stdout); err != nil {
+			logger.Error(err.Error())
+			os.Exit(exitFailure)
+		}
+		return
+	}
+
+	if _, ok := emitters[*emit]; *emit != "" && !ok {
+		logger.Error(fmt.Sprintf("invalid -emit %q (want one of %s)", *emit, strings.Join(emitModes(), ", ")))
+		os.Exit(exitFailure)
+	}
+	if _, ok := outputFormats[*format]; *format != "" && !ok {
+		logger.Error(fmt.Sprintf("invalid -format %q (want one of %s)", *format, strings.Join(outputFormatNames(), ", ")))
+		os.Exit(exitFailure)
+	}
+	if *cArrayWidth <= 0 {
+		logger.Error(fmt.Sprintf("invalid -carray-width %d (must be positive)", *cArrayWidth))
+		os.Exit(exitFailure)
+	}
+	if *maxDepthFlag <= 0 {
+		logger.Error(fmt.Sprintf("invalid -max-depth %d (must be positive)", *maxDepthFlag))
+		os.Exit(exitFailure)
+	}
+	if *autoExt && *stream {
+		logger.Error("-auto-ext and -stream cannot be combined")
+		os.Exit(exitFailure)
+	}
+	if *update && *expect == "" {
+		logger.Error("-update requires -expect")
+		os.Exit(exitFailure)
+	}
+	if *expect != "" && *stream {
+		logger.Error("-expect and -stream cannot be combined")
+		os.Exit(exitFailure)
+	}
+	if *extract != "" && *grep != "" {
+		logger.Error("-extract and -grep cannot be combined")
+		os.Exit(exitFailure)
+	}
+	if _, err := regexp.Compile(*grep); err != nil {
+		logger.Error(fmt.Sprintf("invalid -grep pattern: %v", err))
+		os.Exit(exitFailure)
+	}
+	if _, err := compileJSONPath(*extract); *extract != "" && err != nil {
+		logger.Error(fmt.Sprintf("invalid -extract: %v", err))
+		os.Exit(exitFailure)
+	}
+	if *retries < 0 || *retryDelay < 0 {
+		logger.Error(fmt.Sprintf("invalid -retries %d / -retry-delay %s (must not be negative)", *retries, *retryDelay))
+		os.Exit(exitFailure)
+	}
+	indentText, err := parseIndent(*indent)
+	if err != nil {
+		logger.Error(fmt.Sprintf("invalid -indent: %v", err))
+		os.Exit(exitFailure)
+	}
+	if *reprWidth < 0 {
+		logger.Error(fmt.Sprintf("invalid -repr-width %d (must not be negative)", *reprWidth))
+		os.Exit(exitFailure)
+	}
+	if *offset < 0 || *length < 0 {
+		logger.Error(fmt.Sprintf("invalid -offset %d / -length %d (must not be negative)", *offset, *length))
+		os.Exit(exitFailure)
+	}
+	if *gzipLevel < 0 || *gzipLevel > 9 {
+		logger.Error(fmt.Sprintf("invalid -gzip-level %d (want 0-9)", *gzipLevel))
+		os.Exit(exitFailure)
+	}
+	outputMode, err := parseFileMode(*mode)
+	if err != nil {
+		logger.Error(fmt.Sprintf("invalid -mode: %v", err))
+		os.Exit(exitFailure)
+	}
+	if *color != colorAuto && *color != colorAlways && *color != colorNever {
+		logger.Error(fmt.Sprintf("invalid -color %q (want %s, %s or %s)", *color, colorAuto, colorAlways, colorNever))
+		os.Exit(exitFailure)
+	}
+	if *inputFormat != inputFormatCurl && *inputFormat != inputFormatHTTPRaw && *inputFormat != inputFormatHTTPie && *inputFormat != inputFormatJSONList && *inputFormat != inputFormatB64Cmd {
+		logger.Error(fmt.Sprintf("invalid -input-format %q (want %s, %s, %s, %s or %s)", *inputFormat, inputFormatCurl, inputFormatHTTPRaw, inputFormatHTTPie, inputFormatJSONList, inputFormatB64Cmd))
+		os.Exit(exitFailure)
+	}
+	if *scan && (*inputFormat != inputFormatCurl || *findCurl || *rawInput || *emit != "" || *replay) {
+		logger.Error("-scan cannot be combined with -input-format, -find-curl, -raw-input, -emit or -replay")
+		os.Exit(exitFailure)
+	}
+	if *rawInput && *inputFormat != inputFormatCurl {
+		logger.Error(fmt.Sprintf("-raw-input cannot be combined with -input-format %s", *inputFormat))
+		os.Exit(exitFailure)
+	}
+	if *newline != NewlineKeep && *newline != NewlineLF && *newline != NewlineCRLF {
+		logger.Error(fmt.Sprintf("invalid -newline %q (want %s, %s or %s)", *newline, NewlineKeep, NewlineLF, NewlineCRLF))
+		os.Exit(exitFailure)
+	}
+	if *onInvalid != OnInvalidError && *onInvalid != OnInvalidReplace && *onInvalid != OnInvalidSkip {
+		logger.Error(fmt.Sprintf("invalid -on-invalid %q (want %s, %s or %s)", *onInvalid, OnInvalidError, OnInvalidReplace, OnInvalidSkip))
+		os.Exit(exitFailure)
+	}
+	if *sha256Digest {
+		if *digest != "" && *digest != "sha256" {
+			logger.Error(fmt.Sprintf("-sha256 conflicts with -digest %s", *digest))
+			os.Exit(exitFailure)
+		}
+		*digest = "sha256"
+	}
+	var pattern []byte
+	if *countPattern != "" {
+		if pattern, err = parsePattern(*countPattern); err != nil {
+			logger.Error(fmt.Sprintf("invalid -count-pattern: %v", err))
+			os.Exit(exitFailure)
+		}
+	}
+	if _, ok := digests[*digest]; *digest != "" && !ok {
+		logger.Error(fmt.Sprintf("invalid -digest %q (want one of %s)", *digest, strings.Join(digestNames(), ", ")))
+		os.Exit(exitFailure)
+	}
+	if *tolerantEscapes {
+		if *dialect != DialectPython && *dialect != DialectTolerant {
+			logger.Error(fmt.Sprintf("-tolerant-escapes conflicts with -dialect %s", *dialect))
+			os.Exit(exitFailure)
+		}
+		*dialect = DialectTolerant
+	}
+	if err := validateDecompress(*decompress); err != nil {
+		logger.Error(fmt.Sprintf("invalid -decompress: %v", err))
+		os.Exit(exitFailure)
+	}
+	if _, ok := dialects[*dialect]; !ok {
+		logger.Error(fmt.Sprintf("invalid -dialect %q (want one of %s)", *dialect, strings.Join(dialectNames(), ", ")))
+		os.Exit(exitFailure)
+	}
+
+	opts := Options{
+		RequireJSON:      *requireJSON,
+		NoTrim:           *noTrim,
+		BinaryConcat:     *binaryConcat,
+		Verbose:          *verbose,
+		StrictLength:     *strictLength,
+		Digest:           *digest,
+		CountPattern:     pattern,
+		Summary:          *summary,
+		Inspect:          *inspect,
+		ScanSecrets:      *scanSecrets,
+		Template:         *tmpl,
+		Recurse:          *recurse,
+		MaxDepth:         *maxDepthFlag,
+		Decompress:       *decompress,
+		NoDecompress:     *noDecompress,
+		Stream:           *stream,
+		IgnoreGzipCRC:    *ignoreGzipCRC,
+		SnappyRaw:        *snappyRaw,
+		PercentDecode:    *percentDecode,
+		RawInput:         *rawInput,
+		Indent:           indentText,
+		ReprWidth:        *reprWidth,
+		Offset:           *offset,
+		Length:           *length,
+		Redact:           *redact,
+		Dialect:          *dialect,
+		OnInvalid:        *onInvalid,
+		Newline:          *newline,
+		DisableOctal:     *disableOctal,
+		DisableHex:       *disableHex,
+		DisableUnicode:   *disableUnicode,
+		Emit:             *emit,
+		Color:            resolveColor(*color),
+		Canonical:        *canonical,
+		Format:           *format,
+		CArrayWidth:      *cArrayWidth,
+		SSE:              *sse,
+		GRPC:             *grpc,
+		GRPCWeb:          *grpcWeb,
+		Extract:          *extract,
+		Grep:             *grep,
+		URLDecodeInput:   *urlDecodeInput,
+		InputFormat:      *inputFormat,
+		FindCurl:         *findCurl,
+		Scan:             *scan,
+		Env:              *env,
+		DataFlags:        parseFieldList(*dataFlag),
+		Fields:           parseFieldList(*fields),
+		UnwrapJSONString: *unwrapJSONString,
+		JSONRepair:       *jsonRepair,
+		Recompress:       *recompress,
+		GzipLevel:        *gzipLevel,
+		GzipOutput:       *gzipOutputFlag,
+		KeepGzipHeader:   *keepGzipHeader,
+		Replay:           *replay,
+		Retries:          *retries,
+		RetryDelay:       *retryDelay,
+	}
+
+	if conflicts := streamConflicts(opts); *stream && len(conflicts) > 0 {
+		logger.Error(fmt.Sprintf("-stream writes the body without interpreting it and cannot be combined with %s", strings.Join(conflicts, ", ")))
+		os.Exit(exitFailure)
+	}
+	if *repl {
+		if err := runREPL(os.Stdin, os.Stdout, opts); err != nil {
+			logger.Error(fmt.Sprintf("reading stdin: %v", err), field("error", err))
+			os.Exit(exitFailure)
+		}
+		return
+	}
+
+	if *toClipboard && !*fromClipboard {
+		logger.Error("-clipboard-out requires -clipboard")
+		os.Exit(exitFailure)
+	}
+	if *fromClipboard {
+		cb, err := newSystemClipboard()
+		if err != nil {
+			logger.Error(err.Error(), field("error", err))
+			os.Exit(exitFailure)
+		}
+		output, err := decodeClipboard(cb, opts, *toClipboard)
+		if err != nil {
+			logger.Error(err.Error(), field("exit_code", exitCodeFor(err)))
+			os.Exit(exitCodeFor(err))
+		}
+		if *toClipboard {
+			return
+		}
+		if err := saveOutput(*outputFile, output, outputMode); err != nil {
+			logger.Error(err.Error(), field("file", *outputFile), field("error", err))
+			os.Exit(exitFailure)
+		}
+		return
+	}
+
+	// Log input file usage
+	logger.Info(fmt.Sprintf("Using input file: %s", *inputFile), field("file", *inputFile))
+	if *inputFile == defaultInputFile {
+		isInputSetByUser := false
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "input" {
+				isInputSetByUser = true
+			}
+		})
+		if !isInputSetByUser {
+			logger.Info("(This is the default input path as no -input flag was provided)")
+		}
+	}
+
+	// Log output file usage
+	logger.Info(fmt.Sprintf("Using output file: %s", *outputFile), field("file", *outputFile))
+	if *outputFile == defaultOutputFile {
+		isOutputSetByUser := false
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "output" {
+				isOutputSetByUser = true
+			}
+		})
+		if !isOutputSetByUser {
+			logger.Info("(This is the default output path as no -output flag was provided)")
+		}
+	}
+
+	if *gzipOutputFlag && !*autoExt && *outputFile != stdoutOutput {
+		*outputFile = gzipOutputName(*outputFile)
+		logger.Info(fmt.Sprintf("Using output file %s (-gzip-output).", *outputFile), field("file", *outputFile))
+	}
+	if err := checkOutputPath(*inputFile, *outputFile, *force); err != nil {
+		logger.Error(err.Error(), field("file", *outputFile))
+		os.Exit(exitFailure)
+	}
+
+	// Read the cURL command from the specified input file
+	curlCommand, release, err := readCommandFile(*inputFile, *useMmap)
+	if err != nil {
+		logger.Error(fmt.Sprintf("reading input file %s: %v", *inputFile, err), field("file", *inputFile), field("error", err))
+		os.Exit(exitFailure)
+	}
+
+	if *stream {
+		err := streamOutput(*outputFile, curlCommand, opts, outputMode)
+		release()
+		if err != nil {
+			logger.Error(err.Error(), field("exit_code", exitCodeFor(err)))
+			os.Exit(exitCodeFor(err))
+		}
+		return
+	}
+
+	res, err := Decode(curlCommand, opts)
+	release() // The output never aliases the input, so a mapped input can be released here.
+	if err != nil {
+		logger.Error(err.Error(), field("exit_code", exitCodeFor(err)))
+		os.Exit(exitCodeFor(err))
+	}
+	output := res.Output
+	if *expect != "" {
+		if *update {
+			if err := updateFixture(output, *expect); err != nil {
+				logger.Error(err.Error(), field("file", *expect), field("error", err))
+				os.Exit(exitFailure)
+			}
+			logger.Info(fmt.Sprintf("Updated the fixture %s with the output (-update).", *expect), field("file", *expect))
+		} else if err := checkFixture(output, *expect); err != nil {
+			var mismatchErr *MismatchError
+			if errors.As(err, &mismatchErr) {
+				fmt.Fprint(os.Stderr, mismatchErr.Diff)
+			}
+			logger.Error(err.Error(), field("exit_code", exitCodeFor(err)))
+			os.Exit(exitCodeFor(err))
+		} else {
+			logger.Info(fmt.Sprintf("The output matches the fixture %s (-expect).", *expect), field("file", *expect))
+		}
+	}
+	if *autoExt && *outputFile != stdoutOutput && !*edit {
+		*outputFile = withExtension(*outputFile, outputExtension(res, opts))
+		if *gzipOutputFlag {
+			*outputFile = gzipOutputName(*outputFile)
+		}
+		logger.Info(fmt.Sprintf("Using output file %s (-auto-ext).", *outputFile), field("file", *outputFile))
+		if err := checkOutputPath(*inputFile, *outputFile, *force); err != nil {
+			logger.Error(err.Error(), field("file", *outputFile))
+			os.Exit(exitFailure)
+		}
+	}
+
+	if *edit {
+		path, err := editOutput(output, os.Getenv("EDITOR"), *keep)
+		switch {
+		case errors.Is(err, errNoEditor):
+			logger.Warn("$EDITOR is not set; printing the decoded data instead of opening it (-edit).")
+			if err := saveOutput(stdoutOutput, output, outputMode); err != nil {
+				logger.Error(err.Error(), field("error", err))
+				os.Exit(exitFailure)
+			}
+		case err != nil:
+			logger.Error(err.Error(), field("error", err))
+			os.Exit(exitFailure)
+		case *keep:
+			logger.Info(fmt.Sprintf("Kept the edited output in %s.", path), field("file", path))
+		}
+		return
+	}
+
+	// Save the processed data to the specified output file
+	if err := saveOutput(*outputFile, output, outputMode); err != nil {
+		logger.Error(err.Error(), field("file", *outputFile), field("error", err))
+		os.Exit(exitFailure)
+	}
+}
+
+// streamOutput is saveOutput for -stream: RunTo writes to the output file
+// as it goes, so gzip bodies are streamed to it. The file is only replaced
+// once RunTo succeeds.
+func streamOutput(outputFile, curlCommand string, opts Options, mode os.FileMode) error {
+	if outputFile == stdoutOutput {
+		return RunTo(os.Stdout, curlCommand, opts)
+	}
+	var runErr error
+	err := writeOutputFileWith(outputFile, mode, func(w io.Writer) error {
+		runErr = RunTo(w, curlCommand, opts)
+		return runErr
+	})
+	if runErr != nil {
+		return runErr
+	}
+	if err != nil {
+		return fmt.Errorf("saving decoded data to file %s: %w", outputFile, err)
+	}
+	fmt.Fprintf(previews, "Decoded data has been saved to %s\n", outputFile)
+	return nil
+}
+
+// saveOutput writes output to stdout when outputFile is stdoutOutput, and to
+// outputFile with the given mode otherwise.
+func saveOutput(outputFile string, output []byte, mode os.FileMode) error {
+	if outputFile == stdoutOutput {
+		if err := writeOutput(os.Stdout, output); err != nil {
+			return fmt.Errorf("writing decoded data to stdout: %w", err)
+		}
+		return nil
+	}
+	if err := writeOutputFile(outputFile, output, mode); err != nil {
+		return fmt.Errorf("saving decoded data to file %s: %w", outputFile, err)
+	}
+	fmt.Fprintf(previews, "Decoded data has been saved to %s\n", outputFile)
+	return nil
+}
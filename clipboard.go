@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboard is the system clipboard, holding text.
+type clipboard interface {
+	ReadText() (string, error)
+	WriteText(text string) error
+}
+
+// errClipboardUnsupported is returned by newSystemClipboard on platforms
+// without clipboard support.
+var errClipboardUnsupported = errors.New("the system clipboard is not supported on " + runtime.GOOS)
+
+// execClipboard is a clipboard accessed through command-line tools, such as
+// pbpaste and pbcopy. The copy command reads the text on its stdin.
+type execClipboard struct {
+	paste []string
+	copy  []string
+}
+
+// ReadText runs the paste command and returns its output.
+func (c execClipboard) ReadText() (string, error) {
+	out, err := exec.Command(c.paste[0], c.paste[1:]...).Output()
+	if err != nil {
+		return "", fmt.Errorf("reading the clipboard with %s: %w", c.paste[0], err)
+	}
+	return string(out), nil
+}
+
+// WriteText runs the copy command with text on its stdin.
+func (c execClipboard) WriteText(text string) error {
+	cmd := exec.Command(c.copy[0], c.copy[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("writing the clipboard with %s: %w", c.copy[0], err)
+	}
+	return nil
+}
+
+// decodeClipboard decodes the command on cb with opts, for -clipboard. With
+// writeBack the output replaces the clipboard contents, for -clipboard-out.
+func decodeClipboard(cb clipboard, opts Options, writeBack bool) ([]byte, error) {
+	command, err := cb.ReadText()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(command) == "" {
+		return nil, &ExtractError{Err: errors.New("the clipboard is empty")}
+	}
+	logger.Info(fmt.Sprintf("Read %d bytes from the clipboard.", len(command)), field("length", len(command)))
+	output, err := Run(command, opts)
+	if err != nil {
+		return nil, err
+	}
+	if writeBack {
+		if err := cb.WriteText(string(output)); err != nil {
+			return nil, err
+		}
+		logger.Info(fmt.Sprintf("Copied the %d-byte output to the clipboard.", len(output)), field("length", len(output)))
+	}
+	return output, nil
+}
@@ -0,0 +1,6 @@
+package main
+
+// newSystemClipboard returns the macOS clipboard, accessed with pbpaste and pbcopy.
+func newSystemClipboard() (clipboard, error) {
+	return execClipboard{paste: []string{"pbpaste"}, copy: []string{"pbcopy"}}, nil
+}
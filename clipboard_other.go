@@ -0,0 +1,8 @@
+//go:build !unix && !windows
+
+package main
+
+// newSystemClipboard reports that this platform has no clipboard support.
+func newSystemClipboard() (clipboard, error) {
+	return nil, errClipboardUnsupported
+}
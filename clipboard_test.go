@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeClipboard is an in-memory clipboard for tests.
+type fakeClipboard struct {
+	text     string
+	readErr  error
+	writeErr error
+}
+
+func (c *fakeClipboard) ReadText() (string, error) { return c.text, c.readErr }
+
+func (c *fakeClipboard) WriteText(text string) error {
+	if c.writeErr != nil {
+		return c.writeErr
+	}
+	c.text = text
+	return nil
+}
+
+// TestDecodeClipboard tests the decodeClipboard function.
+func TestDecodeClipboard(t *testing.T) {
+	command := "curl 'u' --data-raw $'" + hexEscape(gzipBytes(t, `{"a":1}`)) + "'"
+	expected := "{\n  \"a\": 1\n}"
+	clipboardErr := errors.New("clipboard locked")
+	tests := []struct {
+		name              string
+		clipboard         *fakeClipboard
+		writeBack         bool
+		expectedClipboard string
+		expectError       bool
+	}{
+		{"read only", &fakeClipboard{text: command}, false, command, false},
+		{"write back", &fakeClipboard{text: command}, true, expected, false},
+		{"empty clipboard", &fakeClipboard{text: " \n"}, false, " \n", true},
+		{"read error", &fakeClipboard{readErr: clipboardErr}, false, "", true},
+		{"write error", &fakeClipboard{text: command, writeErr: clipboardErr}, true, command, true},
+		{"not a command", &fakeClipboard{text: "hello"}, true, "hello", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeClipboard(tt.clipboard, Options{}, tt.writeBack)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("decodeClipboard() expected an error, got %q", got)
+				}
+			} else if err != nil {
+				t.Fatalf("decodeClipboard() returned an unexpected error: %v", err)
+			} else if string(got) != expected {
+				t.Errorf("decodeClipboard() = %q; want %q", got, expected)
+			}
+			if tt.clipboard.text != tt.expectedClipboard {
+				t.Errorf("clipboard = %q; want %q", tt.clipboard.text, tt.expectedClipboard)
+			}
+		})
+	}
+}
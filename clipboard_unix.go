@@ -0,0 +1,24 @@
+//go:build unix && !darwin
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// newSystemClipboard returns the clipboard of the desktop session, accessed
+// with wl-paste and wl-copy under Wayland, or else with xclip or xsel.
+func newSystemClipboard() (clipboard, error) {
+	if _, err := exec.LookPath("wl-paste"); err == nil && os.Getenv("WAYLAND_DISPLAY") != "" {
+		return execClipboard{paste: []string{"wl-paste", "--no-newline"}, copy: []string{"wl-copy"}}, nil
+	}
+	if _, err := exec.LookPath("xclip"); err == nil {
+		return execClipboard{paste: []string{"xclip", "-selection", "clipboard", "-o"}, copy: []string{"xclip", "-selection", "clipboard"}}, nil
+	}
+	if _, err := exec.LookPath("xsel"); err == nil {
+		return execClipboard{paste: []string{"xsel", "--clipboard", "--output"}, copy: []string{"xsel", "--clipboard", "--input"}}, nil
+	}
+	return nil, errors.New("no clipboard tool found; install wl-clipboard, xclip or xsel")
+}
@@ -0,0 +1,9 @@
+package main
+
+// newSystemClipboard returns the Windows clipboard, accessed through PowerShell.
+func newSystemClipboard() (clipboard, error) {
+	return execClipboard{
+		paste: []string{"powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw"},
+		copy:  []string{"powershell", "-NoProfile", "-Command", "[Console]::In.ReadToEnd() | Set-Clipboard"},
+	}, nil
+}
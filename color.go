@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// Color modes accepted by the -color flag.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// ANSI escape sequences used for the stdout previews.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiKey     = "\x1b[34m" // Blue: JSON object keys.
+	ansiString  = "\x1b[32m" // Green: JSON string values.
+	ansiNumber  = "\x1b[36m" // Cyan: JSON numbers.
+	ansiLiteral = "\x1b[35m" // Magenta: true, false and null.
+	ansiEscape  = "\x1b[33m" // Yellow: escape sequences in reprBytes output.
+)
+
+// resolveColor turns a -color mode into whether previews should be colorized.
+// In auto mode, color is used when the previews writer is a terminal, NO_COLOR
+// is unset and TERM is not "dumb".
+func resolveColor(mode string) bool {
+	switch mode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	f, ok := previews.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// paint wraps s in the given ANSI color.
+func paint(color, s string) string {
+	return color + s + ansiReset
+}
+
+// colorizeRepr highlights the backslash escape sequences of a reprBytes string.
+func colorizeRepr(s string) string {
+	var sb strings.Builder
+	// Skip the b' prefix and the closing quote so only the content is scanned.
+	if !strings.HasPrefix(s, "b'") || !strings.HasSuffix(s, "'") || len(s) < 3 {
+		return s
+	}
+	sb.WriteString("b'")
+	body := s[2 : len(s)-1]
+	for i := 0; i < len(body); {
+		if body[i] != '\\' || i+1 >= len(body) {
+			sb.WriteByte(body[i])
+			i++
+			continue
+		}
+		n := 2 // \n, \r, \t, \', \\
+		if body[i+1] == 'x' {
+			n = 4 // \xHH
+		}
+		n = min(n, len(body)-i)
+		sb.WriteString(paint(ansiEscape, body[i:i+n]))
+		i += n
+	}
+	sb.WriteString("'")
+	return sb.String()
+}
+
+// colorizeJSON highlights the keys, strings, numbers and literals of a JSON text.
+func colorizeJSON(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(s))
+			// A string followed by a colon is an object key.
+			k := j
+			for k < len(s) && (s[k] == ' ' || s[k] == '\t' || s[k] == '\n' || s[k] == '\r') {
+				k++
+			}
+			color := ansiString
+			if k < len(s) && s[k] == ':' {
+				color = ansiKey
+			}
+			sb.WriteString(paint(color, s[i:j]))
+			i = j
+		case c == '-' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(s) && strings.IndexByte("0123456789+-.eE", s[j]) >= 0 {
+				j++
+			}
+			sb.WriteString(paint(ansiNumber, s[i:j]))
+			i = j
+		case strings.HasPrefix(s[i:], "true"), strings.HasPrefix(s[i:], "null"):
+			sb.WriteString(paint(ansiLiteral, s[i:i+4]))
+			i += 4
+		case strings.HasPrefix(s[i:], "false"):
+			sb.WriteString(paint(ansiLiteral, s[i:i+5]))
+			i += 5
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String()
+}
+
+// previewRepr returns reprBytes(b) for a stdout preview, wrapped at
+// opts.ReprWidth and colorized when opts.Color is set.
+func previewRepr(b []byte, opts Options) string {
+	lines := reprLines(b, opts.ReprWidth)
+	if opts.Color {
+		for i, line := range lines {
+			lines[i] = colorizeRepr(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// previewJSON returns the JSON text s for a stdout preview, colorized when opts.Color is set.
+func previewJSON(s []byte, opts Options) string {
+	if opts.Color {
+		return colorizeJSON(string(s))
+	}
+	return string(s)
+}
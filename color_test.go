@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestColorizeJSON tests the colorizeJSON function.
+func TestColorizeJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"empty object", "{}", "{}"},
+		{"key and string", `{"k": "v"}`, "{" + paint(ansiKey, `"k"`) + ": " + paint(ansiString, `"v"`) + "}"},
+		{"escaped quote in string", `["a\"b"]`, "[" + paint(ansiString, `"a\"b"`) + "]"},
+		{"numbers", `[1, -2.5e3]`, "[" + paint(ansiNumber, "1") + ", " + paint(ansiNumber, "-2.5e3") + "]"},
+		{"literals", `[true, false, null]`, "[" + paint(ansiLiteral, "true") + ", " + paint(ansiLiteral, "false") + ", " + paint(ansiLiteral, "null") + "]"},
+		{"indented key", "{\n  \"a\": 1\n}", "{\n  " + paint(ansiKey, `"a"`) + ": " + paint(ansiNumber, "1") + "\n}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := colorizeJSON(tt.input); got != tt.expected {
+				t.Errorf("colorizeJSON(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestColorizeRepr tests the colorizeRepr function.
+func TestColorizeRepr(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		{"empty", []byte{}, "b''"},
+		{"plain", []byte("abc"), "b'abc'"},
+		{"escapes", []byte("a\n\x1f'"), "b'a" + paint(ansiEscape, `\n`) + paint(ansiEscape, `\x1f`) + paint(ansiEscape, `\'`) + "'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := colorizeRepr(reprBytes(tt.input)); got != tt.expected {
+				t.Errorf("colorizeRepr(reprBytes(%q)) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestResolveColor tests the explicit modes of resolveColor.
+func TestResolveColor(t *testing.T) {
+	if !resolveColor(colorAlways) {
+		t.Errorf("resolveColor(%q) = false; want true", colorAlways)
+	}
+	if resolveColor(colorNever) {
+		t.Errorf("resolveColor(%q) = true; want false", colorNever)
+	}
+	t.Setenv("NO_COLOR", "1")
+	if resolveColor(colorAuto) {
+		t.Errorf("resolveColor(%q) with NO_COLOR set = true; want false", colorAuto)
+	}
+}
+
+// TestRunOutputIsNeverColorized tests that colorized previews do not leak into the output.
+func TestRunOutputIsNeverColorized(t *testing.T) {
+	got, err := Run("curl 'url' --data-raw $'{\"a\":true}'", Options{Color: true})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if strings.Contains(string(got), "\x1b[") {
+		t.Errorf("Run() output %q contains ANSI escape sequences", got)
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Compression algorithms reported by detectCompression.
+const (
+	algoNone    = ""
+	algoGzip    = "gzip"
+	algoDeflate = "deflate" // HTTP "deflate", i.e. a zlib-wrapped DEFLATE stream
+	algoLZ4     = "lz4"     // LZ4 frame format
+	algoSnappy  = "snappy"  // Snappy framing format
+	// algoSnappyRaw is a raw snappy block. It has no magic bytes, so it is
+	// never detected and only used with -snappy-raw.
+	algoSnappyRaw = "snappy-raw"
+	// algoDeflateRaw is a DEFLATE stream without the zlib header. It has no
+	// magic bytes either, so it is only used when forced with -decompress.
+	algoDeflateRaw = "deflate-raw"
+)
+
+// gzipReaders and zlibReaders pool the readers of decompressGzipData and
+// decompressDeflateData, so decoding many bodies (e.g. with -repl) reuses
+// their buffers and decoding tables instead of allocating new ones per body.
+// sync.Pool makes this safe for concurrent callers.
+var (
+	gzipReaders sync.Pool // *gzip.Reader
+	zlibReaders sync.Pool // io.ReadCloser that also implements zlib.Resetter
+)
+
+// maxLeadingWhitespace is the number of leading ASCII whitespace bytes
+// detectCompression is willing to skip while looking for magic bytes.
+const maxLeadingWhitespace = 8
+
+// detectCompression sniffs the magic bytes at the start of data and returns the
+// compression algorithm, or algoNone if none is recognised. A short run of
+// leading ASCII whitespace (which survives when trimming is disabled or when it
+// was written as escapes) is tolerated; skip reports how many bytes of it must
+// be dropped before decompressing.
+func detectCompression(data []byte) (algorithm string, skip int) {
+	for skip < len(data) && skip < maxLeadingWhitespace && isASCIISpace(data[skip]) {
+		skip++
+	}
+	rest := data[skip:]
+	switch {
+	case len(rest) >= 2 && rest[0] == 0x1f && rest[1] == 0x8b:
+		return algoGzip, skip
+	case len(rest) >= 2 && rest[0] == 0x78 && (rest[1] == 0x01 || rest[1] == 0x5e || rest[1] == 0x9c || rest[1] == 0xda):
+		return algoDeflate, skip
+	case bytes.HasPrefix(rest, lz4FrameMagic):
+		return algoLZ4, skip
+	case bytes.HasPrefix(rest, snappyStreamMagic):
+		return algoSnappy, skip
+	}
+	return algoNone, 0
+}
+
+// minBase64CompressedLength is the shortest base64 text detectBase64Compression
+// considers, so short tokens that happen to decode to magic bytes are ignored.
+const minBase64CompressedLength = 16
+
+// detectBase64Compression reports whether data is base64 text (standard or
+// URL-safe alphabet, with or without padding) that decodes to compressed data.
+// It returns the decoded bytes and their compression algorithm, or algoNone
+// when data is not base64 or the decoded bytes carry no known magic bytes, so
+// plain base64 text is left alone.
+func detectBase64Compression(data []byte) (decoded []byte, algorithm string) {
+	text := bytes.TrimSpace(data)
+	if len(text) < minBase64CompressedLength {
+		return nil, algoNone
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		decoded, err := enc.DecodeString(string(text))
+		if err != nil {
+			continue
+		}
+		if algorithm, skip := detectCompression(decoded); algorithm != algoNone && skip == 0 {
+			return decoded, algorithm
+		}
+		return nil, algoNone
+	}
+	return nil, algoNone
+}
+
+// isASCIISpace reports whether b is one of the ASCII whitespace characters removed by strings.TrimSpace.
+func isASCIISpace(b byte) bool {
+	switch b {
+	case ' ', '\t', '\n', '\r', '\v', '\f':
+		return true
+	}
+	return false
+}
+
+// decompressDeflateData decompresses zlib-wrapped DEFLATE data (HTTP Content-Encoding: deflate).
+func decompressDeflateData(data []byte) ([]byte, error) {
+	reader := bytes.NewReader(data)
+	zReader, ok := zlibReaders.Get().(io.ReadCloser)
+	var err error
+	if ok {
+		err = zReader.(zlib.Resetter).Reset(reader, nil)
+	} else {
+		zReader, err = zlib.NewReader(reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decompressDeflateData: failed to create zlib reader: %w", err)
+	}
+	defer func() {
+		zReader.Close()
+		zlibReaders.Put(zReader)
+	}()
+
+	decompressedData, err := io.ReadAll(zReader)
+	if err != nil {
+		return nil, fmt.Errorf("decompressDeflateData: failed to decompress data: %w", err)
+	}
+	return decompressedData, nil
+}
+
+// decompressRawDeflateData decompresses a bare DEFLATE stream (RFC 1951),
+// as sent by clients that skip the zlib wrapper HTTP "deflate" calls for.
+func decompressRawDeflateData(data []byte) ([]byte, error) {
+	fReader := flate.NewReader(bytes.NewReader(data))
+	defer fReader.Close()
+	decompressedData, err := io.ReadAll(fReader)
+	if err != nil {
+		return nil, fmt.Errorf("decompressRawDeflateData: failed to decompress data: %w", err)
+	}
+	return decompressedData, nil
+}
+
+// defaultGzipLevel is the -gzip-level used unless another one is given; it is
+// the level compress/gzip's DefaultCompression stands for.
+const defaultGzipLevel = 6
+
+// compressGzipData gzips data at the given level, from 0 (no compression) to
+// 9 (best compression).
+func compressGzipData(data []byte, level int) ([]byte, error) {
+	return compressGzipDataWithHeader(data, level, nil)
+}
+
+// compressGzipDataWithHeader is compressGzipData writing the FNAME, comment,
+// modification time and OS fields of header into the gzip header. A nil
+// header leaves compress/gzip's defaults: no name, no time and OS unknown.
+func compressGzipDataWithHeader(data []byte, level int, header *gzip.Header) ([]byte, error) {
+	if level < gzip.NoCompression || level > gzip.BestCompression {
+		return nil, fmt.Errorf("compressGzipData: invalid level %d (want 0-9)", level)
+	}
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, fmt.Errorf("compressGzipData: %w", err)
+	}
+	if header != nil {
+		w.Name, w.Comment, w.ModTime, w.OS = header.Name, header.Comment, header.ModTime, header.OS
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("compressGzipData: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("compressGzipData: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// gzipHeaderOf returns the header of the gzip stream data starts with.
+func gzipHeaderOf(data []byte) (*gzip.Header, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	header := r.Header
+	return &header, nil
+}
+
+// decompressors are the decompression functions keyed by the algorithm names
+// detectCompression reports.
+var decompressors = map[string]func(data []byte) ([]byte, error){
+	algoGzip:    decompressGzipData,
+	algoDeflate: decompressDeflateData,
+	algoLZ4:     decompressLZ4Data,
+	algoSnappy:  decompressSnappyData,
+
+	algoSnappyRaw:  decompressSnappyBlock,
+	algoDeflateRaw: decompressRawDeflateData,
+}
+
+// decompressorNames returns the supported compression algorithms in sorted order.
+func decompressorNames() []string {
+	names := make([]string, 0, len(decompressors))
+	for name := range decompressors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Special -decompress values besides the decompressor names.
+const (
+	DecompressAuto = "auto" // detect the algorithm from the magic bytes
+	DecompressNone = "none" // never decompress
+)
+
+// unsupportedDecompressors are algorithms -decompress recognises but this
+// build, which only uses the standard library, cannot decompress.
+var unsupportedDecompressors = map[string]bool{
+	"brotli": true,
+	"zstd":   true,
+}
+
+// validateDecompress checks a -decompress value.
+func validateDecompress(mode string) error {
+	if _, ok := decompressors[mode]; ok || mode == "" || mode == DecompressAuto || mode == DecompressNone {
+		return nil
+	}
+	if unsupportedDecompressors[mode] {
+		return fmt.Errorf("%s decompression is not supported by this build", mode)
+	}
+	return fmt.Errorf("unknown algorithm %q (want %s, %s or one of %s)", mode, DecompressAuto, DecompressNone, strings.Join(decompressorNames(), ", "))
+}
+
+// forcedCompression returns the algorithm to decompress data with when
+// -decompress names one. Forcing deflate accepts both forms: a stream with a
+// valid zlib header is zlib-wrapped, anything else is taken as raw DEFLATE.
+func forcedCompression(mode string, data []byte) string {
+	if mode == algoDeflate && !hasZlibHeader(data) {
+		return algoDeflateRaw
+	}
+	return mode
+}
+
+// hasZlibHeader reports whether data starts with a valid zlib header (RFC
+// 1950): the DEFLATE method with a window of at most 32K, and a check value
+// making the first two bytes a multiple of 31.
+func hasZlibHeader(data []byte) bool {
+	return len(data) >= 2 && data[0]&0x0f == 8 && data[0]>>4 <= 7 && (uint16(data[0])<<8|uint16(data[1]))%31 == 0
+}
+
+// decompressData decompresses data with the given algorithm as returned by detectCompression.
+func decompressData(algorithm string, data []byte) ([]byte, error) {
+	decompress, ok := decompressors[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("decompressData: unsupported compression algorithm %q", algorithm)
+	}
+	return decompress(data)
+}
+
+// contentEncodingAlgorithm maps a Content-Encoding header value to the
+// algorithm it declares: algoNone for identity or no header, algoGzip or
+// algoDeflate, or the lowercased coding itself when it is not supported. With
+// several codings the last one, which was applied last, is used.
+func contentEncodingAlgorithm(header string) string {
+	codings := strings.Split(header, ",")
+	coding := strings.ToLower(strings.TrimSpace(codings[len(codings)-1]))
+	switch coding {
+	case "", "identity":
+		return algoNone
+	case "gzip", "x-gzip":
+		return algoGzip
+	case "deflate":
+		return algoDeflate
+	default:
+		return coding
+	}
+}
+
+// reconcileContentEncoding compares the compression declared by the
+// Content-Encoding header with the one detected from the magic bytes and logs
+// any discrepancy. The header is only a hint: the body is decompressed when the
+// magic bytes say so, whatever the header claims, and left alone when they do
+// not, even if the header declares a compression.
+func reconcileContentEncoding(header, detected string) {
+	declared := contentEncodingAlgorithm(header)
+	switch {
+	case declared == detected:
+	case declared == algoNone:
+		if header != "" {
+			logger.Warn(fmt.Sprintf("Content-Encoding is %q but the body has %s magic bytes; decompressing anyway.", header, detected), field("content_encoding", header), field("algorithm", detected))
+		}
+	case detected == algoNone:
+		logger.Warn(fmt.Sprintf("Content-Encoding is %q but the body has no matching magic bytes; skipping decompression.", header), field("content_encoding", header))
+	default:
+		logger.Warn(fmt.Sprintf("Content-Encoding is %q but the body has %s magic bytes; decompressing it as %s.", header, detected, detected), field("content_encoding", header), field("algorithm", detected))
+	}
+}
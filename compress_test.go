@@ -0,0 +1,536 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// gzipBytes gzips data for use as a test fixture.
+func gzipBytes(t testing.TB, data string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	if _, err := gz.Write([]byte(data)); err != nil {
+		t.Fatalf("Failed to gzip data: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip writer: %v", err)
+	}
+	return b.Bytes()
+}
+
+// zlibBytes zlib-compresses data for use as a test fixture.
+func zlibBytes(t testing.TB, data string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		t.Fatalf("Failed to zlib data: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close zlib writer: %v", err)
+	}
+	return b.Bytes()
+}
+
+// hexEscape renders every byte of b as a \xHH escape, like a DevTools $'...' export.
+func hexEscape(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		fmt.Fprintf(&sb, "\\x%02x", c)
+	}
+	return sb.String()
+}
+
+// flateBytes compresses data as a raw DEFLATE stream, without the zlib
+// header, for use as a test fixture.
+func flateBytes(t testing.TB, data string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	fw, err := flate.NewWriter(&b, flate.DefaultCompression)
+	if err != nil {
+		t.Fatalf("Failed to create flate writer: %v", err)
+	}
+	if _, err := fw.Write([]byte(data)); err != nil {
+		t.Fatalf("Failed to deflate data: %v", err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("Failed to close flate writer: %v", err)
+	}
+	return b.Bytes()
+}
+
+// TestDetectCompression tests the detectCompression function.
+func TestDetectCompression(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        []byte
+		expectedAlgo string
+		expectedSkip int
+	}{
+		{"empty", []byte{}, algoNone, 0},
+		{"plain text", []byte("hello"), algoNone, 0},
+		{"gzip magic", []byte{0x1f, 0x8b, 0x08}, algoGzip, 0},
+		{"zlib default", []byte{0x78, 0x9c, 0x00}, algoDeflate, 0},
+		{"zlib best", []byte{0x78, 0xda, 0x00}, algoDeflate, 0},
+		{"x followed by text", []byte("xyz"), algoNone, 0},
+		{"space before gzip", []byte{' ', 0x1f, 0x8b}, algoGzip, 1},
+		{"crlf before gzip", []byte{'\r', '\n', 0x1f, 0x8b}, algoGzip, 2},
+		{"whitespace only", []byte("   "), algoNone, 0},
+		{"too much whitespace", append(bytes.Repeat([]byte{' '}, maxLeadingWhitespace+1), 0x1f, 0x8b), algoNone, 0},
+		{"truncated magic", []byte{' ', 0x1f}, algoNone, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			algo, skip := detectCompression(tt.input)
+			if algo != tt.expectedAlgo || skip != tt.expectedSkip {
+				t.Errorf("detectCompression(%v) = (%q, %d); want (%q, %d)", tt.input, algo, skip, tt.expectedAlgo, tt.expectedSkip)
+			}
+		})
+	}
+}
+
+// TestDecompressDeflateData tests the decompressDeflateData function.
+func TestDecompressDeflateData(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expected    []byte
+		expectError bool
+		errorMsg    string
+	}{
+		{"valid zlib data", zlibBytes(t, "hello world"), []byte("hello world"), false, ""},
+		{"empty zlib data", zlibBytes(t, ""), []byte(""), false, ""},
+		{"non-zlib data", []byte("just plain text"), nil, true, "failed to create zlib reader"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decompressDeflateData(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("decompressDeflateData() for %s should have returned an error, but got nil", tt.name)
+				} else if tt.errorMsg != "" && !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("decompressDeflateData() for %s error = %q, want error containing %q", tt.name, err.Error(), tt.errorMsg)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("decompressDeflateData() for %s returned an unexpected error: %v", tt.name, err)
+				}
+				if !bytes.Equal(got, tt.expected) {
+					t.Errorf("decompressDeflateData() for %s = %s; want %s", tt.name, string(got), string(tt.expected))
+				}
+			}
+		})
+	}
+}
+
+// TestRunDecompressesWithLeadingWhitespace tests that Run still decompresses
+// payloads whose magic bytes are preceded by whitespace.
+func TestRunDecompressesWithLeadingWhitespace(t *testing.T) {
+	body := `{"a":1}`
+	expected := "{\n  \"a\": 1\n}"
+	tests := []struct {
+		name        string
+		curlCommand string
+		opts        Options
+	}{
+		{"space-prefixed gzip with -no-trim", "curl 'url' --data-raw $' " + hexEscape(gzipBytes(t, body)) + "'", Options{NoTrim: true}},
+		{"escaped space before gzip", "curl 'url' --data-raw $'\\x20" + hexEscape(gzipBytes(t, body)) + "'", Options{}},
+		{"newline-prefixed zlib", "curl 'url' --data-raw $'\\n" + hexEscape(zlibBytes(t, body)) + "'", Options{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(tt.curlCommand, tt.opts)
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			if string(got) != expected {
+				t.Errorf("Run() = %q; want %q", got, expected)
+			}
+		})
+	}
+}
+
+// TestDetectBase64Compression tests the detectBase64Compression function.
+func TestDetectBase64Compression(t *testing.T) {
+	gz := gzipBytes(t, `{"event":"view"}`)
+	tests := []struct {
+		name         string
+		input        string
+		expectedAlgo string
+		expected     []byte
+	}{
+		{"standard padded", base64.StdEncoding.EncodeToString(gz), algoGzip, gz},
+		{"standard unpadded", base64.RawStdEncoding.EncodeToString(gz), algoGzip, gz},
+		{"url-safe padded", base64.URLEncoding.EncodeToString(gz), algoGzip, gz},
+		{"url-safe unpadded", base64.RawURLEncoding.EncodeToString(gz), algoGzip, gz},
+		{"surrounding whitespace", "\n" + base64.StdEncoding.EncodeToString(gz) + "\n", algoGzip, gz},
+		{"zlib", base64.StdEncoding.EncodeToString(zlibBytes(t, "hi")), algoDeflate, zlibBytes(t, "hi")},
+		{"plain base64 text", base64.StdEncoding.EncodeToString([]byte("just some base64 text")), algoNone, nil},
+		{"too short", "H4sIAA==", algoNone, nil},
+		{"not base64", `{"event":"view","x":1}`, algoNone, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, algo := detectBase64Compression([]byte(tt.input))
+			if algo != tt.expectedAlgo || !bytes.Equal(got, tt.expected) {
+				t.Errorf("detectBase64Compression(%q) = (%x, %q); want (%x, %q)", tt.input, got, algo, tt.expected, tt.expectedAlgo)
+			}
+		})
+	}
+}
+
+// TestRunDecodesBase64Gzip tests that Run decompresses a base64(gzip(json)) body.
+func TestRunDecodesBase64Gzip(t *testing.T) {
+	command := "curl 'url' --data-raw '" + base64.StdEncoding.EncodeToString(gzipBytes(t, `{"a":1}`)) + "'"
+	got, err := Run(command, Options{})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if expected := "{\n  \"a\": 1\n}"; string(got) != expected {
+		t.Errorf("Run() = %q; want %q", got, expected)
+	}
+
+	plain := "curl 'url' --data-raw '" + base64.StdEncoding.EncodeToString([]byte("plain base64 payload")) + "'"
+	got, err = Run(plain, Options{})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if expected := base64.StdEncoding.EncodeToString([]byte("plain base64 payload")); string(got) != expected {
+		t.Errorf("Run() = %q; want the base64 text unchanged %q", got, expected)
+	}
+}
+
+// TestContentEncodingAlgorithm tests the contentEncodingAlgorithm function.
+func TestContentEncodingAlgorithm(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", algoNone},
+		{"identity", algoNone},
+		{"gzip", algoGzip},
+		{"X-GZIP", algoGzip},
+		{"deflate", algoDeflate},
+		{"br", "br"},
+		{"br, gzip", algoGzip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := contentEncodingAlgorithm(tt.header); got != tt.expected {
+				t.Errorf("contentEncodingAlgorithm(%q) = %q; want %q", tt.header, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunContentEncodingMismatch tests that magic bytes win over a
+// contradicting Content-Encoding header and that the discrepancy is logged.
+func TestRunContentEncodingMismatch(t *testing.T) {
+	gzipped := "$'" + hexEscape(gzipBytes(t, `{"a":1}`)) + "'"
+	tests := []struct {
+		name            string
+		command         string
+		expected        string
+		expectedWarning string
+	}{
+		{"identity but gzip magic", "curl 'u' -H 'Content-Encoding: identity' --data-raw " + gzipped, `{"a":1}`, `Content-Encoding is "identity" but the body has gzip magic bytes; decompressing anyway.`},
+		{"gzip without magic", `curl 'u' -H 'Content-Encoding: gzip' --data-raw $'{"a":1}'`, `{"a":1}`, `Content-Encoding is "gzip" but the body has no matching magic bytes; skipping decompression.`},
+		{"deflate but gzip magic", "curl 'u' -H 'Content-Encoding: deflate' --data-raw " + gzipped, `{"a":1}`, `Content-Encoding is "deflate" but the body has gzip magic bytes; decompressing it as gzip.`},
+		{"matching header", "curl 'u' -H 'Content-Encoding: gzip' --data-raw " + gzipped, `{"a":1}`, ""},
+		{"no header", "curl 'u' --data-raw " + gzipped, `{"a":1}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			saved := logger
+			logger = newLogger(&logs, logFormatText)
+			defer func() { logger = saved }()
+
+			got, err := Run(tt.command, Options{Canonical: true})
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %q; want %q", got, tt.expected)
+			}
+			if tt.expectedWarning == "" {
+				if strings.Contains(logs.String(), "Content-Encoding") {
+					t.Errorf("Run() logged an unexpected discrepancy: %s", logs.String())
+				}
+			} else if !strings.Contains(logs.String(), "Warning: "+tt.expectedWarning) {
+				t.Errorf("Run() logs = %q; want the warning %q", logs.String(), tt.expectedWarning)
+			}
+		})
+	}
+}
+
+// TestCompressGzipData tests that compressGzipData produces valid gzip streams at several levels.
+func TestCompressGzipData(t *testing.T) {
+	data := []byte(strings.Repeat(`{"event":"click","x":1},`, 200))
+	tests := []struct {
+		level       int
+		expectError bool
+	}{
+		{0, false},
+		{1, false},
+		{6, false},
+		{9, false},
+		{-1, true},
+		{10, true},
+	}
+
+	sizes := map[int]int{}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("level %d", tt.level), func(t *testing.T) {
+			got, err := compressGzipData(data, tt.level)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("compressGzipData(level %d) error = %v; expectError %v", tt.level, err, tt.expectError)
+			}
+			if tt.expectError {
+				return
+			}
+			if algorithm, _ := detectCompression(got); algorithm != algoGzip {
+				t.Errorf("compressGzipData(level %d) output has no gzip magic bytes", tt.level)
+			}
+			decompressed, err := decompressGzipData(got)
+			if err != nil {
+				t.Fatalf("decompressGzipData() of level %d output failed: %v", tt.level, err)
+			}
+			if !bytes.Equal(decompressed, data) {
+				t.Errorf("level %d round trip changed the data", tt.level)
+			}
+			sizes[tt.level] = len(got)
+		})
+	}
+	if sizes[0] <= sizes[9] {
+		t.Errorf("level 0 output (%d bytes) should be larger than level 9 output (%d bytes)", sizes[0], sizes[9])
+	}
+}
+
+// TestRunRecompress tests -recompress with and without keeping the original gzip header.
+func TestRunRecompress(t *testing.T) {
+	var original bytes.Buffer
+	w := gzip.NewWriter(&original)
+	w.Name, w.OS = "body.json", 3
+	w.Write([]byte(`{"a":1}`))
+	w.Close()
+	command := "curl 'u' --data-raw $'" + hexEscape(original.Bytes()) + "'"
+
+	tests := []struct {
+		name         string
+		keepHeader   bool
+		expectedName string
+		expectedOS   byte
+	}{
+		{"default header", false, "", 255},
+		{"original header kept", true, "body.json", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(command, Options{Recompress: true, GzipLevel: 9, KeepGzipHeader: tt.keepHeader})
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			header, err := gzipHeaderOf(got)
+			if err != nil {
+				t.Fatalf("Run() output is not gzip: %v", err)
+			}
+			if header.Name != tt.expectedName || header.OS != tt.expectedOS {
+				t.Errorf("recompressed header Name=%q OS=%d; want %q and %d", header.Name, header.OS, tt.expectedName, tt.expectedOS)
+			}
+			if body, err := decompressGzipData(got); err != nil || string(body) != `{"a":1}` {
+				t.Errorf("recompressed body = %q, %v; want %q", body, err, `{"a":1}`)
+			}
+		})
+	}
+}
+
+// TestDecompressConcurrently tests that the pooled gzip and zlib readers can
+// be used from several goroutines at once, including after a failed Reset.
+func TestDecompressConcurrently(t *testing.T) {
+	const workers = 8
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			for i := 0; i < 50; i++ {
+				body := fmt.Sprintf(`{"worker":%d,"i":%d}`, w, i)
+				for _, tc := range []struct {
+					algorithm string
+					data      []byte
+				}{{algoGzip, gzipBytes(t, body)}, {algoDeflate, zlibBytes(t, body)}} {
+					got, err := decompressData(tc.algorithm, tc.data)
+					if err != nil || string(got) != body {
+						errs <- fmt.Errorf("decompressData(%s) = (%q, %v); want %q", tc.algorithm, got, err, body)
+						return
+					}
+					if _, err := decompressData(tc.algorithm, []byte("not compressed")); err == nil {
+						errs <- fmt.Errorf("decompressData(%s) of garbage succeeded", tc.algorithm)
+						return
+					}
+				}
+			}
+			errs <- nil
+		}(w)
+	}
+	for w := 0; w < workers; w++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// BenchmarkDecompressGzipData compares decompressGzipData, which reuses
+// pooled readers, with creating a new gzip.Reader for every body, on the
+// small bodies typical of captured requests.
+func BenchmarkDecompressGzipData(b *testing.B) {
+	data := gzipBytes(b, strings.Repeat(`{"event":"page_view","path":"/docs/getting-started","ts":1700000000},`, 20))
+	b.Run("pooled", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := decompressGzipData(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("new-reader", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := io.ReadAll(r); err != nil {
+				b.Fatal(err)
+			}
+			r.Close()
+		}
+	})
+}
+
+// TestRunIgnoreGzipCRC tests the -ignore-gzip-crc option of Decode.
+func TestRunIgnoreGzipCRC(t *testing.T) {
+	body := `{"a":1}`
+	badCRC := gzipBytes(t, body)
+	badCRC[len(badCRC)-8] ^= 0xff
+	badLength := gzipBytes(t, body)
+	badLength[len(badLength)-1] = 0x7f
+	tests := []struct {
+		name                 string
+		data                 []byte
+		ignoreCRC            bool
+		expectedDecompressed string
+		expectedWarnings     int
+	}{
+		{"corrupted CRC is an error by default", badCRC, false, string(badCRC), 0},
+		{"corrupted CRC ignored", badCRC, true, body, 1},
+		{"corrupted length ignored", badLength, true, body, 1},
+		{"intact stream", gzipBytes(t, body), true, body, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Decode("curl 'u' --data-raw $'"+hexEscape(tt.data)+"'", Options{IgnoreGzipCRC: tt.ignoreCRC})
+			if err != nil {
+				t.Fatalf("Decode() returned an unexpected error: %v", err)
+			}
+			if string(res.Decompressed) != tt.expectedDecompressed {
+				t.Errorf("Decode() decompressed = %q; want %q", res.Decompressed, tt.expectedDecompressed)
+			}
+			if len(res.Warnings) != tt.expectedWarnings {
+				t.Errorf("Decode() warnings = %q; want %d", res.Warnings, tt.expectedWarnings)
+			}
+		})
+	}
+}
+
+// TestValidateDecompress tests the validateDecompress function.
+func TestValidateDecompress(t *testing.T) {
+	tests := []struct {
+		mode     string
+		errorMsg string
+	}{
+		{"", ""},
+		{DecompressAuto, ""},
+		{DecompressNone, ""},
+		{algoGzip, ""},
+		{algoDeflate, ""},
+		{algoDeflateRaw, ""},
+		{"zstd", "not supported by this build"},
+		{"brotli", "not supported by this build"},
+		{"rar", `unknown algorithm "rar"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			err := validateDecompress(tt.mode)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("validateDecompress(%q) returned an unexpected error: %v", tt.mode, err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("validateDecompress(%q) error = %v; want error containing %q", tt.mode, err, tt.errorMsg)
+			}
+		})
+	}
+}
+
+// TestRunForcedDecompress tests that -decompress overrides the magic-byte
+// detection, including a raw DEFLATE stream that detection misses.
+func TestRunForcedDecompress(t *testing.T) {
+	body := `{"a":1}`
+	rawDeflate := flateBytes(t, body)
+	tests := []struct {
+		name                 string
+		data                 []byte
+		decompress           string
+		expectedDecompressed string
+		expectedAlgorithm    string
+	}{
+		{"auto misses raw deflate", rawDeflate, DecompressAuto, string(rawDeflate), algoNone},
+		{"forced deflate on raw deflate", rawDeflate, algoDeflate, body, algoDeflateRaw},
+		{"forced deflate-raw", rawDeflate, algoDeflateRaw, body, algoDeflateRaw},
+		{"forced deflate on zlib", zlibBytes(t, body), algoDeflate, body, algoDeflate},
+		{"none keeps gzip compressed", gzipBytes(t, body), DecompressNone, string(gzipBytes(t, body)), algoNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Decode("curl 'u' --data-raw $'"+hexEscape(tt.data)+"'", Options{Decompress: tt.decompress})
+			if err != nil {
+				t.Fatalf("Decode() returned an unexpected error: %v", err)
+			}
+			if string(res.Decompressed) != tt.expectedDecompressed {
+				t.Errorf("Decode() decompressed = %q; want %q", res.Decompressed, tt.expectedDecompressed)
+			}
+			if res.Algorithm != tt.expectedAlgorithm {
+				t.Errorf("Decode() algorithm = %q; want %q", res.Algorithm, tt.expectedAlgorithm)
+			}
+		})
+	}
+
+	t.Run("forced gzip on plain text", func(t *testing.T) {
+		_, err := Decode(`curl 'u' --data-raw $'{"a":1}'`, Options{Decompress: algoGzip})
+		var decompressErr *DecompressError
+		if !errors.As(err, &decompressErr) {
+			t.Errorf("Decode() error = %v; want a *DecompressError", err)
+		}
+	})
+}
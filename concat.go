@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// extractPayloads returns the arguments of every data option of a cURL
+// command, in order, for -binary-concat. Unlike extractPayload it always
+// tokenizes the command, since the body may be split over several options.
+func extractPayloads(curlCommand string, dataFlags []string) ([]Token, error) {
+	tokens, err := tokenizeCurl(curlCommand)
+	if err != nil {
+		return nil, err
+	}
+	isData := withDataFlags(dataFlags)
+	flags, _ := scanFlagsWith(tokens, isData)
+	var payloads []Token
+	for _, f := range flags {
+		if isData[f.Name] && f.HasValue {
+			payloads = append(payloads, f.Value)
+		}
+	}
+	if len(payloads) == 0 {
+		return nil, errors.New("failed to extract data-raw part: no data option found")
+	}
+	return payloads, nil
+}
+
+// concatPayloads joins the data option arguments of a body split over several
+// options (--data-raw $'\x1f\x8b...' -d $'...') byte for byte, where curl
+// would insert a '&' between them, so a binary stream stays intact. When any
+// argument is $'...' quoted, the others are escaped with escapeANSIC so the
+// result decodes as one ANSI-C payload.
+func concatPayloads(payloads []Token) Token {
+	joined := Token{Start: payloads[0].Start, End: payloads[len(payloads)-1].End}
+	for _, p := range payloads {
+		joined.ANSIC = joined.ANSIC || p.ANSIC
+	}
+	var b strings.Builder
+	for _, p := range payloads {
+		if joined.ANSIC && !p.ANSIC {
+			b.WriteString(escapeANSIC(p.Value))
+		} else {
+			b.WriteString(p.Value)
+		}
+	}
+	joined.Value = b.String()
+	return joined
+}
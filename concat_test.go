@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+)
+
+// TestConcatPayloads tests the concatPayloads function.
+func TestConcatPayloads(t *testing.T) {
+	tests := []struct {
+		name     string
+		payloads []Token
+		expected Token
+	}{
+		{"single", []Token{{Value: `\x1f`, ANSIC: true, Start: 3, End: 10}}, Token{Value: `\x1f`, ANSIC: true, Start: 3, End: 10}},
+		{"ansi-c segments", []Token{{Value: `\x1f`, ANSIC: true, Start: 0, End: 7}, {Value: `\x8b`, ANSIC: true, Start: 11, End: 18}}, Token{Value: `\x1f\x8b`, ANSIC: true, Start: 0, End: 18}},
+		{"plain segment escaped", []Token{{Value: `\x1f`, ANSIC: true}, {Value: `it's \x`}}, Token{Value: `\x1fit\'s \\x`, ANSIC: true}},
+		{"plain segments", []Token{{Value: "a=1"}, {Value: "b=2"}}, Token{Value: "a=1b=2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := concatPayloads(tt.payloads); got != tt.expected {
+				t.Errorf("concatPayloads() = %+v; want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunBinaryConcat tests that Options.BinaryConcat reassembles a gzip
+// stream split across two data options.
+func TestRunBinaryConcat(t *testing.T) {
+	escaped := hexEscape(gzipBytes(t, `{"a":1}`))
+	split := len(escaped) / 8 * 4 // On an escape boundary: each \xHH is 4 bytes.
+	tests := []struct {
+		name        string
+		command     string
+		expected    string
+		expectError bool
+	}{
+		{"split gzip", "curl u --data-raw $'" + escaped[:split] + "' -d $'" + escaped[split:] + "'", "{\n  \"a\": 1\n}", false},
+		{"three segments", "curl u --data-raw $'" + escaped[:4] + "' --data-binary $'" + escaped[4:split] + "' -d $'" + escaped[split:] + "'", "{\n  \"a\": 1\n}", false},
+		{"one data option", "curl u --data-raw $'" + escaped + "'", "{\n  \"a\": 1\n}", false},
+		{"plain segments", "curl u -d 'a=' -d 'b'", "a=b", false},
+		{"no data option", "curl u", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(tt.command, Options{BinaryConcat: true})
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Run() error = %v, expectError %v", err, tt.expectError)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
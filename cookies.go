@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// extractSetCookies parses the Set-Cookie headers in headers, as found in a
+// captured response, into cookies with their attributes (Path, Domain,
+// Expires, Max-Age, Secure, HttpOnly, SameSite). Malformed values are
+// skipped with a warning.
+func extractSetCookies(headers Headers) []*http.Cookie {
+	var cookies []*http.Cookie
+	for _, value := range headers.Values("Set-Cookie") {
+		cookie, err := http.ParseSetCookie(value)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Skipping malformed Set-Cookie header %q: %v", value, err), field("error", err))
+			continue
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies
+}
+
+// formatCookieHeader renders cookies as a Cookie header value, the form
+// taken by curl's -b option ("a=1; b=2"). Attributes are not sent back, and
+// a later cookie replaces an earlier one with the same name and path, as a
+// browser's cookie jar would.
+func formatCookieHeader(cookies []*http.Cookie) string {
+	var pairs []string
+	index := map[string]int{}
+	for _, c := range cookies {
+		key := c.Name + "\x00" + c.Path
+		if i, ok := index[key]; ok {
+			pairs[i] = c.Name + "=" + c.Value
+			continue
+		}
+		index[key] = len(pairs)
+		pairs = append(pairs, c.Name+"="+c.Value)
+	}
+	return strings.Join(pairs, "; ")
+}
+
+// emitCookies renders the Set-Cookie headers of r, such as a raw response
+// read with -input-format httpraw, as a curl -b option for a follow-up
+// request.
+func emitCookies(r *Request) ([]byte, error) {
+	cookies := extractSetCookies(r.Headers)
+	if len(cookies) == 0 {
+		return nil, errors.New("emitCookies: the input has no valid Set-Cookie headers")
+	}
+	return []byte("-b " + shellQuote(formatCookieHeader(cookies)) + "\n"), nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestExtractSetCookies tests the extractSetCookies function.
+func TestExtractSetCookies(t *testing.T) {
+	headers := Headers{
+		{"Content-Type", "text/html"},
+		{"Set-Cookie", "session=abc123; Path=/; HttpOnly; Secure"},
+		{"set-cookie", "theme=dark; Path=/app; Expires=Wed, 21 Oct 2026 07:28:00 GMT; Max-Age=3600"},
+		{"Set-Cookie", "=novalue"},
+		{"Set-Cookie", "lang=en"},
+	}
+	cookies := extractSetCookies(headers)
+	if len(cookies) != 3 {
+		t.Fatalf("extractSetCookies() returned %d cookies; want 3", len(cookies))
+	}
+	tests := []struct {
+		name         string
+		index        int
+		expectedName string
+		value        string
+		path         string
+		httpOnly     bool
+		secure       bool
+		expires      time.Time
+		maxAge       int
+	}{
+		{"flags", 0, "session", "abc123", "/", true, true, time.Time{}, 0},
+		{"expires and max-age", 1, "theme", "dark", "/app", false, false, time.Date(2026, 10, 21, 7, 28, 0, 0, time.UTC), 3600},
+		{"bare cookie", 2, "lang", "en", "", false, false, time.Time{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cookies[tt.index]
+			if c.Name != tt.expectedName || c.Value != tt.value || c.Path != tt.path {
+				t.Errorf("cookie = %s=%s (Path %q); want %s=%s (Path %q)", c.Name, c.Value, c.Path, tt.expectedName, tt.value, tt.path)
+			}
+			if c.HttpOnly != tt.httpOnly || c.Secure != tt.secure {
+				t.Errorf("cookie HttpOnly, Secure = %v, %v; want %v, %v", c.HttpOnly, c.Secure, tt.httpOnly, tt.secure)
+			}
+			if !c.Expires.Equal(tt.expires) || c.MaxAge != tt.maxAge {
+				t.Errorf("cookie Expires, MaxAge = %v, %d; want %v, %d", c.Expires, c.MaxAge, tt.expires, tt.maxAge)
+			}
+		})
+	}
+}
+
+// TestFormatCookieHeader tests the formatCookieHeader function.
+func TestFormatCookieHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  Headers
+		expected string
+	}{
+		{"several cookies", Headers{{"Set-Cookie", "a=1; Path=/"}, {"Set-Cookie", "b=2; HttpOnly"}}, "a=1; b=2"},
+		{"later cookie replaces earlier", Headers{{"Set-Cookie", "a=1; Path=/"}, {"Set-Cookie", "b=2"}, {"Set-Cookie", "a=3; Path=/"}}, "a=3; b=2"},
+		{"same name on different paths", Headers{{"Set-Cookie", "a=1; Path=/"}, {"Set-Cookie", "a=2; Path=/x"}}, "a=1; a=2"},
+		{"no cookies", Headers{{"Accept", "*/*"}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatCookieHeader(extractSetCookies(tt.headers)); got != tt.expected {
+				t.Errorf("formatCookieHeader() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunEmitCookies tests that Run emits the Set-Cookie headers of a raw response as a -b option.
+func TestRunEmitCookies(t *testing.T) {
+	raw := "HTTP/1.1 200 OK\r\n" +
+		"Set-Cookie: session=abc123; Path=/; HttpOnly\r\n" +
+		"Set-Cookie: csrf=x'y; Path=/\r\n" +
+		"Content-Length: 2\r\n" +
+		"\r\n" +
+		"ok"
+	got, err := Run(raw, Options{InputFormat: inputFormatHTTPRaw, Emit: "cookies"})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if expected := "-b 'session=abc123; csrf=x'\\''y'\n"; string(got) != expected {
+		t.Errorf("Run() = %q; want %q", got, expected)
+	}
+}
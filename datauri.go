@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// defaultDataURIType is the media type of a data: URI that declares none,
+// per RFC 2397.
+const defaultDataURIType = "text/plain;charset=US-ASCII"
+
+// isDataURI reports whether s starts like a data: URI; the scheme is
+// case-insensitive.
+func isDataURI(s []byte) bool {
+	return len(s) >= 5 && bytes.EqualFold(s[:5], []byte("data:"))
+}
+
+// parseDataURI parses an RFC 2397 data: URI such as
+// data:application/json;base64,eyJhIjoxfQ== and returns its media type, with
+// its parameters, and its decoded content. The content is percent-decoded
+// and then, with the ;base64 extension, base64-decoded (standard or URL-safe
+// alphabet, padding optional, whitespace ignored). A URI without a media
+// type is text/plain;charset=US-ASCII.
+func parseDataURI(s string) (mediaType string, data []byte, err error) {
+	if !isDataURI([]byte(s)) {
+		return "", nil, errors.New("not a data: URI")
+	}
+	meta, payload, ok := strings.Cut(s[5:], ",")
+	if !ok {
+		return "", nil, errors.New("data: URI has no comma before its data")
+	}
+	isBase64 := false
+	if m, found := strings.CutSuffix(meta, ";base64"); found {
+		meta, isBase64 = m, true
+	}
+	mediaType = defaultDataURIType
+	if strings.HasPrefix(meta, ";") {
+		mediaType = "text/plain" + meta
+	} else if meta != "" {
+		mediaType = meta
+	}
+	mt, params, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return "", nil, fmt.Errorf("data: URI media type %q: %w", mediaType, err)
+	}
+	mediaType = mime.FormatMediaType(mt, params)
+
+	unescaped, err := url.PathUnescape(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("data: URI percent-encoding: %w", err)
+	}
+	if !isBase64 {
+		return mediaType, []byte(unescaped), nil
+	}
+	text := strings.TrimRight(strings.Join(strings.Fields(unescaped), ""), "=")
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(text, "-_") {
+		encoding = base64.RawURLEncoding
+	}
+	if data, err = encoding.DecodeString(text); err != nil {
+		return "", nil, fmt.Errorf("data: URI base64: %w", err)
+	}
+	return mediaType, data, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+// TestParseDataURI tests the parseDataURI function.
+func TestParseDataURI(t *testing.T) {
+	tests := []struct {
+		name              string
+		input             string
+		expectedMediaType string
+		expectedData      []byte
+		expectError       bool
+	}{
+		{"base64 json", "data:application/json;base64,eyJhIjoxfQ==", "application/json", []byte(`{"a":1}`), false},
+		{"base64 without padding", "data:application/json;base64,eyJhIjoxfQ", "application/json", []byte(`{"a":1}`), false},
+		{"url-safe base64", "data:application/octet-stream;base64,-_8", "application/octet-stream", []byte{0xfb, 0xff}, false},
+		{"percent-encoded base64", "data:application/json;base64,eyJhIjoxfQ%3D%3D", "application/json", []byte(`{"a":1}`), false},
+		{"percent-encoded text", "data:text/plain;charset=utf-8,a%20b%2Cc", "text/plain; charset=utf-8", []byte("a b,c"), false},
+		{"default media type", "data:,hello", "text/plain; charset=US-ASCII", []byte("hello"), false},
+		{"parameters without a type", "data:;charset=utf-8,hi", "text/plain; charset=utf-8", []byte("hi"), false},
+		{"capital scheme", "DATA:text/csv,a", "text/csv", []byte("a"), false},
+		{"binary percent-encoded", "data:application/octet-stream,%1F%8B", "application/octet-stream", []byte{0x1f, 0x8b}, false},
+		{"empty data", "data:text/plain,", "text/plain", []byte{}, false},
+		{"no comma", "data:text/plain", "", nil, true},
+		{"bad base64", "data:application/json;base64,e*J", "", nil, true},
+		{"bad percent-encoding", "data:text/plain,%zz", "", nil, true},
+		{"bad media type", "data:text/,x", "", nil, true},
+		{"not a data URI", "https://example.com", "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mediaType, data, err := parseDataURI(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parseDataURI(%q) error = %v, expectError %v", tt.input, err, tt.expectError)
+			}
+			if mediaType != tt.expectedMediaType || !bytes.Equal(data, tt.expectedData) {
+				t.Errorf("parseDataURI(%q) = %q, %q; want %q, %q", tt.input, mediaType, data, tt.expectedMediaType, tt.expectedData)
+			}
+		})
+	}
+}
+
+// TestRunDataURI tests that Run decodes a data: URI given as the body or, for
+// a command without data, as the URL.
+func TestRunDataURI(t *testing.T) {
+	gzipped := base64.StdEncoding.EncodeToString(gzipBytes(t, `{"b":2}`))
+	tests := []struct {
+		name     string
+		command  string
+		expected string
+	}{
+		{"base64 body", "curl u --data-raw 'data:application/json;base64,eyJhIjoxfQ=='", "{\n  \"a\": 1\n}"},
+		{"percent-encoded body", "curl u -d 'data:application/x-www-form-urlencoded,a=1%26b=2'", "{\n  \"a\": [\n    \"1\"\n  ],\n  \"b\": [\n    \"2\"\n  ]\n}"},
+		{"gzip in a base64 url", "curl 'data:application/json;base64," + gzipped + "'", "{\n  \"b\": 2\n}"},
+		{"header beats the uri media type", "curl u -H 'Content-Type: text/plain' -d 'data:application/json;base64,eyJhIjoxfQ=='", `{"a":1}`},
+		{"not a data uri", "curl u -d 'data: x'", "data: x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(tt.command, Options{})
+			if err != nil {
+				t.Fatalf("Run(%q) returned an unexpected error: %v", tt.command, err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run(%q) = %q; want %q", tt.command, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+)
+
+// digests are the hash functions -digest can report for the processed body,
+// keyed by name.
+var digests = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+}
+
+// digestNames returns the names of the registered -digest algorithms in sorted order.
+func digestNames() []string {
+	names := make([]string, 0, len(digests))
+	for name := range digests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// digestOf returns the hex-encoded digest of data with the named algorithm.
+func digestOf(algorithm string, data []byte) (string, error) {
+	newHash, ok := digests[algorithm]
+	if !ok {
+		return "", fmt.Errorf("unknown digest %q", algorithm)
+	}
+	h := newHash()
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
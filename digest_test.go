@@ -0,0 +1,65 @@
+package main
+
+import "testing"
+
+// TestDigestOf tests the digestOf function.
+func TestDigestOf(t *testing.T) {
+	tests := []struct {
+		algorithm   string
+		data        string
+		expected    string
+		expectError bool
+	}{
+		{"md5", "abc", "900150983cd24fb0d6963f7d28e17f72", false},
+		{"sha1", "abc", "a9993e364706816aba3e25717850c26c9cd0d89d", false},
+		{"sha256", "abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", false},
+		{"sha256", "", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", false},
+		{"crc32", "abc", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm+"/"+tt.data, func(t *testing.T) {
+			got, err := digestOf(tt.algorithm, []byte(tt.data))
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("digestOf(%q) expected an error, got %q", tt.algorithm, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("digestOf(%q) returned an unexpected error: %v", tt.algorithm, err)
+			}
+			if got != tt.expected {
+				t.Errorf("digestOf(%q, %q) = %q; want %q", tt.algorithm, tt.data, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestDecodeDigest tests that Decode hashes the decompressed body, so that
+// the same payload gives the same digest however it was compressed.
+func TestDecodeDigest(t *testing.T) {
+	const sha256OfABC = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	tests := []struct {
+		name     string
+		command  string
+		opts     Options
+		expected string
+	}{
+		{"plain", "curl 'url' --data-raw $'abc'", Options{Digest: "sha256"}, sha256OfABC},
+		{"gzipped", "curl 'url' --data-raw $'" + hexEscape(gzipBytes(t, "abc")) + "'", Options{Digest: "sha256"}, sha256OfABC},
+		{"not requested", "curl 'url' --data-raw $'abc'", Options{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Decode(tt.command, tt.opts)
+			if err != nil {
+				t.Fatalf("Decode() returned an unexpected error: %v", err)
+			}
+			if res.Digest != tt.expected {
+				t.Errorf("Decode() Digest = %q; want %q", res.Digest, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// errNoEditor is returned by editOutput when $EDITOR is not set.
+var errNoEditor = errors.New("$EDITOR is not set")
+
+// editOutput writes output to a temporary file and runs editor on it, attached
+// to the terminal, for -edit. editor is a command line such as "code --wait",
+// split into words with the shell's quoting rules; the file name is appended.
+// The file is deleted once the editor exits unless keep is set. It returns
+// the file's path.
+func editOutput(output []byte, editor string, keep bool) (string, error) {
+	tokens, err := tokenizeCurl(editor)
+	if err != nil {
+		return "", fmt.Errorf("parsing $EDITOR %q: %w", editor, err)
+	}
+	if len(tokens) == 0 {
+		return "", errNoEditor
+	}
+	pattern := "decoded-*.txt"
+	if json.Valid(output) {
+		pattern = "decoded-*.json" // Lets the editor pick JSON highlighting.
+	}
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", fmt.Errorf("creating a temporary file for the editor: %w", err)
+	}
+	path := f.Name()
+	if !keep {
+		defer os.Remove(path)
+	}
+	_, err = f.Write(output)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return path, fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	args := make([]string, 0, len(tokens))
+	for _, tok := range tokens[1:] {
+		args = append(args, tok.Value)
+	}
+	cmd := exec.Command(tokens[0].Value, append(args, path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return path, fmt.Errorf("running %s on %s: %w", tokens[0].Value, path, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEditOutput tests that editOutput runs the editor on a temporary file
+// holding the output, using a shell script that copies the file as the editor.
+func TestEditOutput(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("the mock editor needs sh")
+	}
+	tests := []struct {
+		name      string
+		output    string
+		keep      bool
+		extension string
+	}{
+		{"text", "hello", false, ".txt"},
+		{"json kept", `{"a":1}`, true, ".json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := filepath.Join(t.TempDir(), "seen")
+			editor := `sh -c 'cp "$1" ` + seen + `' mock-editor`
+			path, err := editOutput([]byte(tt.output), editor, tt.keep)
+			if err != nil {
+				t.Fatalf("editOutput() returned an unexpected error: %v", err)
+			}
+			if got, err := os.ReadFile(seen); err != nil || string(got) != tt.output {
+				t.Errorf("the editor saw %q (%v); want %q", got, err, tt.output)
+			}
+			if !strings.HasSuffix(path, tt.extension) {
+				t.Errorf("editOutput() used %s; want a %s file", path, tt.extension)
+			}
+			_, err = os.Stat(path)
+			if tt.keep {
+				if err != nil {
+					t.Errorf("editOutput() with keep removed %s: %v", path, err)
+				}
+				os.Remove(path)
+			} else if !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("editOutput() left %s behind (%v)", path, err)
+			}
+		})
+	}
+}
+
+// TestEditOutputErrors tests editOutput without an editor and with a failing one.
+func TestEditOutputErrors(t *testing.T) {
+	if _, err := editOutput([]byte("x"), "", false); !errors.Is(err, errNoEditor) {
+		t.Errorf("editOutput() without an editor = %v; want errNoEditor", err)
+	}
+	if _, err := exec.LookPath("false"); err != nil {
+		t.Skip("the failing editor needs false")
+	}
+	path, err := editOutput([]byte("x"), "false", false)
+	if err == nil || errors.Is(err, errNoEditor) {
+		t.Errorf("editOutput() with a failing editor = %v; want its error", err)
+	}
+	if _, statErr := os.Stat(path); !errors.Is(statErr, os.ErrNotExist) {
+		t.Errorf("editOutput() left %s behind after the editor failed", path)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// emitters renders a parsed Request as a snippet in another language or tool,
+// keyed by the -emit mode name.
+var emitters = map[string]func(r *Request) ([]byte, error){
+	"cookies":    emitCookies,
+	"curl":       emitCurl,
+	"insomnia":   emitInsomnia,
+	"powershell": emitPowerShell,
+}
+
+// emitModes returns the names of the registered -emit modes in sorted order.
+func emitModes() []string {
+	modes := make([]string, 0, len(emitters))
+	for mode := range emitters {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+	return modes
+}
+
+// isText reports whether b is valid UTF-8 without control characters other
+// than tab, newline and carriage return, i.e. whether it can be embedded in a
+// source string literal rather than as a byte array.
+func isText(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, c := range b {
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// shellQuote quotes s as a single shell word: in '...' when it is printable
+// ASCII, closing the quotes around a \' for each single quote in s, and as
+// $'...' with escapes otherwise.
+func shellQuote(s string) string {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 32 || s[i] >= 127 {
+			return "$'" + encodeRawData([]byte(s)) + "'"
+		}
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Curl renders r as a cURL command, one option per line. The method is only
+// given with -X when it is not the one cURL implies, and the body is sent
+// with --data-raw. Use validateCurlSafe to check that the command parses
+// back to r.
+func (r *Request) Curl() string {
+	parts := []string{"curl " + shellQuote(r.URL)}
+	implied := "GET"
+	if r.Body != nil {
+		implied = "POST"
+	}
+	if r.Method != implied {
+		parts = append(parts, "-X "+shellQuote(r.Method))
+	}
+	for _, h := range r.Headers {
+		parts = append(parts, "-H "+shellQuote(h.Name+": "+h.Value))
+	}
+	if r.Body != nil {
+		parts = append(parts, "--data-raw "+shellQuote(string(r.Body)))
+	}
+	return strings.Join(parts, " \\\n  ")
+}
+
+// validateCurlSafe checks that r.Curl() tokenizes and parses back to r, so
+// that no value's quoting breaks the command. Values that cannot survive the
+// round trip, such as a URL or header with control characters (only bodies
+// are unescaped from $'...'), a header value with surrounding whitespace or
+// a lowercase method, are reported.
+func validateCurlSafe(r *Request) error {
+	command := r.Curl()
+	back, err := parseCurl(command, Options{})
+	if err != nil {
+		return fmt.Errorf("validateCurlSafe: the command does not parse back: %w", err)
+	}
+	switch {
+	case back.URL != r.URL:
+		return fmt.Errorf("validateCurlSafe: URL %q parses back as %q", r.URL, back.URL)
+	case back.Method != r.Method:
+		return fmt.Errorf("validateCurlSafe: method %q parses back as %q", r.Method, back.Method)
+	case len(back.Headers) != len(r.Headers):
+		return fmt.Errorf("validateCurlSafe: %d header(s) parse back as %d", len(r.Headers), len(back.Headers))
+	case (back.Body == nil) != (r.Body == nil) || !bytes.Equal(back.Body, r.Body):
+		return fmt.Errorf("validateCurlSafe: the %d-byte body parses back as %d bytes", len(r.Body), len(back.Body))
+	}
+	for i, h := range r.Headers {
+		if back.Headers[i] != h {
+			return fmt.Errorf("validateCurlSafe: header %q parses back as %q", h.Name+": "+h.Value, back.Headers[i].Name+": "+back.Headers[i].Value)
+		}
+	}
+	return nil
+}
+
+// emitCurl renders r as a cURL command after checking with validateCurlSafe
+// that it reproduces r.
+func emitCurl(r *Request) ([]byte, error) {
+	if err := validateCurlSafe(r); err != nil {
+		return nil, err
+	}
+	return []byte(r.Curl() + "\n"), nil
+}
+
+// psQuote quotes s as a PowerShell single-quoted string.
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// emitPowerShell renders r as an Invoke-WebRequest call. Content-Type and
+// User-Agent are passed through their dedicated parameters because Windows
+// PowerShell 5.1 rejects them in the -Headers hashtable. Text bodies become a
+// here-string; binary bodies, and text that a here-string cannot represent
+// exactly, become a byte array.
+func emitPowerShell(r *Request) ([]byte, error) {
+	var sb strings.Builder
+	args := []string{"-Uri " + psQuote(r.URL), "-Method " + r.Method}
+
+	// PowerShell hashtable keys are case-insensitive, so repeated headers are merged.
+	var names []string
+	values := map[string][]string{}
+	for _, h := range r.Headers {
+		switch key := strings.ToLower(h.Name); key {
+		case "content-type", "user-agent":
+		default:
+			if _, seen := values[key]; !seen {
+				names = append(names, h.Name)
+			}
+			values[key] = append(values[key], h.Value)
+		}
+	}
+	if len(names) > 0 {
+		sb.WriteString("$headers = @{\n")
+		for _, name := range names {
+			fmt.Fprintf(&sb, "    %s = %s\n", psQuote(name), psQuote(strings.Join(values[strings.ToLower(name)], ", ")))
+		}
+		sb.WriteString("}\n")
+		args = append(args, "-Headers $headers")
+	}
+	if ct := r.Headers.Get("Content-Type"); ct != "" {
+		args = append(args, "-ContentType "+psQuote(ct))
+	}
+	if ua := r.Headers.Get("User-Agent"); ua != "" {
+		args = append(args, "-UserAgent "+psQuote(ua))
+	}
+
+	if r.Body != nil {
+		body := string(r.Body)
+		if isText(r.Body) && !strings.Contains(body, "\r") && !strings.Contains(body, "\n'@") {
+			sb.WriteString("$body = @'\n" + body + "\n'@\n")
+		} else {
+			sb.WriteString("$body = [byte[]]@(")
+			for i, c := range r.Body {
+				if i > 0 {
+					sb.WriteString(", ")
+				}
+				fmt.Fprintf(&sb, "0x%02x", c)
+			}
+			sb.WriteString(")\n")
+		}
+		args = append(args, "-Body $body")
+	}
+
+	sb.WriteString("Invoke-WebRequest " + strings.Join(args, " ") + "\n")
+	return []byte(sb.String()), nil
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestEmitPowerShell tests the emitPowerShell function.
+func TestEmitPowerShell(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  *Request
+		expected string
+	}{
+		{
+			name:     "GET without headers",
+			request:  &Request{Method: "GET", URL: "https://example.com/it's"},
+			expected: "Invoke-WebRequest -Uri 'https://example.com/it''s' -Method GET\n",
+		},
+		{
+			name: "JSON body with headers",
+			request: &Request{Method: "POST", URL: "https://example.com", Headers: Headers{
+				{"Accept", "*/*"}, {"Content-Type", "application/json"}, {"User-Agent", "ua"}, {"X-A", "1"}, {"x-a", "2"},
+			}, Body: []byte(`{"a":"b"}`)},
+			expected: "$headers = @{\n    'Accept' = '*/*'\n    'X-A' = '1, 2'\n}\n" +
+				"$body = @'\n{\"a\":\"b\"}\n'@\n" +
+				"Invoke-WebRequest -Uri 'https://example.com' -Method POST -Headers $headers -ContentType 'application/json' -UserAgent 'ua' -Body $body\n",
+		},
+		{
+			name:     "binary body",
+			request:  &Request{Method: "POST", URL: "u", Body: []byte{0x1f, 0x8b, 0x00}},
+			expected: "$body = [byte[]]@(0x1f, 0x8b, 0x00)\nInvoke-WebRequest -Uri 'u' -Method POST -Body $body\n",
+		},
+		{
+			name:     "text that would end the here-string",
+			request:  &Request{Method: "POST", URL: "u", Body: []byte("a\n'@")},
+			expected: "$body = [byte[]]@(0x61, 0x0a, 0x27, 0x40)\nInvoke-WebRequest -Uri 'u' -Method POST -Body $body\n",
+		},
+		{
+			name:     "empty body",
+			request:  &Request{Method: "POST", URL: "u", Body: []byte{}},
+			expected: "$body = @'\n\n'@\nInvoke-WebRequest -Uri 'u' -Method POST -Body $body\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := emitPowerShell(tt.request)
+			if err != nil {
+				t.Fatalf("emitPowerShell() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("emitPowerShell() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunEmit tests that Run renders the request when an emit mode is selected.
+func TestRunEmit(t *testing.T) {
+	command := "curl 'https://example.com' -H 'Content-Type: application/json' --data-raw $'{\"a\":1}'"
+	got, err := Run(command, Options{Emit: "powershell"})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	expected := "$body = @'\n{\"a\":1}\n'@\nInvoke-WebRequest -Uri 'https://example.com' -Method POST -ContentType 'application/json' -Body $body\n"
+	if string(got) != expected {
+		t.Errorf("Run() = %q; want %q", got, expected)
+	}
+
+	if _, err := Run(command, Options{Emit: "cobol"}); err == nil {
+		t.Errorf("Run() with an unknown emit mode should have returned an error, but got nil")
+	}
+	if _, err := Run("curl -H 'A: b'", Options{Emit: "powershell"}); exitCodeFor(err) != exitExtract {
+		t.Errorf("Run() without a URL returned %v; want an extraction error", err)
+	}
+}
+
+// TestValidateCurlSafe tests the validateCurlSafe function.
+func TestValidateCurlSafe(t *testing.T) {
+	tests := []struct {
+		name        string
+		request     *Request
+		expectError bool
+	}{
+		{"plain GET", &Request{Method: "GET", URL: "https://example.com/a?b=1&c=2"}, false},
+		{"body with quotes", &Request{Method: "POST", URL: "u", Body: []byte(`{"a":"it's \"quoted\""}`)}, false},
+		{"body with newlines", &Request{Method: "POST", URL: "u", Body: []byte("line 1\nline 2\r\n\tindented")}, false},
+		{"command substitution lookalikes", &Request{Method: "POST", URL: "u", Headers: Headers{{"X-Cmd", "$(rm -rf /) `id` ${HOME}"}}, Body: []byte("$(echo hi) `whoami` $HOME")}, false},
+		{"binary body", &Request{Method: "PUT", URL: "u", Body: []byte{0x1f, 0x8b, 0x00, '\\', '\''}}, false},
+		{"backslashes and edge spaces", &Request{Method: "POST", URL: "u", Body: []byte(` a\nb\\ `)}, false},
+		{"header with a quote", &Request{Method: "GET", URL: "u", Headers: Headers{{"Cookie", "a='1'"}}}, false},
+		{"empty body", &Request{Method: "POST", URL: "u", Body: []byte{}}, false},
+		{"GET with body", &Request{Method: "GET", URL: "u", Body: []byte("x")}, false},
+		{"URL with a newline", &Request{Method: "GET", URL: "https://example.com/\n"}, true},
+		{"header with a newline", &Request{Method: "GET", URL: "u", Headers: Headers{{"X-A", "a\nb"}}}, true},
+		{"header with surrounding whitespace", &Request{Method: "GET", URL: "u", Headers: Headers{{"X-A", " a "}}}, true},
+		{"lowercase method", &Request{Method: "delete", URL: "u"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCurlSafe(tt.request)
+			if tt.expectError && err == nil {
+				t.Errorf("validateCurlSafe() expected an error for %q", tt.request.Curl())
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("validateCurlSafe() returned an unexpected error: %v\ncommand: %s", err, tt.request.Curl())
+			}
+		})
+	}
+}
+
+// TestRequestCurl tests the Request.Curl method.
+func TestRequestCurl(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  *Request
+		expected string
+	}{
+		{"GET", &Request{Method: "GET", URL: "https://example.com"}, "curl 'https://example.com'"},
+		{"POST body with a quote", &Request{Method: "POST", URL: "u", Headers: Headers{{"A", "b"}}, Body: []byte("it's")}, "curl 'u' \\\n  -H 'A: b' \\\n  --data-raw 'it'\\''s'"},
+		{"explicit method", &Request{Method: "DELETE", URL: "u"}, "curl 'u' \\\n  -X 'DELETE'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.request.Curl(); got != tt.expected {
+				t.Errorf("Curl() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestEmitInsomnia tests that emitInsomnia produces an Insomnia export with
+// the request's fields.
+func TestEmitInsomnia(t *testing.T) {
+	tests := []struct {
+		name             string
+		request          *Request
+		expectedName     string
+		expectedMimeType string
+		expectError      bool
+	}{
+		{
+			name: "json body",
+			request: &Request{Method: "POST", URL: "https://example.com/api?x=1", Body: []byte(`{"a":1}`),
+				Headers: Headers{{"Content-Type", "application/json; charset=utf-8"}, {"X-Trace", "1"}}},
+			expectedName:     "POST /api",
+			expectedMimeType: "application/json",
+		},
+		{
+			name:             "form body without a content type",
+			request:          &Request{Method: "POST", URL: "https://example.com", Body: []byte("a=1&b=2")},
+			expectedName:     "POST https://example.com",
+			expectedMimeType: "application/x-www-form-urlencoded",
+		},
+		{
+			name:         "no body",
+			request:      &Request{Method: "GET", URL: "https://example.com/x"},
+			expectedName: "GET /x",
+		},
+		{
+			name:        "binary body",
+			request:     &Request{Method: "POST", URL: "https://example.com", Body: []byte{0x1f, 0x8b, 0xff}},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := emitInsomnia(tt.request)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("emitInsomnia() error = %v, expectError %v", err, tt.expectError)
+			}
+			if tt.expectError {
+				return
+			}
+			var export insomniaExport
+			if err := json.Unmarshal(got, &export); err != nil {
+				t.Fatalf("emitInsomnia() = %s is not JSON: %v", got, err)
+			}
+			if export.Type != "export" || export.ExportFormat != 4 || len(export.Resources) != 2 {
+				t.Fatalf("emitInsomnia() = %s; want a v4 export with a workspace and a request", got)
+			}
+			workspace, request := export.Resources[0], export.Resources[1]
+			if workspace.Type != "workspace" || request.Type != "request" || request.ParentID == nil || *request.ParentID != workspace.ID {
+				t.Errorf("emitInsomnia() = %s; want the request inside the workspace", got)
+			}
+			if request.Name != tt.expectedName || request.Method != tt.request.Method || request.URL != tt.request.URL {
+				t.Errorf("emitInsomnia() request = %q %s %s; want %q %s %s", request.Name, request.Method, request.URL, tt.expectedName, tt.request.Method, tt.request.URL)
+			}
+			if len(request.Headers) != len(tt.request.Headers) {
+				t.Errorf("emitInsomnia() headers = %v; want %v", request.Headers, tt.request.Headers)
+			}
+			for i, h := range request.Headers {
+				if i < len(tt.request.Headers) && (h.Name != tt.request.Headers[i].Name || h.Value != tt.request.Headers[i].Value) {
+					t.Errorf("emitInsomnia() header %d = %v; want %v", i, h, tt.request.Headers[i])
+				}
+			}
+			switch {
+			case tt.request.Body == nil && request.Body != nil:
+				t.Errorf("emitInsomnia() body = %+v; want none", *request.Body)
+			case tt.request.Body != nil && (request.Body == nil || request.Body.Text != string(tt.request.Body) || request.Body.MimeType != tt.expectedMimeType):
+				t.Errorf("emitInsomnia() body = %+v; want %q as %s", request.Body, tt.request.Body, tt.expectedMimeType)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+)
+
+// expandEnvRefs substitutes $NAME and ${NAME} references in a cURL command
+// with the values lookup returns (os.LookupEnv for -env), as the shell would
+// for unquoted and "..." words. '...' and $'...' segments and escaped \$ are
+// left untouched, as are references to unset variables, which are returned in
+// missing. Values are quoted for their context so that the tokenizer reads
+// them back literally.
+func expandEnvRefs(command string, lookup func(string) (string, bool)) (expanded string, missing []string) {
+	var sb strings.Builder
+	inDouble := false
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == '\\' && i+1 < len(command):
+			sb.WriteString(command[i : i+2])
+			i++
+		case c == '"':
+			inDouble = !inDouble
+			sb.WriteByte(c)
+		case c == '\'' && !inDouble:
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				sb.WriteString(command[i:])
+				return sb.String(), missing
+			}
+			sb.WriteString(command[i : i+end+2])
+			i += end + 1
+		case c == '$' && !inDouble && i+1 < len(command) && command[i+1] == '\'':
+			j := i + 2
+			for ; j < len(command) && command[j] != '\''; j++ {
+				if command[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(command) {
+				sb.WriteString(command[i:])
+				return sb.String(), missing
+			}
+			sb.WriteString(command[i : j+1])
+			i = j
+		case c == '$':
+			name, n := envRefName(command[i+1:])
+			if n == 0 {
+				sb.WriteByte(c)
+				continue
+			}
+			value, ok := lookup(name)
+			if !ok {
+				missing = append(missing, name)
+				sb.WriteString(command[i : i+1+n])
+			} else if inDouble {
+				sb.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`").Replace(value))
+			} else {
+				sb.WriteString("'" + strings.ReplaceAll(value, "'", `'\''`) + "'")
+			}
+			i += n
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), missing
+}
+
+// envRefName parses the variable name of a reference following a '$': NAME
+// or {NAME}. n is the number of bytes of s the reference takes, or 0 when s
+// does not start with one.
+func envRefName(s string) (name string, n int) {
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 2 || !isEnvName(s[1:end]) {
+			return "", 0
+		}
+		return s[1:end], end + 1
+	}
+	for n < len(s) && (s[n] == '_' || s[n] >= 'A' && s[n] <= 'Z' || s[n] >= 'a' && s[n] <= 'z' || n > 0 && s[n] >= '0' && s[n] <= '9') {
+		n++
+	}
+	return s[:n], n
+}
+
+// isEnvName reports whether s is a valid shell variable name.
+func isEnvName(s string) bool {
+	_, n := envRefName(s)
+	return n == len(s) && n > 0
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestExpandEnvRefs tests the expandEnvRefs function.
+func TestExpandEnvRefs(t *testing.T) {
+	env := map[string]string{
+		"BODY":  `{"a":1}`,
+		"TOKEN": "abc",
+		"QUOTE": `it's "quoted" $x`,
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	tests := []struct {
+		name            string
+		command         string
+		expected        string
+		expectedMissing []string
+	}{
+		{"double-quoted body", `curl 'u' --data-raw "$BODY"`, `curl 'u' --data-raw "{\"a\":1}"`, nil},
+		{"braces and unquoted", `curl 'u' -H Authorization:${TOKEN} -d $BODY`, `curl 'u' -H Authorization:'abc' -d '{"a":1}'`, nil},
+		{"ansi-c untouched", `curl 'u' --data-raw $'$BODY\'$TOKEN'`, `curl 'u' --data-raw $'$BODY\'$TOKEN'`, nil},
+		{"single quotes untouched", `curl 'u' -d '$BODY'`, `curl 'u' -d '$BODY'`, nil},
+		{"escaped dollar untouched", `curl 'u' -d "\$BODY"`, `curl 'u' -d "\$BODY"`, nil},
+		{"quotes in value", `curl 'u' -d "$QUOTE" -H $QUOTE`, `curl 'u' -d "it's \"quoted\" \$x" -H 'it'\''s "quoted" $x'`, nil},
+		{"unset variable", `curl 'u' -d "$NOPE-${NOPE2}"`, `curl 'u' -d "$NOPE-${NOPE2}"`, []string{"NOPE", "NOPE2"}},
+		{"lone dollar", `curl 'u' -d "5$ and $"`, `curl 'u' -d "5$ and $"`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, missing := expandEnvRefs(tt.command, lookup)
+			if got != tt.expected || !reflect.DeepEqual(missing, tt.expectedMissing) {
+				t.Errorf("expandEnvRefs(%q) = (%q, %q); want (%q, %q)", tt.command, got, missing, tt.expected, tt.expectedMissing)
+			}
+		})
+	}
+}
+
+// TestRunEnv tests that Run substitutes environment variables only with Env set.
+func TestRunEnv(t *testing.T) {
+	t.Setenv("CDE_TEST_BODY", `{"b":'2'}`)
+	command := `curl 'u' --data-raw "$CDE_TEST_BODY"`
+
+	got, err := Run(command, Options{Env: true, Format: "escaped"})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if expected := `$'{"b":\'2\'}'`; string(got) != expected {
+		t.Errorf("Run() with Env = %q; want %q", got, expected)
+	}
+
+	got, err = Run(command, Options{Format: "escaped"})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if expected := "$'$CDE_TEST_BODY'"; string(got) != expected {
+		t.Errorf("Run() without Env = %q; want %q", got, expected)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Exit codes returned by the CLI so scripts and CI can tell why a run failed.
+const (
+	exitOK         = 0 // Success.
+	exitFailure    = 1 // Any failure not covered by a more specific code (I/O, flags, ...).
+	exitExtract    = 2 // The data payload could not be extracted from the cURL command.
+	exitDecode     = 3 // The extracted payload could not be decoded.
+	exitDecompress = 4 // The decoded payload could not be decompressed.
+	exitNotJSON    = 5 // The processed data is not JSON but -require-json was set.
+	exitNoMatch    = 6 // -extract or -grep matched nothing.
+	exitMismatch   = 7 // The output differs from the -expect fixture.
+)
+
+// ExtractError reports that the data payload could not be located in the cURL command.
+type ExtractError struct {
+	Err error
+}
+
+func (e *ExtractError) Error() string { return "extraction failed: " + e.Err.Error() }
+func (e *ExtractError) Unwrap() error { return e.Err }
+
+// DecodeError reports that the extracted payload contains an invalid escape
+// sequence or a character that cannot be represented in Latin-1.
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string { return "decoding failed: " + e.Err.Error() }
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// DecompressError reports that the decoded payload could not be decompressed.
+type DecompressError struct {
+	Err error
+}
+
+func (e *DecompressError) Error() string { return "decompression failed: " + e.Err.Error() }
+func (e *DecompressError) Unwrap() error { return e.Err }
+
+// NotJSONError reports that the processed data is not valid JSON when JSON was required.
+type NotJSONError struct {
+	Err error
+}
+
+func (e *NotJSONError) Error() string { return "data is not valid JSON: " + e.Err.Error() }
+func (e *NotJSONError) Unwrap() error { return e.Err }
+
+// NoMatchError reports that an -extract or -grep query matched nothing.
+type NoMatchError struct {
+	Err error
+}
+
+func (e *NoMatchError) Error() string { return "no match: " + e.Err.Error() }
+func (e *NoMatchError) Unwrap() error { return e.Err }
+
+// MismatchError reports that the output differs from an -expect fixture.
+// Diff is a unified diff of the two, or a hex diff when either is binary.
+type MismatchError struct {
+	Fixture string
+	Diff    string
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("output differs from the fixture %s (-expect)", e.Fixture)
+}
+
+// DepthError reports that a recursive step went deeper than Options.MaxDepth,
+// naming the option that triggered it.
+type DepthError struct {
+	Feature string
+	Limit   int
+}
+
+func (e *DepthError) Error() string {
+	return fmt.Sprintf("%s went deeper than the maximum depth of %d (-max-depth)", e.Feature, e.Limit)
+}
+
+// exitCodeFor maps an error returned by Run to the process exit code.
+func exitCodeFor(err error) int {
+	var (
+		extractErr    *ExtractError
+		decodeErr     *DecodeError
+		decompressErr *DecompressError
+		notJSONErr    *NotJSONError
+		noMatchErr    *NoMatchError
+		mismatchErr   *MismatchError
+	)
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &extractErr):
+		return exitExtract
+	case errors.As(err, &decodeErr):
+		return exitDecode
+	case errors.As(err, &decompressErr):
+		return exitDecompress
+	case errors.As(err, &notJSONErr):
+		return exitNotJSON
+	case errors.As(err, &noMatchErr):
+		return exitNoMatch
+	case errors.As(err, &mismatchErr):
+		return exitMismatch
+	default:
+		return exitFailure
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestExitCodeFor tests the exitCodeFor function.
+func TestExitCodeFor(t *testing.T) {
+	cause := errors.New("boom")
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"nil error", nil, exitOK},
+		{"plain error", cause, exitFailure},
+		{"extract error", &ExtractError{Err: cause}, exitExtract},
+		{"decode error", &DecodeError{Err: cause}, exitDecode},
+		{"decompress error", &DecompressError{Err: cause}, exitDecompress},
+		{"not JSON error", &NotJSONError{Err: cause}, exitNotJSON},
+		{"no match error", &NoMatchError{Err: cause}, exitNoMatch},
+		{"mismatch error", &MismatchError{Fixture: "f"}, exitMismatch},
+		{"wrapped decode error", fmt.Errorf("context: %w", &DecodeError{Err: cause}), exitDecode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCodeFor(tt.err); got != tt.expected {
+				t.Errorf("exitCodeFor(%v) = %d; want %d", tt.err, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunErrorCategories tests that Run reports each failing stage with the matching exit code.
+func TestRunErrorCategories(t *testing.T) {
+	tests := []struct {
+		name        string
+		curlCommand string
+		opts        Options
+		expected    int
+	}{
+		{"success", "curl 'url' --data-raw $'{\"a\":1}'", Options{}, exitOK},
+		{"not JSON allowed", "curl 'url' --data-raw $'plain'", Options{}, exitOK},
+		{"missing data-raw", "curl 'url'", Options{}, exitExtract},
+		{"bad escape", "curl 'url' --data-raw $'\\x4G'", Options{}, exitDecode},
+		{"forced decompression fails", "curl 'url' --data-raw $'plain'", Options{Decompress: algoGzip}, exitDecompress},
+		{"not JSON required", "curl 'url' --data-raw $'plain'", Options{RequireJSON: true}, exitNotJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Run(tt.curlCommand, tt.opts)
+			if got := exitCodeFor(err); got != tt.expected {
+				t.Errorf("exitCodeFor(Run(%q)) = %d (err: %v); want %d", tt.curlCommand, got, err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change in
+// the unified diff of an -expect mismatch.
+const diffContext = 3
+
+// maxDiffCells caps the lines(fixture) x lines(output) table the line diff
+// builds. Beyond it, the differing middle is shown as removed and added as a
+// whole rather than as a minimal diff.
+const maxDiffCells = 4 << 20
+
+// maxHexDiffRows is the number of differing 16-byte rows a hex diff shows.
+const maxHexDiffRows = 16
+
+// checkFixture compares output with the content of the fixture file for
+// -expect. It returns a MismatchError holding a diff when they differ.
+func checkFixture(output []byte, fixture string) error {
+	expected, err := os.ReadFile(fixture)
+	if err != nil {
+		return fmt.Errorf("reading fixture: %w", err)
+	}
+	if bytes.Equal(expected, output) {
+		return nil
+	}
+	return &MismatchError{Fixture: fixture, Diff: diffOutputs(fixture, expected, output)}
+}
+
+// updateFixture overwrites the fixture file with output for -expect with
+// -update.
+func updateFixture(output []byte, fixture string) error {
+	if err := writeOutputFile(fixture, output, defaultOutputMode); err != nil {
+		return fmt.Errorf("updating fixture: %w", err)
+	}
+	return nil
+}
+
+// diffOutputs describes how output differs from expected, read from the
+// fixture file: a unified diff when both are text, a hex diff otherwise.
+func diffOutputs(fixture string, expected, output []byte) string {
+	if isText(expected) && isText(output) {
+		return unifiedDiff(fixture, "output", splitLines(string(expected)), splitLines(string(output)))
+	}
+	return hexDiff(expected, output)
+}
+
+// splitLines splits s into lines, each keeping its "\n", so a missing final
+// newline shows up as a changed last line.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffOp is one line of a line diff: ' ' kept, '-' only in the fixture or
+// '+' only in the output.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines returns the edit script turning a into b, from a longest common
+// subsequence of their lines. The common prefix and suffix are matched first;
+// when the rest is too large for the table, it is all removed and added.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(ma)*len(mb) > maxDiffCells {
+		for _, line := range ma {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range mb {
+			ops = append(ops, diffOp{'+', line})
+		}
+	} else {
+		// lcs[i][j] is the length of the longest common subsequence of
+		// ma[i:] and mb[j:].
+		lcs := make([][]int, len(ma)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(mb)+1)
+		}
+		for i := len(ma) - 1; i >= 0; i-- {
+			for j := len(mb) - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(ma) || j < len(mb) {
+			switch {
+			case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+				ops = append(ops, diffOp{' ', ma[i]})
+				i++
+				j++
+			case j == len(mb) || i < len(ma) && lcs[i+1][j] >= lcs[i][j+1]:
+				ops = append(ops, diffOp{'-', ma[i]})
+				i++
+			default:
+				ops = append(ops, diffOp{'+', mb[j]})
+				j++
+			}
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// unifiedDiff renders the line diff of a (named aName) and b (named bName)
+// in the unified format of diff -u, with diffContext lines of context.
+func unifiedDiff(aName, bName string, a, b []string) string {
+	ops := diffLines(a, b)
+	// aLine[k] and bLine[k] are the numbers of the lines of a and b before ops[k].
+	aLine, bLine := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for k, op := range ops {
+		aLine[k+1], bLine[k+1] = aLine[k], bLine[k]
+		if op.kind != '+' {
+			aLine[k+1]++
+		}
+		if op.kind != '-' {
+			bLine[k+1]++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+	for k := 0; k < len(ops); {
+		for k < len(ops) && ops[k].kind == ' ' {
+			k++
+		}
+		if k == len(ops) {
+			break
+		}
+		start := max(k-diffContext, 0)
+		end := k
+		for {
+			for end < len(ops) && ops[end].kind != ' ' {
+				end++
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*diffContext {
+				break
+			}
+			end = next
+		}
+		stop := min(end+diffContext, len(ops))
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aLine[start], aLine[stop]-aLine[start]), hunkRange(bLine[start], bLine[stop]-bLine[start]))
+		for _, op := range ops[start:stop] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		k = stop
+	}
+	return sb.String()
+}
+
+// hunkRange formats the line range of one side of a unified diff hunk that
+// covers count lines after line before.
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// hexDiff lists the 16-byte rows that differ between expected and output
+// as hexdump lines, the fixture's prefixed with - and the output's with +,
+// up to maxHexDiffRows of them.
+func hexDiff(expected, output []byte) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "fixture: %d bytes, output: %d bytes\n", len(expected), len(output))
+	rows := 0
+	for offset := 0; offset < max(len(expected), len(output)); offset += 16 {
+		e, o := hexRow(expected, offset), hexRow(output, offset)
+		if bytes.Equal(e, o) {
+			continue
+		}
+		if rows == maxHexDiffRows {
+			sb.WriteString("...\n")
+			break
+		}
+		rows++
+		if e != nil {
+			fmt.Fprintf(&sb, "-%s\n", hexDumpLine(offset, e))
+		}
+		if o != nil {
+			fmt.Fprintf(&sb, "+%s\n", hexDumpLine(offset, o))
+		}
+	}
+	return sb.String()
+}
+
+// hexRow returns the up to 16 bytes of data at offset, or nil past its end.
+func hexRow(data []byte, offset int) []byte {
+	if offset >= len(data) {
+		return nil
+	}
+	return data[offset:min(offset+16, len(data))]
+}
+
+// hexDumpLine formats row, found at offset, as a hexdump -C line.
+func hexDumpLine(offset int, row []byte) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%08x ", offset)
+	for i := 0; i < 16; i++ {
+		if i == 8 {
+			sb.WriteByte(' ')
+		}
+		if i < len(row) {
+			fmt.Fprintf(&sb, " %02x", row[i])
+		} else {
+			sb.WriteString("   ")
+		}
+	}
+	sb.WriteString("  |")
+	for _, c := range row {
+		if c < 0x20 || c > 0x7e {
+			c = '.'
+		}
+		sb.WriteByte(c)
+	}
+	sb.WriteByte('|')
+	return sb.String()
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCheckFixture tests the checkFixture function with matching and
+// mismatching fixtures.
+func TestCheckFixture(t *testing.T) {
+	tests := []struct {
+		name         string
+		fixture      string
+		output       string
+		expectedDiff string
+	}{
+		{"matching text", "{\n  \"a\": 1\n}", "{\n  \"a\": 1\n}", ""},
+		{"matching binary", "\x1f\x8b\x00", "\x1f\x8b\x00", ""},
+		{
+			name:         "mismatching text",
+			fixture:      "{\n  \"a\": 1,\n  \"b\": 2\n}",
+			output:       "{\n  \"a\": 1,\n  \"b\": 3\n}",
+			expectedDiff: "--- FIXTURE\n+++ output\n@@ -1,4 +1,4 @@\n {\n   \"a\": 1,\n-  \"b\": 2\n+  \"b\": 3\n }\n\\ No newline at end of file\n",
+		},
+		{
+			name:         "mismatching binary",
+			fixture:      "\x00\x01\x02",
+			output:       "\x00\x01\x03\x04",
+			expectedDiff: "fixture: 3 bytes, output: 4 bytes\n-00000000  00 01 02                                          |...|\n+00000000  00 01 03 04                                       |....|\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixture := filepath.Join(t.TempDir(), "fixture.bin")
+			if err := os.WriteFile(fixture, []byte(tt.fixture), 0644); err != nil {
+				t.Fatalf("Failed to write fixture: %v", err)
+			}
+			err := checkFixture([]byte(tt.output), fixture)
+			if tt.expectedDiff == "" {
+				if err != nil {
+					t.Errorf("checkFixture() returned an unexpected error: %v", err)
+				}
+				return
+			}
+			var mismatchErr *MismatchError
+			if !errors.As(err, &mismatchErr) {
+				t.Fatalf("checkFixture() error = %v; want a *MismatchError", err)
+			}
+			if exitCodeFor(err) != exitMismatch {
+				t.Errorf("exitCodeFor(checkFixture()) = %d; want %d", exitCodeFor(err), exitMismatch)
+			}
+			if expected := strings.ReplaceAll(tt.expectedDiff, "FIXTURE", fixture); mismatchErr.Diff != expected {
+				t.Errorf("checkFixture() diff = %q; want %q", mismatchErr.Diff, expected)
+			}
+		})
+	}
+
+	t.Run("missing fixture", func(t *testing.T) {
+		err := checkFixture([]byte("x"), filepath.Join(t.TempDir(), "missing.bin"))
+		if err == nil || !strings.Contains(err.Error(), "reading fixture") {
+			t.Errorf("checkFixture() error = %v; want a reading fixture error", err)
+		}
+	})
+}
+
+// TestUnifiedDiff tests the unifiedDiff function.
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected string
+	}{
+		{"added line", "a\nb\n", "a\nx\nb\n", "@@ -1,2 +1,3 @@\n a\n+x\n b\n"},
+		{"removed line", "a\nb\nc\n", "a\nc\n", "@@ -1,3 +1,2 @@\n a\n-b\n c\n"},
+		{"into empty", "", "a\n", "@@ -0,0 +1 @@\n+a\n"},
+		{
+			name:     "separate hunks",
+			a:        "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:        "x\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ny\n",
+			expected: "@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+y\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unifiedDiff("a", "b", splitLines(tt.a), splitLines(tt.b))
+			if expected := "--- a\n+++ b\n" + tt.expected; got != expected {
+				t.Errorf("unifiedDiff() = %q; want %q", got, expected)
+			}
+		})
+	}
+}
+
+// TestUpdateFixture tests that updateFixture overwrites the fixture so a
+// later checkFixture matches.
+func TestUpdateFixture(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+	if err := os.WriteFile(fixture, []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+	if err := updateFixture([]byte(`{"a":1}`), fixture); err != nil {
+		t.Fatalf("updateFixture() returned an unexpected error: %v", err)
+	}
+	if err := checkFixture([]byte(`{"a":1}`), fixture); err != nil {
+		t.Errorf("checkFixture() after updateFixture() returned an unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// extractJSONPath evaluates the JSONPath expr against a JSON body and returns
+// the matched values one per line: strings as their raw text, anything else as
+// compact JSON. It fails with a NotJSONError when the body is not JSON and a
+// NoMatchError when the expression matches nothing.
+func extractJSONPath(data []byte, expr string, opts Options) ([]byte, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, &NotJSONError{Err: err}
+	}
+	matches, err := evalJSONPath(expr, doc)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, &NoMatchError{Err: fmt.Errorf("JSONPath %s matched nothing", expr)}
+	}
+	var out bytes.Buffer
+	for _, match := range matches {
+		if s, ok := match.(string); ok {
+			out.WriteString(s)
+		} else {
+			encoded, err := json.Marshal(match)
+			if err != nil {
+				return nil, fmt.Errorf("marshalling JSONPath match: %w", err)
+			}
+			out.Write(encoded)
+		}
+		out.WriteByte('\n')
+	}
+	fmt.Fprintf(previews, "JSONPath %s matched %d value(s):\n", expr, len(matches))
+	fmt.Fprint(previews, out.String())
+	return out.Bytes(), nil
+}
+
+// grepLines returns the lines of data matching the regular expression pattern,
+// each followed by a newline. It fails with a NoMatchError when no line matches.
+func grepLines(data []byte, pattern string) ([]byte, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -grep pattern: %w", err)
+	}
+	var out bytes.Buffer
+	matched := 0
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		if re.MatchString(strings.TrimSuffix(line, "\r")) {
+			out.WriteString(line)
+			out.WriteByte('\n')
+			matched++
+		}
+	}
+	if matched == 0 {
+		return nil, &NoMatchError{Err: fmt.Errorf("pattern %q matched no line", pattern)}
+	}
+	fmt.Fprintf(previews, "Pattern %q matched %d line(s):\n", pattern, matched)
+	fmt.Fprint(previews, out.String())
+	return out.Bytes(), nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+// TestRunExtract tests the -extract and -grep modes through Run, including the no-match exit code.
+func TestRunExtract(t *testing.T) {
+	jsonCommand := `curl 'url' --data-raw $'{"data": {"token": "s3cr3t", "ids": [1, 2]}}'`
+	textCommand := `curl 'url' --data-raw $'level=info msg=start\nlevel=error msg=boom\nlevel=error msg=again'`
+
+	tests := []struct {
+		name         string
+		curlCommand  string
+		opts         Options
+		expected     string
+		expectedCode int
+	}{
+		{"string value", jsonCommand, Options{Extract: "$.data.token"}, "s3cr3t\n", exitOK},
+		{"non-string values", jsonCommand, Options{Extract: "$.data.ids"}, "[1,2]\n", exitOK},
+		{"several matches", jsonCommand, Options{Extract: "$.data.ids[*]"}, "1\n2\n", exitOK},
+		{"no JSONPath match", jsonCommand, Options{Extract: "$.missing"}, "", exitNoMatch},
+		{"not JSON", textCommand, Options{Extract: "$.a"}, "", exitNotJSON},
+		{"grep lines", textCommand, Options{Grep: "level=error"}, "level=error msg=boom\nlevel=error msg=again\n", exitOK},
+		{"no grep match", textCommand, Options{Grep: "^panic"}, "", exitNoMatch},
+		{"invalid pattern", textCommand, Options{Grep: "("}, "", exitFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(tt.curlCommand, tt.opts)
+			if code := exitCodeFor(err); code != tt.expectedCode {
+				t.Fatalf("Run() error = %v (exit code %d); want exit code %d", err, code, tt.expectedCode)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// listFeatures writes the escape sequences, compression formats, escape
+// dialects, output formats, digests and emit modes this build supports to w.
+// Every section is read from the registry the pipeline itself uses, so the
+// listing cannot drift from what the decoder actually accepts.
+func listFeatures(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("Escape sequences:\n")
+	for _, esc := range escapeSequences {
+		fmt.Fprintf(&sb, "  %-12s %s\n", esc.Sequence, esc.Description)
+	}
+	sb.WriteString("\nCompression formats:\n")
+	for _, name := range decompressorNames() {
+		fmt.Fprintf(&sb, "  %s\n", name)
+	}
+	sb.WriteString("\nInput dialects (-dialect):\n")
+	for _, name := range dialectNames() {
+		fmt.Fprintf(&sb, "  %-12s %s\n", name, dialects[name])
+	}
+	sb.WriteString("\nOutput formats (-format):\n")
+	for _, name := range outputFormatNames() {
+		fmt.Fprintf(&sb, "  %s\n", name)
+	}
+	sb.WriteString("\nDigests (-digest):\n")
+	for _, name := range digestNames() {
+		fmt.Fprintf(&sb, "  %s\n", name)
+	}
+	sb.WriteString("\nEmit modes (-emit):\n")
+	for _, name := range emitModes() {
+		fmt.Fprintf(&sb, "  %s\n", name)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
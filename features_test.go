@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestListFeatures tests the listFeatures function.
+func TestListFeatures(t *testing.T) {
+	var buf bytes.Buffer
+	if err := listFeatures(&buf); err != nil {
+		t.Fatalf("listFeatures() error = %v", err)
+	}
+	got := buf.String()
+
+	var want []string
+	for _, esc := range escapeSequences {
+		want = append(want, esc.Sequence)
+	}
+	want = append(want, decompressorNames()...)
+	want = append(want, dialectNames()...)
+	want = append(want, outputFormatNames()...)
+	want = append(want, digestNames()...)
+	want = append(want, emitModes()...)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"escape header", "Escape sequences:"},
+		{"compression header", "Compression formats:"},
+		{"dialect header", "Input dialects (-dialect):"},
+		{"format header", "Output formats (-format):"},
+		{"digest header", "Digests (-digest):"},
+		{"emit header", "Emit modes (-emit):"},
+	}
+	for _, entry := range want {
+		tests = append(tests, struct {
+			name string
+			want string
+		}{"entry " + entry, "  " + entry})
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("listFeatures() output missing %q:\n%s", tt.want, got)
+			}
+		})
+	}
+}
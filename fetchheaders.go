@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// parseHeadersBlock parses the headers object of a DevTools "Copy as fetch
+// (Node.js)" export into Headers. s is the object literal itself ({ ... }),
+// optionally preceded by its "headers": key and followed by a comma, as
+// when the line is copied along with it. The literal is repaired with
+// repairJSON first, so trailing commas, unquoted keys and 'single quotes'
+// are tolerated.
+//
+// Headers keep the order of the object. Keys that differ only in case, or
+// repeat outright, are all kept, as Headers allows repeated names. Numbers
+// and booleans are kept in their JSON form; null and nested values are an
+// error.
+func parseHeadersBlock(s string) (Headers, error) {
+	block := strings.TrimSpace(s)
+	block = strings.TrimSuffix(block, ",")
+	if key, rest, ok := strings.Cut(block, ":"); ok && strings.Trim(strings.TrimSpace(key), `"'`) == "headers" {
+		block = strings.TrimSpace(rest)
+	}
+	if !strings.HasPrefix(block, "{") {
+		return nil, errors.New("parseHeadersBlock: expected a { ... } headers object")
+	}
+	repaired, err := repairJSON([]byte(block))
+	if err != nil {
+		return nil, fmt.Errorf("parseHeadersBlock: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(repaired))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, errors.New("parseHeadersBlock: expected a { ... } headers object")
+	}
+	var headers Headers
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("parseHeadersBlock: %w", err)
+		}
+		name := tok.(string)
+		tok, err = dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("parseHeadersBlock: %w", err)
+		}
+		var value string
+		switch v := tok.(type) {
+		case string:
+			value = v
+		case json.Number:
+			value = v.String()
+		case bool:
+			value = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("parseHeadersBlock: the value of header %q is not a string, number or boolean", name)
+		}
+		headers = append(headers, Header{Name: name, Value: value})
+	}
+	return headers, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// devToolsHeadersBlock is the headers object of a DevTools "Copy as fetch
+// (Node.js)" export, with its key and trailing commas as copied.
+const devToolsHeadersBlock = `"headers": {
+    "accept": "*/*",
+    "accept-language": "en-US,en;q=0.9",
+    "content-type": "application/json",
+    "sec-ch-ua": "\"Chromium\";v=\"124\", \"Not-A.Brand\";v=\"99\"",
+    "sec-ch-ua-mobile": "?0",
+    "x-request-id": "1",
+    "X-Request-Id": "2",
+    "Referer": "https://example.com/app",
+  },`
+
+// TestParseHeadersBlock tests the parseHeadersBlock function.
+func TestParseHeadersBlock(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Headers
+		errorMsg string
+	}{
+		{
+			name:  "devtools block",
+			input: devToolsHeadersBlock,
+			expected: Headers{
+				{"accept", "*/*"},
+				{"accept-language", "en-US,en;q=0.9"},
+				{"content-type", "application/json"},
+				{"sec-ch-ua", `"Chromium";v="124", "Not-A.Brand";v="99"`},
+				{"sec-ch-ua-mobile", "?0"},
+				{"x-request-id", "1"},
+				{"X-Request-Id", "2"},
+				{"Referer", "https://example.com/app"},
+			},
+		},
+		{"bare object", `{"a": "1"}`, Headers{{"a", "1"}}, ""},
+		{"repeated key", `{"a": "1", "a": "2",}`, Headers{{"a", "1"}, {"a", "2"}}, ""},
+		{"unquoted key and single quotes", `headers: {accept: 'text/html'}`, Headers{{"accept", "text/html"}}, ""},
+		{"number and boolean", `{"dnt": 1, "x-debug": true}`, Headers{{"dnt", "1"}, {"x-debug", "true"}}, ""},
+		{"empty object", `{}`, nil, ""},
+		{"not an object", `"accept": "*/*"`, nil, "expected a { ... } headers object"},
+		{"nested value", `{"a": {"b": "c"}}`, nil, `the value of header "a" is not a string`},
+		{"null value", `{"a": null}`, nil, `the value of header "a" is not a string`},
+		{"unterminated", `{"a": "1"`, nil, "parseHeadersBlock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHeadersBlock(tt.input)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("parseHeadersBlock() error = %v; want error containing %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseHeadersBlock() returned an unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseHeadersBlock() = %q; want %q", got, tt.expected)
+			}
+			if tt.name == "devtools block" && got.Get("x-request-id") != "1" {
+				t.Errorf("Get(x-request-id) = %q; want the first of the repeated keys", got.Get("x-request-id"))
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import "strings"
+
+// parseFieldList splits a -fields value such as "id, user.name" into its
+// dotted paths, dropping empty entries.
+func parseFieldList(s string) []string {
+	var fields []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// projectFields reduces a JSON value decoded by encoding/json to the given
+// dotted paths, keeping their nesting: "user.name" keeps only the name member
+// of the user object. Missing paths are omitted. An array is projected element
+// by element, so a list of records keeps the same fields in each.
+func projectFields(v interface{}, fields []string) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		projected := make([]interface{}, len(v))
+		for i, elem := range v {
+			projected[i] = projectFields(elem, fields)
+		}
+		return projected
+	case map[string]interface{}:
+		// Group the paths by their first key; a bare key keeps the whole value.
+		var keys []string
+		rest := map[string][]string{}
+		whole := map[string]bool{}
+		for _, f := range fields {
+			key, sub, nested := strings.Cut(f, ".")
+			if _, seen := rest[key]; !seen && !whole[key] {
+				keys = append(keys, key)
+			}
+			if nested {
+				rest[key] = append(rest[key], sub)
+			} else {
+				whole[key] = true
+			}
+		}
+		projected := map[string]interface{}{}
+		for _, key := range keys {
+			value, ok := v[key]
+			switch {
+			case !ok:
+			case whole[key]:
+				projected[key] = value
+			default:
+				switch value.(type) {
+				case map[string]interface{}, []interface{}:
+					if sub := projectFields(value, rest[key]); !isEmptyObject(sub) {
+						projected[key] = sub
+					}
+				}
+			}
+		}
+		return projected
+	default:
+		return v
+	}
+}
+
+// isEmptyObject reports whether v is a JSON object without members.
+func isEmptyObject(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	return ok && len(m) == 0
+}
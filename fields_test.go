@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestProjectFields tests the projectFields function.
+func TestProjectFields(t *testing.T) {
+	const doc = `{"id": 7, "name": "ada", "status": "active", "secret": "x",
+		"user": {"email": "a@example.com", "address": {"city": "Paris", "zip": "75001"}},
+		"items": [{"sku": "a", "qty": 1, "price": 2}, {"sku": "b", "qty": 3}]}`
+
+	tests := []struct {
+		name     string
+		fields   []string
+		expected string
+	}{
+		{"top-level keys", []string{"id", "name", "status"}, `{"id": 7, "name": "ada", "status": "active"}`},
+		{"absent keys are omitted", []string{"id", "missing", "user.missing"}, `{"id": 7}`},
+		{"nested path", []string{"user.address.city"}, `{"user": {"address": {"city": "Paris"}}}`},
+		{"sibling nested paths merge", []string{"user.email", "user.address.zip"}, `{"user": {"email": "a@example.com", "address": {"zip": "75001"}}}`},
+		{"whole object wins over a nested path", []string{"user.email", "user"}, `{"user": {"email": "a@example.com", "address": {"city": "Paris", "zip": "75001"}}}`},
+		{"path through an array", []string{"items.sku", "items.qty"}, `{"items": [{"sku": "a", "qty": 1}, {"sku": "b", "qty": 3}]}`},
+		{"path below a scalar", []string{"id.x"}, `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v, expected interface{}
+			if err := json.Unmarshal([]byte(doc), &v); err != nil {
+				t.Fatalf("Failed to parse fixture: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatalf("Failed to parse expectation: %v", err)
+			}
+			if got := projectFields(v, tt.fields); !reflect.DeepEqual(got, expected) {
+				t.Errorf("projectFields(%q) = %v; want %v", tt.fields, got, expected)
+			}
+		})
+	}
+}
+
+// TestRunFields tests that -fields reduces a JSON body and each element of a top-level array.
+func TestRunFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		fields   string
+		expected string
+	}{
+		{"object", `curl 'u' --data-raw $'{"id":1,"name":"a","big":[1,2,3]}'`, "id, name", `{"id":1,"name":"a"}`},
+		{"array of records", `curl 'u' --data-raw $'[{"id":1,"x":0},{"id":2}]'`, "id", `[{"id":1},{"id":2}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(tt.command, Options{Fields: parseFieldList(tt.fields), Canonical: true})
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %s; want %s", got, tt.expected)
+			}
+		})
+	}
+}
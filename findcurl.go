@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// curlInvocationPattern matches the word curl in command position: at the
+// start of a line (after indentation or a "$ " prompt) or after ;, &, |, $(
+// or a backtick.
+var curlInvocationPattern = regexp.MustCompile("(?m)(?:^[ \\t]*(?:\\$[ \\t]+)?|[;&|(`][ \\t]*)curl(?:[ \\t]|\\\\\\r?\\n|$)")
+
+// findCurlCommand locates the first curl invocation in text, such as a shell
+// script with set -e and variable assignments around it, and returns just that
+// command. The command runs to the first unquoted newline that is not a
+// backslash continuation, or to an unquoted ;, &, |, ) or backtick, or to a
+// comment; a here-document it reads is included up to its delimiter line.
+func findCurlCommand(text string) (command string, line int, err error) {
+	loc := curlInvocationPattern.FindStringIndex(text)
+	if loc == nil {
+		return "", 0, errors.New("no curl invocation found in the input")
+	}
+	start := loc[0] + strings.Index(text[loc[0]:loc[1]], "curl")
+	end, err := shellCommandEnd(text, start)
+	if err != nil {
+		return "", 0, err
+	}
+	command = text[start:end]
+	if m := heredocPattern.FindStringSubmatch(command); m != nil && end < len(text) && (text[end] == '\n' || text[end] == '\r') {
+		delimiter := m[2] + m[3] + m[5]
+		for pos := end + strings.IndexByte(text[end:], '\n') + 1; pos < len(text); {
+			next := strings.IndexByte(text[pos:], '\n')
+			lineEnd := len(text)
+			if next >= 0 {
+				lineEnd = pos + next
+			}
+			l := strings.TrimSuffix(text[pos:lineEnd], "\r")
+			if m[1] == "-" {
+				l = strings.TrimLeft(l, "\t")
+			}
+			if l == delimiter {
+				command = text[start:lineEnd]
+				break
+			}
+			pos = lineEnd + 1
+		}
+	}
+	return strings.TrimSpace(command), strings.Count(text[:start], "\n") + 1, nil
+}
+
+// shellCommandEnd returns the index in text where the shell command starting
+// at start ends, following quotes and backslash continuations.
+func shellCommandEnd(text string, start int) (int, error) {
+	for i := start; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '\\':
+			i++ // Skips the escaped character, including a continuation newline.
+			if i+1 < len(text) && text[i] == '\r' && text[i+1] == '\n' {
+				i++
+			}
+		case '\'':
+			ansiC := i > 0 && text[i-1] == '$'
+			for i++; i < len(text) && text[i] != '\''; i++ {
+				if ansiC && text[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(text) {
+				return 0, fmt.Errorf("unterminated ' quote in the curl command at byte %d", start)
+			}
+		case '"':
+			for i++; i < len(text) && text[i] != '"'; i++ {
+				if text[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(text) {
+				return 0, fmt.Errorf("unterminated \" quote in the curl command at byte %d", start)
+			}
+		case '#':
+			if text[i-1] == ' ' || text[i-1] == '\t' {
+				return i, nil
+			}
+		case '\n', ';', '&', '|', ')', '`':
+			if c == '\n' && i > start && text[i-1] == '\r' {
+				return i - 1, nil
+			}
+			return i, nil
+		}
+	}
+	return len(text), nil
+}
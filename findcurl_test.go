@@ -0,0 +1,97 @@
+package main
+
+import "testing"
+
+// TestFindCurlCommand tests the findCurlCommand function.
+func TestFindCurlCommand(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		expected     string
+		expectedLine int
+		expectError  bool
+	}{
+		{
+			name: "command in the middle of a script",
+			text: "#!/bin/bash\nset -euo pipefail\nTOKEN=abc\n# send the event\n" +
+				"curl 'https://example.com/api' \\\n  -H 'Content-Type: application/json' \\\n  --data-raw $'{\"a\":\\'1\\'}'\n" +
+				"echo done\n",
+			expected:     "curl 'https://example.com/api' \\\n  -H 'Content-Type: application/json' \\\n  --data-raw $'{\"a\":\\'1\\'}'",
+			expectedLine: 5,
+		},
+		{
+			name:         "ends at a pipe",
+			text:         "RESP=$(curl -s 'u' -d 'a=1' | jq .)\n",
+			expected:     "curl -s 'u' -d 'a=1'",
+			expectedLine: 1,
+		},
+		{
+			name:         "ends at && and keeps quoted separators",
+			text:         "cd /tmp && curl 'u?a=1&b=2' -d \"x;y|z\" && echo ok",
+			expected:     "curl 'u?a=1&b=2' -d \"x;y|z\"",
+			expectedLine: 1,
+		},
+		{
+			name:         "prompt and trailing comment",
+			text:         "$ curl 'u' -d 'x' # copied from devtools\n",
+			expected:     "curl 'u' -d 'x'",
+			expectedLine: 1,
+		},
+		{
+			name:         "multi-line quoted body",
+			text:         "x=1\ncurl 'u' --data-raw '{\n  \"a\": 1\n}'\ny=2",
+			expected:     "curl 'u' --data-raw '{\n  \"a\": 1\n}'",
+			expectedLine: 2,
+		},
+		{
+			name:         "crlf continuations",
+			text:         "set -e\r\ncurl 'u' \\\r\n  -d 'x'\r\necho\r\n",
+			expected:     "curl 'u' \\\r\n  -d 'x'",
+			expectedLine: 2,
+		},
+		{
+			name:         "here-document body is included",
+			text:         "curl 'u' --data-binary @- <<'EOF'\n{\"a\": 1}\nEOF\necho done",
+			expected:     "curl 'u' --data-binary @- <<'EOF'\n{\"a\": 1}\nEOF",
+			expectedLine: 1,
+		},
+		{
+			name:         "curl in an echo is skipped",
+			text:         "echo \"run curl later\"\ncurl 'u' -d 'x'",
+			expected:     "curl 'u' -d 'x'",
+			expectedLine: 2,
+		},
+		{name: "no curl", text: "echo curl\nwget 'u'", expectError: true},
+		{name: "unterminated quote", text: "curl 'u' -d 'x", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, line, err := findCurlCommand(tt.text)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("findCurlCommand() expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("findCurlCommand() returned an unexpected error: %v", err)
+			}
+			if got != tt.expected || line != tt.expectedLine {
+				t.Errorf("findCurlCommand() = (%q, line %d); want (%q, line %d)", got, line, tt.expected, tt.expectedLine)
+			}
+		})
+	}
+}
+
+// TestRunFindCurl tests that Run decodes a curl command embedded in a script with FindCurl.
+func TestRunFindCurl(t *testing.T) {
+	script := "#!/bin/sh\nset -e\nURL=https://example.com\ncurl \"$URL\" \\\n  --data-raw $'{\"a\":\\x31}'\necho sent\n"
+	got, err := Run(script, Options{FindCurl: true, Canonical: true})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if expected := `{"a":1}`; string(got) != expected {
+		t.Errorf("Run() = %q; want %q", got, expected)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import "strings"
+
+// curlShortFlags maps cURL's single-letter options to their long names, which
+// are the names flags are reported under.
+var curlShortFlags = map[string]string{
+	"-A": "--user-agent", "-b": "--cookie", "-c": "--cookie-jar", "-d": "--data",
+	"-e": "--referer", "-E": "--cert", "-F": "--form", "-G": "--get", "-H": "--header",
+	"-i": "--include", "-I": "--head", "-k": "--insecure", "-L": "--location",
+	"-m": "--max-time", "-o": "--output", "-s": "--silent", "-S": "--show-error",
+	"-u": "--user", "-v": "--verbose", "-w": "--write-out", "-x": "--proxy", "-X": "--request",
+	"-:": "--next",
+}
+
+// curlDataFlags are the cURL options whose value is sent as the request body.
+var curlDataFlags = map[string]bool{
+	"--data": true, "--data-raw": true, "--data-binary": true, "--data-ascii": true,
+}
+
+// withDataFlags returns curlDataFlags extended with the custom option names
+// given by -data-flag, for cURL wrappers that carry the body in their own
+// option. A name without leading dashes is taken as a long option.
+func withDataFlags(custom []string) map[string]bool {
+	if len(custom) == 0 {
+		return curlDataFlags
+	}
+	flags := make(map[string]bool, len(curlDataFlags)+len(custom))
+	for name := range curlDataFlags {
+		flags[name] = true
+	}
+	for _, name := range custom {
+		flags[normalizeFlagName(name)] = true
+	}
+	return flags
+}
+
+// normalizeFlagName prefixes a bare option name with "--" and maps short
+// cURL options to their long names.
+func normalizeFlagName(name string) string {
+	if !strings.HasPrefix(name, "-") {
+		return "--" + name
+	}
+	if long, ok := curlShortFlags[name]; ok {
+		return long
+	}
+	return name
+}
+
+// curlValueFlags are the cURL options that take a value, so that the value is
+// not mistaken for the URL. Options missing here are treated as booleans.
+var curlValueFlags = map[string]bool{
+	"--data": true, "--data-raw": true, "--data-binary": true, "--data-ascii": true,
+	"--data-urlencode": true, "--request": true, "--header": true, "--cookie": true,
+	"--cookie-jar": true, "--user-agent": true, "--referer": true, "--user": true,
+	"--output": true, "--url": true, "--max-time": true, "--connect-timeout": true,
+	"--proxy": true, "--form": true, "--write-out": true, "--cert": true, "--key": true,
+	"--cacert": true, "--capath": true, "--resolve": true, "--retry": true, "--json": true,
+}
+
+// curlFlag is one occurrence of an option in a tokenized cURL command.
+type curlFlag struct {
+	// Name is the long option name, e.g. "--header" for both -H and --header.
+	Name string
+	// Value is the option's argument; HasValue is false for boolean options
+	// and for a value option at the very end of the command.
+	Value    Token
+	HasValue bool
+	// Word is the word the option was given in, e.g. "-sSL" for --location.
+	Word Token
+}
+
+// scanFlags walks tokenized cURL arguments (without the leading "curl") and
+// returns the options in command order together with the positional
+// arguments. It understands "--name value", "--name=value", "-H value",
+// attached short values ("-XPOST"), combined boolean short options ("-sSL")
+// and "--" ending option parsing.
+func scanFlags(tokens []Token) (flags []curlFlag, positional []Token) {
+	return scanFlagsWith(tokens, nil)
+}
+
+// scanFlagsWith is scanFlags treating the options in extraValueFlags (keyed
+// like curlValueFlags) as taking a value too.
+func scanFlagsWith(tokens []Token, extraValueFlags map[string]bool) (flags []curlFlag, positional []Token) {
+	takesValue := func(name string) bool { return curlValueFlags[name] || extraValueFlags[name] }
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		arg := tok.Value
+		switch {
+		case arg == "--":
+			return flags, append(positional, tokens[i+1:]...)
+		case strings.HasPrefix(arg, "--"):
+			if name, value, ok := strings.Cut(arg, "="); ok {
+				flags = append(flags, curlFlag{Name: name, Value: Token{Value: value, ANSIC: tok.ANSIC, Start: tok.Start, End: tok.End}, HasValue: true, Word: tok})
+				continue
+			}
+			f := curlFlag{Name: arg, Word: tok}
+			if takesValue(arg) && i+1 < len(tokens) {
+				i++
+				f.Value, f.HasValue = tokens[i], true
+			}
+			flags = append(flags, f)
+		case len(arg) > 1 && arg[0] == '-':
+			// Combined short options: every letter up to the first one taking a
+			// value is a boolean; the rest of the word (or the next word) is that
+			// option's value.
+			for j := 1; j < len(arg); j++ {
+				short := "-" + arg[j:j+1]
+				name := short
+				if long, ok := curlShortFlags[short]; ok {
+					name = long
+				}
+				if !takesValue(name) {
+					flags = append(flags, curlFlag{Name: name, Word: tok})
+					continue
+				}
+				f := curlFlag{Name: name, Word: tok}
+				if j+1 < len(arg) {
+					f.Value, f.HasValue = Token{Value: arg[j+1:], ANSIC: tok.ANSIC, Start: tok.Start, End: tok.End}, true
+				} else if i+1 < len(tokens) {
+					i++
+					f.Value, f.HasValue = tokens[i], true
+				}
+				flags = append(flags, f)
+				break
+			}
+		default:
+			positional = append(positional, tok)
+		}
+	}
+	return flags, positional
+}
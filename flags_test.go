@@ -0,0 +1,93 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestScanFlags tests the scanFlags function.
+func TestScanFlags(t *testing.T) {
+	tests := []struct {
+		name               string
+		tokens             []string
+		expectedFlags      []string // name=value, or the bare name without a value
+		expectedPositional []string
+	}{
+		{
+			name:               "short and long headers share a name",
+			tokens:             []string{"https://x", "-H", "A: 1", "--header", "B: 2"},
+			expectedFlags:      []string{"--header=A: 1", "--header=B: 2"},
+			expectedPositional: []string{"https://x"},
+		},
+		{
+			name:               "equals form",
+			tokens:             []string{"--request=PUT", "--url=https://x"},
+			expectedFlags:      []string{"--request=PUT", "--url=https://x"},
+			expectedPositional: nil,
+		},
+		{
+			name:               "attached short value",
+			tokens:             []string{"-XPOST", "https://x"},
+			expectedFlags:      []string{"--request=POST"},
+			expectedPositional: []string{"https://x"},
+		},
+		{
+			name:               "combined booleans then value",
+			tokens:             []string{"-sSLd", "a=1", "https://x"},
+			expectedFlags:      []string{"--silent", "--show-error", "--location", "--data=a=1"},
+			expectedPositional: []string{"https://x"},
+		},
+		{
+			name:               "repeated data and boolean",
+			tokens:             []string{"-d", "a", "--compressed", "--data-raw", "b", "-d", "c"},
+			expectedFlags:      []string{"--data=a", "--compressed", "--data-raw=b", "--data=c"},
+			expectedPositional: nil,
+		},
+		{
+			name:               "unknown short flag kept",
+			tokens:             []string{"-Z", "https://x"},
+			expectedFlags:      []string{"-Z"},
+			expectedPositional: []string{"https://x"},
+		},
+		{
+			name:               "double dash ends options",
+			tokens:             []string{"-k", "--", "-not-a-flag"},
+			expectedFlags:      []string{"--insecure"},
+			expectedPositional: []string{"-not-a-flag"},
+		},
+		{
+			name:               "value flag at end",
+			tokens:             []string{"https://x", "-H"},
+			expectedFlags:      []string{"--header"},
+			expectedPositional: []string{"https://x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := make([]Token, len(tt.tokens))
+			for i, s := range tt.tokens {
+				tokens[i] = Token{Value: s}
+			}
+			flags, positional := scanFlags(tokens)
+			var gotFlags []string
+			for _, f := range flags {
+				if f.HasValue {
+					gotFlags = append(gotFlags, f.Name+"="+f.Value.Value)
+				} else {
+					gotFlags = append(gotFlags, f.Name)
+				}
+			}
+			var gotPositional []string
+			for _, tok := range positional {
+				gotPositional = append(gotPositional, tok.Value)
+			}
+			if !reflect.DeepEqual(gotFlags, tt.expectedFlags) {
+				t.Errorf("scanFlags(%q) flags = %q; want %q", tt.tokens, gotFlags, tt.expectedFlags)
+			}
+			if !reflect.DeepEqual(gotPositional, tt.expectedPositional) {
+				t.Errorf("scanFlags(%q) positional = %q; want %q", tt.tokens, gotPositional, tt.expectedPositional)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultCArrayWidth is the number of bytes per line of -format carray output, as used by xxd -i.
+const defaultCArrayWidth = 12
+
+// outputFormats render the final (decoded and decompressed) body bytes in a
+// representation other than the default Content-Type driven one, keyed by the
+// -format name.
+var outputFormats = map[string]func(data []byte, opts Options) ([]byte, error){
+	"carray":    formatCArray,
+	"escaped":   formatEscaped,
+	"hexstring": formatHexString,
+	"repr":      formatRepr,
+	"xml":       formatXML,
+	"yaml":      formatYAML,
+}
+
+// outputFormatNames returns the names of the registered -format values in sorted order.
+func outputFormatNames() []string {
+	names := make([]string, 0, len(outputFormats))
+	for name := range outputFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatCArray renders data as a C array definition in the style of xxd -i,
+// with opts.CArrayWidth bytes per line (defaultCArrayWidth when unset).
+func formatCArray(data []byte, opts Options) ([]byte, error) {
+	width := opts.CArrayWidth
+	if width <= 0 {
+		width = defaultCArrayWidth
+	}
+	var sb strings.Builder
+	sb.WriteString("unsigned char data[] = {\n")
+	for i := 0; i < len(data); i += width {
+		line := data[i:min(i+width, len(data))]
+		sb.WriteString(" ")
+		for j, b := range line {
+			fmt.Fprintf(&sb, " 0x%02x", b)
+			if i+j < len(data)-1 {
+				sb.WriteByte(',')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	fmt.Fprintf(&sb, "};\nunsigned int data_len = %d;\n", len(data))
+	return []byte(sb.String()), nil
+}
+
+// formatHexString renders data as one continuous lowercase hex string.
+func formatHexString(data []byte, opts Options) ([]byte, error) {
+	return []byte(hex.EncodeToString(data)), nil
+}
+
+// formatRepr renders data in Python's b'...' notation, wrapped into lines of
+// at most opts.ReprWidth characters when it is set.
+func formatRepr(data []byte, opts Options) ([]byte, error) {
+	return []byte(strings.Join(reprLines(data, opts.ReprWidth), "\n")), nil
+}
+
+// formatEscaped renders data as a $'...' quoted string, ready to be pasted
+// back into a curl command as the --data-raw value.
+func formatEscaped(data []byte, opts Options) ([]byte, error) {
+	return []byte("$'" + encodeRawData(data) + "'"), nil
+}
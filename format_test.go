@@ -0,0 +1,136 @@
+package main
+
+import (
+	"testing"
+)
+
+// TestFormatCArray tests the formatCArray function.
+func TestFormatCArray(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		width    int
+		expected string
+	}{
+		{"empty body", []byte{}, 0, "unsigned char data[] = {\n};\nunsigned int data_len = 0;\n"},
+		{"single byte", []byte{0x7b}, 0, "unsigned char data[] = {\n  0x7b\n};\nunsigned int data_len = 1;\n"},
+		{"default width", []byte("hello world!x"), 0,
+			"unsigned char data[] = {\n  0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x20, 0x77, 0x6f, 0x72, 0x6c, 0x64, 0x21,\n  0x78\n};\nunsigned int data_len = 13;\n"},
+		{"custom width", []byte{1, 2, 3, 4}, 2, "unsigned char data[] = {\n  0x01, 0x02,\n  0x03, 0x04\n};\nunsigned int data_len = 4;\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatCArray(tt.input, Options{CArrayWidth: tt.width})
+			if err != nil {
+				t.Fatalf("formatCArray() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("formatCArray(%x, %d) = %q; want %q", tt.input, tt.width, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestFormatHexString tests the formatHexString function.
+func TestFormatHexString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		{"empty body", []byte{}, ""},
+		{"small body", []byte{0x1f, 0x8b, 'A'}, "1f8b41"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatHexString(tt.input, Options{})
+			if err != nil {
+				t.Fatalf("formatHexString() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("formatHexString(%x) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestFormatRepr tests the formatRepr function.
+func TestFormatRepr(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		width    int
+		expected string
+	}{
+		{"one line", []byte("a\x1f\x8b"), 0, `b'a\x1f\x8b'`},
+		{"wrapped", []byte("a\x1f\x8b"), 7, "b'a'\n" + `b'\x1f'` + "\n" + `b'\x8b'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatRepr(tt.input, Options{ReprWidth: tt.width})
+			if err != nil {
+				t.Fatalf("formatRepr() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("formatRepr(%x) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestFormatEscaped tests that formatEscaped output can be fed back into Run.
+func TestFormatEscaped(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		{"empty body", []byte{}, "$''"},
+		{"json body", []byte("{\"msg\": \"it's\"}\n"), `$'{"msg": "it\'s"}\n'`},
+		{"binary body", []byte{0x1f, 0x8b, 0x00}, `$'\x1f\x8b\x00'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatEscaped(tt.input, Options{})
+			if err != nil {
+				t.Fatalf("formatEscaped() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("formatEscaped(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+
+	// A gzipped body written back escaped decodes to the same bytes again.
+	body := gzipBytes(t, " {\"a\": [1, 2]}\n")
+	escaped, err := Run("curl 'url' --data-raw $'"+hexEscape(body)+"'", Options{Format: "escaped"})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	again, err := Run("curl 'url' --data-raw "+string(escaped), Options{Format: "escaped"})
+	if err != nil {
+		t.Fatalf("Run() over the escaped output returned an unexpected error: %v", err)
+	}
+	if string(again) != string(escaped) {
+		t.Errorf("Run() round trip = %q; want %q", again, escaped)
+	}
+}
+
+// TestRunOutputFormat tests that Run renders the decompressed body with the selected format.
+func TestRunOutputFormat(t *testing.T) {
+	command := "curl 'url' --data-raw $'" + hexEscape(gzipBytes(t, `{}`)) + "'"
+	got, err := Run(command, Options{Format: "hexstring"})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if string(got) != "7b7d" {
+		t.Errorf("Run() = %q; want %q", got, "7b7d")
+	}
+	if _, err := Run(command, Options{Format: "bogus"}); err == nil {
+		t.Errorf("Run() with an unknown format should have returned an error, but got nil")
+	}
+}
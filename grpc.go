@@ -0,0 +1,35 @@
+package main
+
+import "fmt"
+
+// formatGRPC de-frames a gRPC body, as captured from an HTTP/2 request or
+// response, and reports each message's length with a hex dump of its bytes.
+// gRPC uses the grpc-web framing without trailer frames: its trailers travel
+// as HTTP/2 headers. Messages with the compressed flag are decompressed when
+// they carry gzip (grpc-encoding: gzip) or other known compressed data. A body
+// that is not gRPC framed is returned unchanged.
+func formatGRPC(body []byte, opts Options) ([]byte, error) {
+	frames, err := parseGRPCFrames(body)
+	if err != nil {
+		logger.Info(fmt.Sprintf("Body is not gRPC framed (%v), saving raw processed data to output file.", err), field("error", err))
+		return body, nil
+	}
+	out, messages := writeGRPCFrames(frames, "gRPC")
+	fmt.Fprintf(previews, "De-framed %d gRPC message(s).\n", messages)
+	return out, nil
+}
+
+// parseGRPCFrames splits a gRPC body into its length-prefixed messages. It is
+// parseGRPCWebFrames without the trailer flag, which gRPC does not use.
+func parseGRPCFrames(body []byte) ([]grpcWebFrame, error) {
+	frames, err := parseGRPCWebFrames(body)
+	if err != nil {
+		return nil, err
+	}
+	for i, frame := range frames {
+		if frame.Trailer {
+			return nil, fmt.Errorf("frame %d has the grpc-web trailer flag (use -grpcweb for grpc-web bodies)", i+1)
+		}
+	}
+	return frames, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+// TestFormatGRPC tests the formatGRPC function.
+func TestFormatGRPC(t *testing.T) {
+	compressed := grpcWebFrameBytes(1, string(gzipBytes(t, "\x08\x96\x01")))
+	body := append(append([]byte{}, compressed...), grpcWebFrameBytes(0, "\x12\x02hi")...)
+	expected := "message 1: " + strconv.Itoa(len(compressed)-grpcWebHeaderLength) + " bytes (compressed)\n" +
+		"decompressed (gzip): 3 bytes\n" +
+		"00000000  08 96 01                                          |...|\n" +
+		"message 2: 4 bytes\n" +
+		"00000000  12 02 68 69                                       |..hi|\n"
+	withTrailer := append(grpcWebFrameBytes(0, "x"), grpcWebFrameBytes(0x80, "grpc-status:0")...)
+
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		{"compressed and uncompressed frames", body, expected},
+		{"not grpc", []byte("plain text"), "plain text"},
+		{"grpc-web trailer frame", withTrailer, string(withTrailer)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatGRPC(tt.input, Options{})
+			if err != nil {
+				t.Fatalf("formatGRPC() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("formatGRPC() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// grpc-web frame flag bits. The low bit marks a compressed message and the
+// high bit a trailer frame holding the grpc-status headers.
+const (
+	grpcWebFlagCompressed = 0x01
+	grpcWebFlagTrailer    = 0x80
+	grpcWebHeaderLength   = 5
+)
+
+// grpcWebFrame is one length-prefixed frame of a grpc-web body.
+type grpcWebFrame struct {
+	Compressed bool
+	Trailer    bool
+	Data       []byte
+}
+
+// parseGRPCWebFrames splits a binary grpc-web body into its frames. Each
+// frame is a flag byte and a 4-byte big-endian length followed by that many
+// bytes; a body that does not consist of whole frames is an error.
+func parseGRPCWebFrames(body []byte) ([]grpcWebFrame, error) {
+	if len(body) == 0 {
+		return nil, errors.New("empty body")
+	}
+	var frames []grpcWebFrame
+	for offset := 0; offset < len(body); {
+		if len(body)-offset < grpcWebHeaderLength {
+			return nil, fmt.Errorf("truncated frame header at offset %d", offset)
+		}
+		flag := body[offset]
+		if flag&^(grpcWebFlagCompressed|grpcWebFlagTrailer) != 0 {
+			return nil, fmt.Errorf("invalid frame flag 0x%02x at offset %d", flag, offset)
+		}
+		length := binary.BigEndian.Uint32(body[offset+1 : offset+grpcWebHeaderLength])
+		offset += grpcWebHeaderLength
+		if uint64(length) > uint64(len(body)-offset) {
+			return nil, fmt.Errorf("frame at offset %d declares %d bytes but only %d remain", offset-grpcWebHeaderLength, length, len(body)-offset)
+		}
+		frames = append(frames, grpcWebFrame{
+			Compressed: flag&grpcWebFlagCompressed != 0,
+			Trailer:    flag&grpcWebFlagTrailer != 0,
+			Data:       body[offset : offset+int(length)],
+		})
+		offset += int(length)
+	}
+	return frames, nil
+}
+
+// decodeGRPCWebText decodes a grpc-web-text body. Servers may encode each
+// frame separately, so the body can be several padded base64 chunks glued
+// together; every chunk is decoded on its own and the results concatenated.
+func decodeGRPCWebText(body []byte) ([]byte, error) {
+	text := strings.Join(strings.Fields(string(body)), "")
+	if text == "" {
+		return nil, errors.New("empty body")
+	}
+	var decoded []byte
+	for text != "" {
+		end := strings.IndexByte(text, '=')
+		if end < 0 {
+			end = len(text)
+		} else {
+			for end < len(text) && text[end] == '=' {
+				end++
+			}
+		}
+		chunk, err := base64.StdEncoding.DecodeString(text[:end])
+		if err != nil {
+			return nil, err
+		}
+		decoded = append(decoded, chunk...)
+		text = text[end:]
+	}
+	return decoded, nil
+}
+
+// formatGRPCWeb de-frames a grpc-web body, binary or base64 text, and reports
+// each message's length with a hex dump of its bytes. Compressed messages are
+// decompressed when they carry gzip or zlib data, and trailer frames are shown
+// as text. A body that is not grpc-web framed is returned unchanged.
+func formatGRPCWeb(body []byte, opts Options) ([]byte, error) {
+	frames, err := parseGRPCWebFrames(body)
+	if err != nil {
+		decoded, decodeErr := decodeGRPCWebText(body)
+		if decodeErr != nil {
+			logger.Info(fmt.Sprintf("Body is not grpc-web framed (%v), saving raw processed data to output file.", err), field("error", err))
+			return body, nil
+		}
+		if frames, err = parseGRPCWebFrames(decoded); err != nil {
+			logger.Info(fmt.Sprintf("Body is not grpc-web framed (%v), saving raw processed data to output file.", err), field("error", err))
+			return body, nil
+		}
+		fmt.Fprintln(previews, "Decoded grpc-web-text base64 body.")
+	}
+
+	out, messages := writeGRPCFrames(frames, "grpc-web")
+	fmt.Fprintf(previews, "De-framed %d grpc-web message(s) from %d frame(s).\n", messages, len(frames))
+	return out, nil
+}
+
+// writeGRPCFrames reports the length of each frame with a hex dump of its
+// bytes, decompressing compressed messages when they carry known compressed
+// data and showing trailer frames as text. It returns the report and the
+// number of messages; protocol names the framing in log messages.
+func writeGRPCFrames(frames []grpcWebFrame, protocol string) ([]byte, int) {
+	var out bytes.Buffer
+	messages := 0
+	for _, frame := range frames {
+		if frame.Trailer {
+			fmt.Fprintf(&out, "trailer: %d bytes\n%s\n", len(frame.Data), strings.TrimRight(string(frame.Data), "\r\n"))
+			continue
+		}
+		messages++
+		data := frame.Data
+		if frame.Compressed {
+			fmt.Fprintf(&out, "message %d: %d bytes (compressed)\n", messages, len(data))
+			if algorithm, skip := detectCompression(data); algorithm != algoNone {
+				if decompressed, err := decompressData(algorithm, data[skip:]); err == nil {
+					data = decompressed
+					fmt.Fprintf(&out, "decompressed (%s): %d bytes\n", algorithm, len(data))
+				} else {
+					logger.Warn(fmt.Sprintf("failed to decompress %s message %d: %v", protocol, messages, err), field("message", messages), field("algorithm", algorithm), field("error", err))
+				}
+			}
+		} else {
+			fmt.Fprintf(&out, "message %d: %d bytes\n", messages, len(data))
+		}
+		out.WriteString(hex.Dump(data))
+	}
+	return out.Bytes(), messages
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// grpcWebFrameBytes builds one grpc-web frame with the given flag and payload.
+func grpcWebFrameBytes(flag byte, payload string) []byte {
+	n := len(payload)
+	return append([]byte{flag, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}, payload...)
+}
+
+// TestParseGRPCWebFrames tests the parseGRPCWebFrames function.
+func TestParseGRPCWebFrames(t *testing.T) {
+	twoMessages := append(grpcWebFrameBytes(0, "\x08\x96\x01"), grpcWebFrameBytes(0, "\x12\x02hi")...)
+	withTrailer := append(grpcWebFrameBytes(0, "\x08\x01"), grpcWebFrameBytes(0x80, "grpc-status:0\r\n")...)
+
+	tests := []struct {
+		name        string
+		input       []byte
+		expected    []grpcWebFrame
+		expectError bool
+	}{
+		{
+			name:  "two messages",
+			input: twoMessages,
+			expected: []grpcWebFrame{
+				{Data: []byte("\x08\x96\x01")},
+				{Data: []byte("\x12\x02hi")},
+			},
+		},
+		{
+			name:  "message and trailer",
+			input: withTrailer,
+			expected: []grpcWebFrame{
+				{Data: []byte("\x08\x01")},
+				{Trailer: true, Data: []byte("grpc-status:0\r\n")},
+			},
+		},
+		{name: "compressed flag", input: grpcWebFrameBytes(1, "x"), expected: []grpcWebFrame{{Compressed: true, Data: []byte("x")}}},
+		{name: "empty message", input: grpcWebFrameBytes(0, ""), expected: []grpcWebFrame{{Data: []byte{}}}},
+		{name: "empty body", input: nil, expectError: true},
+		{name: "truncated header", input: []byte{0, 0, 0}, expectError: true},
+		{name: "length overrun", input: []byte{0, 0, 0, 0, 9, 'a'}, expectError: true},
+		{name: "invalid flag", input: grpcWebFrameBytes(0x42, "x"), expectError: true},
+		{name: "json body", input: []byte(`{"a":1}`), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGRPCWebFrames(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parseGRPCWebFrames(%q) error = %v; expectError %v", tt.input, err, tt.expectError)
+			}
+			if !tt.expectError && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseGRPCWebFrames(%q) = %+v; want %+v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestFormatGRPCWeb tests the formatGRPCWeb function with binary, base64 text and non-grpc-web bodies.
+func TestFormatGRPCWeb(t *testing.T) {
+	body := append(grpcWebFrameBytes(0, "\x08\x96\x01"), grpcWebFrameBytes(0, "\x12\x02hi")...)
+	body = append(body, grpcWebFrameBytes(0x80, "grpc-status:0\r\n")...)
+	expected := "message 1: 3 bytes\n" +
+		"00000000  08 96 01                                          |...|\n" +
+		"message 2: 4 bytes\n" +
+		"00000000  12 02 68 69                                       |..hi|\n" +
+		"trailer: 15 bytes\ngrpc-status:0\n"
+
+	// grpc-web-text servers may base64-encode each frame separately.
+	var chunked strings.Builder
+	for _, frame := range [][]byte{body[:8], body[8:17], body[17:]} {
+		chunked.WriteString(base64.StdEncoding.EncodeToString(frame))
+	}
+
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		{"binary", body, expected},
+		{"base64 text", []byte(base64.StdEncoding.EncodeToString(body)), expected},
+		{"chunked base64 text", []byte(chunked.String()), expected},
+		{"not grpc-web", []byte("plain text"), "plain text"},
+		{"compressed gzip message", grpcWebFrameBytes(1, string(gzipBytes(t, "ok"))), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatGRPCWeb(tt.input, Options{})
+			if err != nil {
+				t.Fatalf("formatGRPCWeb() returned an unexpected error: %v", err)
+			}
+			if tt.expected == "" {
+				if !strings.Contains(string(got), "decompressed (gzip): 2 bytes\n00000000  6f 6b") {
+					t.Errorf("formatGRPCWeb() = %q; want the decompressed message dumped", got)
+				}
+				return
+			}
+			if string(got) != tt.expected {
+				t.Errorf("formatGRPCWeb() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
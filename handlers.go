@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+	"sync"
+)
+
+// contentHandler formats a body of the media type it is registered for;
+// params are the parameters of the Content-Type header, such as the
+// multipart boundary.
+type contentHandler func(data []byte, params map[string]string, opts Options) ([]byte, error)
+
+var (
+	// contentHandlersMu guards contentHandlers, which RegisterContentHandler
+	// may change while bodies are being interpreted.
+	contentHandlersMu sync.RWMutex
+	// contentHandlers are the handlers interpretBody uses, keyed by media
+	// type or, for the types with a structured syntax suffix such as
+	// application/ld+json, by the suffix ("+json").
+	contentHandlers = map[string]contentHandler{
+		"application/json": handleJSON,
+		"+json":            handleJSON,
+		"application/x-www-form-urlencoded": func(data []byte, _ map[string]string, opts Options) ([]byte, error) {
+			return formatForm(data, opts)
+		},
+		"multipart/form-data": func(data []byte, params map[string]string, opts Options) ([]byte, error) {
+			return formatMultipart(data, params["boundary"], opts)
+		},
+		"application/xml": handleXML,
+		"text/xml":        handleXML,
+		"+xml":            handleXML,
+		"text/html": func(data []byte, _ map[string]string, opts Options) ([]byte, error) {
+			return formatMarkup(data, true, opts)
+		},
+	}
+)
+
+// handleJSON is the contentHandler of JSON bodies.
+func handleJSON(data []byte, _ map[string]string, opts Options) ([]byte, error) {
+	return formatJSON(data, opts)
+}
+
+// handleXML is the contentHandler of XML bodies.
+func handleXML(data []byte, _ map[string]string, opts Options) ([]byte, error) {
+	return formatXML(data, opts)
+}
+
+// RegisterContentHandler makes interpretBody format bodies whose Content-Type
+// is mimeType with fn, for proprietary body formats. mimeType is a media type
+// such as "application/x-foo" (parameters are ignored) or a structured syntax
+// suffix such as "+foo". A handler registered for a type that already has one,
+// including the built-in JSON, form, multipart, XML and HTML handlers,
+// replaces it. An error returned by fn fails the decoding. It panics when fn
+// is nil.
+func RegisterContentHandler(mimeType string, fn func([]byte) ([]byte, error)) {
+	if fn == nil {
+		panic("RegisterContentHandler: nil handler for " + mimeType)
+	}
+	key := strings.ToLower(strings.TrimSpace(mimeType))
+	if mediaType, _, err := mime.ParseMediaType(key); err == nil {
+		key = mediaType
+	}
+	contentHandlersMu.Lock()
+	defer contentHandlersMu.Unlock()
+	contentHandlers[key] = func(data []byte, _ map[string]string, _ Options) ([]byte, error) {
+		out, err := fn(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s handler: %w", key, err)
+		}
+		return out, nil
+	}
+}
+
+// lookupContentHandler returns the handler registered for mediaType, or for
+// its structured syntax suffix when the type itself has none.
+func lookupContentHandler(mediaType string) (contentHandler, bool) {
+	contentHandlersMu.RLock()
+	defer contentHandlersMu.RUnlock()
+	if h, ok := contentHandlers[mediaType]; ok {
+		return h, true
+	}
+	if i := strings.LastIndexByte(mediaType, '+'); i >= 0 {
+		h, ok := contentHandlers[mediaType[i:]]
+		return h, ok
+	}
+	return nil, false
+}
+
+// isJSONMediaType reports whether mediaType is JSON: application/json or a
+// type with the +json suffix.
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
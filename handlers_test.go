@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestRegisterContentHandler tests that a handler registered with
+// RegisterContentHandler formats the bodies of its Content-Type.
+func TestRegisterContentHandler(t *testing.T) {
+	errBadFoo := errors.New("bad foo")
+	RegisterContentHandler("Application/X-Foo; version=2", func(data []byte) ([]byte, error) {
+		if bytes.HasPrefix(data, []byte("bad")) {
+			return nil, errBadFoo
+		}
+		return bytes.ToUpper(data), nil
+	})
+	RegisterContentHandler("+foo", func(data []byte) ([]byte, error) {
+		return append([]byte("suffix:"), data...), nil
+	})
+	t.Cleanup(func() {
+		contentHandlersMu.Lock()
+		delete(contentHandlers, "application/x-foo")
+		delete(contentHandlers, "+foo")
+		contentHandlersMu.Unlock()
+	})
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+		expectError bool
+	}{
+		{"registered type", "application/x-foo", "hello", "HELLO", false},
+		{"registered type with parameters", "application/x-foo; charset=utf-8", "hello", "HELLO", false},
+		{"registered suffix", "application/vnd.acme+foo", "hello", "suffix:hello", false},
+		{"handler error", "application/x-foo", "bad body", "", true},
+		{"built-in json", "application/json", `{"a":1}`, "{\n  \"a\": 1\n}", false},
+		{"unregistered type", "application/x-bar", "hello", "hello", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := "curl u -H 'Content-Type: " + tt.contentType + "' --data-raw $'" + tt.body + "'"
+			got, err := Run(command, Options{})
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Run() error = %v, expectError %v", err, tt.expectError)
+			}
+			if tt.expectError && !errors.Is(err, errBadFoo) {
+				t.Errorf("Run() error = %v; want it to wrap the handler's error", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
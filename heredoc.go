@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// heredocPattern matches a here-document redirection: <<WORD, <<'WORD',
+// <<"WORD" or <<\WORD, optionally as <<- (leading tabs stripped).
+var heredocPattern = regexp.MustCompile(`<<(-?)[ \t]*(?:'([^'\n]+)'|"([^"\n]+)"|(\\?)([A-Za-z0-9_.-]+))`)
+
+// heredocStdinFlags are the data options that read the body from standard
+// input when given @-. --data-raw sends "@-" literally and is not included.
+var heredocStdinFlags = map[string]bool{
+	"--data": true, "--data-ascii": true, "--data-binary": true, "--json": true,
+}
+
+// findHeredocBody returns the body of a command such as
+//
+//	curl https://example.com --data @- <<'EOF'
+//	{"a": 1}
+//	EOF
+//
+// where a data option reads @- and stdin is a here-document. With a quoted
+// delimiter the lines are taken verbatim; with an unquoted one the shell's
+// backslash escapes (\$, \`, \\ and backslash-newline) are applied, but
+// parameter expansion and command substitution are not performed. Like curl,
+// --data and --data-ascii strip the newlines from the body while
+// --data-binary and --json keep them (except the one before the delimiter).
+// ok is false when the command has no such here-document.
+func findHeredocBody(command string) (body Token, ok bool, err error) {
+	if !strings.Contains(command, "<<") {
+		return Token{}, false, nil
+	}
+	for _, loc := range heredocPattern.FindAllStringSubmatchIndex(command, -1) {
+		if loc[0] > 0 && command[loc[0]-1] == '<' {
+			continue // A <<< here-string, not a here-document.
+		}
+		tokens, tokErr := tokenizeCurl(command[:loc[0]])
+		if tokErr != nil {
+			continue // The << is inside a quoted word.
+		}
+		flags, _ := scanFlags(tokens)
+		dataFlag := ""
+		for _, f := range flags {
+			if heredocStdinFlags[f.Name] && f.HasValue && f.Value.Value == "@-" {
+				dataFlag = f.Name
+			}
+		}
+		if dataFlag == "" {
+			continue
+		}
+
+		stripTabs := command[loc[2]:loc[3]] == "-"
+		quoted := loc[4] >= 0 || loc[6] >= 0 || loc[8] < loc[9]
+		var delimiter string
+		switch {
+		case loc[4] >= 0:
+			delimiter = command[loc[4]:loc[5]]
+		case loc[6] >= 0:
+			delimiter = command[loc[6]:loc[7]]
+		default:
+			delimiter = command[loc[10]:loc[11]]
+		}
+
+		newline := strings.IndexByte(command[loc[1]:], '\n')
+		if newline < 0 {
+			return Token{}, false, fmt.Errorf("here-document <<%s has no body", delimiter)
+		}
+		start := loc[1] + newline + 1
+		var lines []string
+		end := -1
+		for pos := start; pos < len(command); {
+			lineEnd := strings.IndexByte(command[pos:], '\n')
+			next := len(command)
+			if lineEnd < 0 {
+				lineEnd = len(command)
+			} else {
+				lineEnd += pos
+				next = lineEnd + 1
+			}
+			line := strings.TrimSuffix(command[pos:lineEnd], "\r")
+			if stripTabs {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == delimiter {
+				end = pos
+				break
+			}
+			lines = append(lines, line)
+			pos = next
+		}
+		if end < 0 {
+			return Token{}, false, fmt.Errorf("here-document <<%s is not terminated", delimiter)
+		}
+
+		text := strings.Join(lines, "\n")
+		if len(lines) > 0 {
+			text += "\n"
+		}
+		if !quoted {
+			text = unescapeHeredoc(text)
+		}
+		if dataFlag == "--data" || dataFlag == "--data-ascii" {
+			text = strings.NewReplacer("\r", "", "\n", "").Replace(text)
+		} else {
+			text = strings.TrimSuffix(text, "\n")
+		}
+		return Token{Value: text, Start: start, End: end}, true, nil
+	}
+	return Token{}, false, nil
+}
+
+// unescapeHeredoc applies the backslash escapes the shell honors in the body
+// of an unquoted here-document: \$, \`, \\ and backslash-newline. A backslash
+// before any other character is kept.
+func unescapeHeredoc(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case '$', '`', '\\':
+				sb.WriteByte(s[i+1])
+				i++
+				continue
+			case '\n':
+				i++
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,89 @@
+package main
+
+import "testing"
+
+// TestFindHeredocBody tests the findHeredocBody function.
+func TestFindHeredocBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		expected    string
+		expectedOK  bool
+		expectError bool
+	}{
+		{
+			name:       "quoted delimiter is verbatim",
+			command:    "curl https://example.com --data-binary @- <<'EOF'\n{\"price\": \"\\$5\"}\nline two\nEOF\n",
+			expected:   "{\"price\": \"\\$5\"}\nline two",
+			expectedOK: true,
+		},
+		{
+			name:       "unquoted delimiter applies backslash escapes",
+			command:    "curl https://example.com --data-binary @- <<EOF\n{\"price\": \"\\$5\", \"path\": \"a\\\\b\", \"keep\": \"\\n\"}\nEOF",
+			expected:   "{\"price\": \"$5\", \"path\": \"a\\b\", \"keep\": \"\\n\"}",
+			expectedOK: true,
+		},
+		{
+			name:       "double-quoted delimiter",
+			command:    "curl https://example.com --data-binary @- <<\"END\"\n\\$x\nEND",
+			expected:   "\\$x",
+			expectedOK: true,
+		},
+		{
+			name:       "data strips newlines",
+			command:    "curl https://example.com -d @- <<'EOF'\na=1&\nb=2\nEOF",
+			expected:   "a=1&b=2",
+			expectedOK: true,
+		},
+		{
+			name:       "dash strips leading tabs",
+			command:    "curl https://example.com --data-binary @- <<-EOF\n\t\tindented\n\tEOF\n",
+			expected:   "indented",
+			expectedOK: true,
+		},
+		{
+			name:       "crlf line endings",
+			command:    "curl https://example.com --json @- <<'EOF'\r\n{}\r\nEOF\r\n",
+			expected:   "{}",
+			expectedOK: true,
+		},
+		{
+			name:       "empty body",
+			command:    "curl https://example.com --data-binary @- <<'EOF'\nEOF",
+			expected:   "",
+			expectedOK: true,
+		},
+		{name: "no heredoc", command: "curl https://example.com --data-raw 'a<<b'", expectedOK: false},
+		{name: "heredoc without @-", command: "cat <<'EOF'\nx\nEOF", expectedOK: false},
+		{name: "data-raw sends @- literally", command: "curl https://example.com --data-raw @- <<'EOF'\nx\nEOF", expectedOK: false},
+		{name: "here-string", command: "curl https://example.com --data-binary @- <<< 'x'", expectedOK: false},
+		{name: "unterminated", command: "curl https://example.com --data-binary @- <<'EOF'\nx\n", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := findHeredocBody(tt.command)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("findHeredocBody(%q) error = %v; expectError %v", tt.command, err, tt.expectError)
+			}
+			if ok != tt.expectedOK {
+				t.Fatalf("findHeredocBody(%q) ok = %v; want %v", tt.command, ok, tt.expectedOK)
+			}
+			if ok && (got.Value != tt.expected || got.ANSIC) {
+				t.Errorf("findHeredocBody(%q) = (%q, ANSIC=%v); want %q", tt.command, got.Value, got.ANSIC, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunHeredoc tests that Run decodes a body read from a here-document.
+func TestRunHeredoc(t *testing.T) {
+	command := "#!/bin/sh\ncurl -s https://example.com/api \\\n  -H 'Content-Type: application/json' \\\n  --data-binary @- <<'EOF'\n{\"user\": \"ada\", \"roles\": [\"admin\"]}\nEOF\necho done\n"
+	got, err := Run(command, Options{Canonical: true})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if expected := `{"roles":["admin"],"user":"ada"}`; string(got) != expected {
+		t.Errorf("Run() = %s; want %s", got, expected)
+	}
+}
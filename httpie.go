@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// httpieValueOptions are the HTTPie options that take a value, so that the
+// value is not mistaken for a request item.
+var httpieValueOptions = map[string]bool{
+	"--auth": true, "-a": true, "--auth-type": true, "-A": true, "--session": true,
+	"--session-read-only": true, "--output": true, "-o": true, "--timeout": true,
+	"--proxy": true, "--cert": true, "--cert-key": true, "--verify": true,
+	"--print": true, "-p": true, "--pretty": true, "--style": true, "-s": true,
+	"--raw": true, "--boundary": true, "--max-redirects": true,
+}
+
+// httpieMethodPattern matches an explicit HTTPie method argument such as POST.
+var httpieMethodPattern = regexp.MustCompile(`^[A-Z]+$`)
+
+// httpieItemSeparators are HTTPie's request item separators. When several
+// occur in an item the earliest one wins, and at the same position the
+// longest, so "a:=1" is raw JSON rather than a header.
+var httpieItemSeparators = []string{":=@", "=@", ":=", "==", "=", "@", ":", ";"}
+
+// parseHTTPie parses an HTTPie command (http [METHOD] URL [ITEM...]) into a
+// Request. Items are mapped the way HTTPie sends them:
+//
+//	Header:value   a request header ("Header;" sends it empty)
+//	name==value    a query string parameter
+//	field=value    a JSON string field (a form field with --form)
+//	field:=json    a raw JSON field
+//
+// Data fields become a JSON object body with Content-Type application/json
+// unless --form is given, and --raw sets the body directly. The method
+// defaults to POST when there is a body and GET otherwise. A URL without a
+// scheme gets http:// (https:// when the command is https), and ":3000/x" is
+// shorthand for localhost. File items (@ and =@) are not supported.
+func parseHTTPie(cmd string) (*Request, error) {
+	tokens, err := tokenizeCurl(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("parseHTTPie: %w", err)
+	}
+	scheme := "http://"
+	if len(tokens) > 0 && (tokens[0].Value == "http" || tokens[0].Value == "https") {
+		if tokens[0].Value == "https" {
+			scheme = "https://"
+		}
+		tokens = tokens[1:]
+	}
+
+	var args []string
+	var raw *string
+	form := false
+	for i := 0; i < len(tokens); i++ {
+		value, err := httpieTokenValue(tokens[i])
+		if err != nil {
+			return nil, err
+		}
+		name, inline, hasInline := strings.Cut(value, "=")
+		switch {
+		case !strings.HasPrefix(value, "-") || value == "-":
+			args = append(args, value)
+		case name == "--form" || name == "-f":
+			form = true
+		case name == "--json" || name == "-j":
+			form = false
+		case httpieValueOptions[name]:
+			if !hasInline {
+				if i+1 == len(tokens) {
+					return nil, fmt.Errorf("parseHTTPie: option %s is missing its value", name)
+				}
+				i++
+				if inline, err = httpieTokenValue(tokens[i]); err != nil {
+					return nil, err
+				}
+			}
+			if name == "--raw" {
+				raw = &inline
+			}
+		}
+	}
+
+	r := &Request{}
+	if len(args) > 1 && httpieMethodPattern.MatchString(args[0]) {
+		r.Method, args = args[0], args[1:]
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("parseHTTPie: no URL found in command")
+	}
+	r.URL = httpieURL(args[0], scheme)
+
+	var query []string
+	var fields []httpieField
+	for _, item := range args[1:] {
+		key, sep, value := splitHTTPieItem(item)
+		switch sep {
+		case ":":
+			r.Headers = append(r.Headers, Header{Name: key, Value: strings.TrimSpace(value)})
+		case ";":
+			r.Headers = append(r.Headers, Header{Name: key})
+		case "==":
+			query = append(query, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		case "=":
+			fields = append(fields, httpieField{Name: key, Value: value})
+		case ":=":
+			if form {
+				return nil, fmt.Errorf("parseHTTPie: raw JSON field %q cannot be sent with --form", item)
+			}
+			if !json.Valid([]byte(value)) {
+				return nil, fmt.Errorf("parseHTTPie: raw JSON field %q is not valid JSON", item)
+			}
+			fields = append(fields, httpieField{Name: key, Value: value, Raw: true})
+		case "":
+			return nil, fmt.Errorf("parseHTTPie: %q is not a request item", item)
+		default:
+			return nil, fmt.Errorf("parseHTTPie: file item %q is not supported", item)
+		}
+	}
+	if len(query) > 0 {
+		if strings.Contains(r.URL, "?") {
+			r.URL += "&" + strings.Join(query, "&")
+		} else {
+			r.URL += "?" + strings.Join(query, "&")
+		}
+	}
+
+	switch {
+	case raw != nil:
+		r.Body = []byte(*raw)
+	case len(fields) > 0 && form:
+		r.Body = httpieFormBody(fields)
+		r.Headers = withDefaultHeader(r.Headers, "Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	case len(fields) > 0:
+		r.Body = httpieJSONBody(fields)
+		r.Headers = withDefaultHeader(r.Headers, "Content-Type", "application/json")
+		r.Headers = withDefaultHeader(r.Headers, "Accept", "application/json, */*;q=0.5")
+	}
+	if r.Method == "" {
+		r.Method = "GET"
+		if r.Body != nil {
+			r.Method = "POST"
+		}
+	}
+	return r, nil
+}
+
+// httpieField is a data item of an HTTPie command. Raw fields (:=) hold JSON.
+type httpieField struct {
+	Name  string
+	Value string
+	Raw   bool
+}
+
+// httpieTokenValue returns the text of a shell word, decoding $'...' escapes.
+func httpieTokenValue(t Token) (string, error) {
+	if !t.ANSIC {
+		return t.Value, nil
+	}
+	decoded, err := decodeRawDataWith(t.Value, Options{})
+	if err != nil {
+		return "", &DecodeError{Err: err}
+	}
+	return string(decoded), nil
+}
+
+// httpieURL completes an HTTPie URL argument with scheme when it has none and
+// expands the ":port/path" localhost shorthand.
+func httpieURL(arg, scheme string) string {
+	if strings.HasPrefix(arg, ":") {
+		arg = "localhost" + arg
+	}
+	if strings.Contains(arg, "://") {
+		return arg
+	}
+	return scheme + arg
+}
+
+// splitHTTPieItem splits a request item at its separator (see
+// httpieItemSeparators). sep is "" when the item has none.
+func splitHTTPieItem(item string) (key, sep, value string) {
+	best := -1
+	for _, s := range httpieItemSeparators {
+		if i := strings.Index(item, s); i > 0 && (best < 0 || i < best || i == best && len(s) > len(sep)) {
+			best, sep = i, s
+		}
+	}
+	if best < 0 {
+		return item, "", ""
+	}
+	return item[:best], sep, item[best+len(sep):]
+}
+
+// httpieJSONBody encodes fields as a JSON object, keeping their order.
+func httpieJSONBody(fields []httpieField) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(f.Name)
+		buf.Write(name)
+		buf.WriteByte(':')
+		if f.Raw {
+			json.Compact(&buf, []byte(f.Value))
+		} else {
+			value, _ := json.Marshal(f.Value)
+			buf.Write(value)
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+// httpieFormBody encodes fields as application/x-www-form-urlencoded,
+// keeping their order.
+func httpieFormBody(fields []httpieField) []byte {
+	pairs := make([]string, len(fields))
+	for i, f := range fields {
+		pairs[i] = url.QueryEscape(f.Name) + "=" + url.QueryEscape(f.Value)
+	}
+	return []byte(strings.Join(pairs, "&"))
+}
+
+// withDefaultHeader appends the header name: value unless headers already
+// has one with that name.
+func withDefaultHeader(headers Headers, name, value string) Headers {
+	if headers.Get(name) != "" {
+		return headers
+	}
+	return append(headers, Header{Name: name, Value: value})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseHTTPie tests the parseHTTPie function.
+func TestParseHTTPie(t *testing.T) {
+	jsonHeaders := Headers{{"Content-Type", "application/json"}, {"Accept", "application/json, */*;q=0.5"}}
+	tests := []struct {
+		name        string
+		command     string
+		expected    *Request
+		expectError bool
+	}{
+		{
+			name:     "GET with header and query",
+			command:  "http example.com/api X-Trace:abc q==go page==2",
+			expected: &Request{Method: "GET", URL: "http://example.com/api?q=go&page=2", Headers: Headers{{"X-Trace", "abc"}}},
+		},
+		{
+			name:    "JSON string and raw fields imply POST",
+			command: `http example.com name=John age:=29 'tags:=["a", "b"]' married:=false`,
+			expected: &Request{Method: "POST", URL: "http://example.com", Headers: jsonHeaders,
+				Body: []byte(`{"name":"John","age":29,"tags":["a","b"],"married":false}`)},
+		},
+		{
+			name:    "explicit method and https program",
+			command: "https PUT api.example.com/x Authorization:'Bearer t' a=b",
+			expected: &Request{Method: "PUT", URL: "https://api.example.com/x",
+				Headers: append(Headers{{"Authorization", "Bearer t"}}, jsonHeaders...), Body: []byte(`{"a":"b"}`)},
+		},
+		{
+			name:    "form fields",
+			command: "http --form POST :3000/login user=me 'pass=a b&c'",
+			expected: &Request{Method: "POST", URL: "http://localhost:3000/login",
+				Headers: Headers{{"Content-Type", "application/x-www-form-urlencoded; charset=utf-8"}}, Body: []byte("user=me&pass=a+b%26c")},
+		},
+		{
+			name:     "raw body and an explicit Content-Type",
+			command:  "http -v --raw 'hello' example.com Content-Type:text/plain",
+			expected: &Request{Method: "POST", URL: "http://example.com", Headers: Headers{{"Content-Type", "text/plain"}}, Body: []byte("hello")},
+		},
+		{
+			name:    "separator precedence",
+			command: "http example.com 'url=http://a.test' 'X-Eq:a=b' Empty;",
+			expected: &Request{Method: "POST", URL: "http://example.com",
+				Headers: append(Headers{{"X-Eq", "a=b"}, {"Empty", ""}}, jsonHeaders...), Body: []byte(`{"url":"http://a.test"}`)},
+		},
+		{
+			name:     "ANSI-C quoted value",
+			command:  "http example.com $'msg=a\\nb'",
+			expected: &Request{Method: "POST", URL: "http://example.com", Headers: jsonHeaders, Body: []byte(`{"msg":"a\nb"}`)},
+		},
+		{name: "no URL", command: "http --form", expectError: true},
+		{name: "invalid raw JSON", command: "http example.com a:={", expectError: true},
+		{name: "file item", command: "http example.com file@/tmp/x", expectError: true},
+		{name: "not an item", command: "http example.com oops", expectError: true},
+		{name: "option missing its value", command: "http example.com --auth", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHTTPie(tt.command)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("parseHTTPie() expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseHTTPie() returned an unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseHTTPie() = %+v; want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunHTTPie tests that Run decodes the body of an HTTPie command.
+func TestRunHTTPie(t *testing.T) {
+	got, err := Run("http POST example.com/events id:=7 name=a", Options{InputFormat: inputFormatHTTPie})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if expected := "{\n  \"id\": 7,\n  \"name\": \"a\"\n}"; string(got) != expected {
+		t.Errorf("Run() = %q; want %q", got, expected)
+	}
+}
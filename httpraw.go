@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// Input formats accepted by -input-format.
+const (
+	inputFormatCurl     = "curl"     // A cURL command (the default).
+	inputFormatHTTPRaw  = "httpraw"  // A raw HTTP/1.x request as captured by a proxy.
+	inputFormatJSONList = "jsonlist" // A JSON array of cURL command strings.
+	inputFormatHTTPie   = "httpie"   // An HTTPie command (http POST example.com a=b).
+	inputFormatB64Cmd   = "b64cmd"   // A base64-encoded cURL command, as in share links.
+)
+
+// parseHTTPRaw parses a raw HTTP/1.x request ("POST /x HTTP/1.1\r\nHost: ...")
+// into a Request. The body is read according to Content-Length or chunked
+// Transfer-Encoding, so it is already de-chunked; bytes after it are ignored
+// with a warning. Bare LF line endings are accepted as well as CRLF.
+//
+// Headers keep the order they had in the request. Transfer-Encoding is
+// dropped since the body no longer is chunked. An origin-form target
+// ("/x") is turned into an absolute URL using the Host header, with https
+// unless Host names a port other than 443.
+//
+// A raw response ("HTTP/1.1 200 OK\r\n...") is read with
+// parseHTTPRawResponse instead.
+func parseHTTPRaw(raw string) (*Request, error) {
+	raw = strings.TrimLeft(raw, " \t\r\n")
+	if strings.HasPrefix(raw, "HTTP/") {
+		return parseHTTPRawResponse(raw)
+	}
+	br := bufio.NewReader(strings.NewReader(raw))
+	req, err := http.ReadRequest(br)
+	if err != nil {
+		return nil, fmt.Errorf("parseHTTPRaw: %w", err)
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("parseHTTPRaw: body is shorter than its Content-Length or chunk sizes: %w", err)
+		}
+		return nil, fmt.Errorf("parseHTTPRaw: reading body: %w", err)
+	}
+	if rest, _ := io.ReadAll(br); len(strings.TrimSpace(string(rest))) > 0 {
+		logger.Warn(fmt.Sprintf("Ignored %d byte(s) after the end of the raw request body.", len(rest)), field("ignored", len(rest)))
+	}
+
+	headers, err := rawHeaderLines(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parseHTTPRaw: %w", err)
+	}
+
+	r := &Request{Method: req.Method, URL: req.URL.String(), Headers: headers}
+	if !req.URL.IsAbs() {
+		if req.Host == "" {
+			return nil, fmt.Errorf("parseHTTPRaw: request target %q is not absolute and there is no Host header", req.RequestURI)
+		}
+		scheme := "https"
+		if _, port, ok := strings.Cut(req.Host, ":"); ok && port != "443" {
+			scheme = "http"
+		}
+		r.URL = scheme + "://" + req.Host + req.RequestURI
+	}
+	if len(body) > 0 || req.ContentLength == 0 && req.Header.Get("Content-Length") != "" {
+		r.Body = body
+	}
+	return r, nil
+}
+
+// parseHTTPRawResponse parses a raw HTTP/1.x response into a Request with
+// its headers and body, and no method or URL, so that a captured response
+// body can be decoded like a request body and its Set-Cookie headers reused.
+func parseHTTPRawResponse(raw string) (*Request, error) {
+	br := bufio.NewReader(strings.NewReader(raw))
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parseHTTPRaw: %w", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parseHTTPRaw: reading response body: %w", err)
+	}
+	headers, err := rawHeaderLines(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parseHTTPRaw: %w", err)
+	}
+	r := &Request{Headers: headers}
+	if len(body) > 0 {
+		r.Body = body
+	}
+	return r, nil
+}
+
+// rawHeaderLines returns the header fields of a raw request in their original
+// order, unfolding obsolete continuation lines. Transfer-Encoding is left out.
+func rawHeaderLines(raw string) (Headers, error) {
+	tp := textproto.NewReader(bufio.NewReader(strings.NewReader(raw)))
+	if _, err := tp.ReadLine(); err != nil { // Request line.
+		return nil, err
+	}
+	var headers Headers
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "" {
+			break
+		}
+		if headers, err = headers.appendLine(line); err != nil {
+			return nil, err
+		}
+	}
+	kept := headers[:0]
+	for _, h := range headers {
+		if !strings.EqualFold(h.Name, "Transfer-Encoding") {
+			kept = append(kept, h)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// TestParseHTTPRaw tests the parseHTTPRaw function.
+func TestParseHTTPRaw(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		expected    *Request
+		expectError bool
+	}{
+		{
+			name: "content-length body as captured by a proxy",
+			raw: "POST /api/v1/events?x=1 HTTP/1.1\r\n" +
+				"Host: example.com\r\n" +
+				"User-Agent: Mozilla/5.0\r\n" +
+				"Content-Type: application/json\r\n" +
+				"Accept: */*\r\n" +
+				"Accept: text/plain\r\n" +
+				"Content-Length: 7\r\n" +
+				"\r\n" +
+				`{"a":1}`,
+			expected: &Request{
+				Method: "POST",
+				URL:    "https://example.com/api/v1/events?x=1",
+				Headers: Headers{
+					{Name: "Host", Value: "example.com"},
+					{Name: "User-Agent", Value: "Mozilla/5.0"},
+					{Name: "Content-Type", Value: "application/json"},
+					{Name: "Accept", Value: "*/*"},
+					{Name: "Accept", Value: "text/plain"},
+					{Name: "Content-Length", Value: "7"},
+				},
+				Body: []byte(`{"a":1}`),
+			},
+		},
+		{
+			name: "chunked body is de-chunked",
+			raw: "PUT /upload HTTP/1.1\r\nHost: localhost:8080\r\nTransfer-Encoding: chunked\r\n\r\n" +
+				"4\r\nWiki\r\n5\r\npedia\r\n0\r\n\r\n",
+			expected: &Request{
+				Method:  "PUT",
+				URL:     "http://localhost:8080/upload",
+				Headers: Headers{{Name: "Host", Value: "localhost:8080"}},
+				Body:    []byte("Wikipedia"),
+			},
+		},
+		{
+			name: "bare LF line endings and absolute-form target",
+			raw:  "\nPOST http://proxy.test/x HTTP/1.1\nHost: proxy.test\nContent-Length: 3\n\nabc\n",
+			expected: &Request{
+				Method:  "POST",
+				URL:     "http://proxy.test/x",
+				Headers: Headers{{Name: "Host", Value: "proxy.test"}, {Name: "Content-Length", Value: "3"}},
+				Body:    []byte("abc"),
+			},
+		},
+		{
+			name: "folded content type",
+			raw:  "POST /x HTTP/1.1\r\nHost: example.com\r\nContent-Type: text/plain;\r\n charset=utf-8\r\nContent-Length: 1\r\n\r\na",
+			expected: &Request{
+				Method: "POST",
+				URL:    "https://example.com/x",
+				Headers: Headers{
+					{Name: "Host", Value: "example.com"},
+					{Name: "Content-Type", Value: "text/plain; charset=utf-8"},
+					{Name: "Content-Length", Value: "1"},
+				},
+				Body: []byte("a"),
+			},
+		},
+		{
+			name: "no body",
+			raw:  "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n",
+			expected: &Request{
+				Method:  "GET",
+				URL:     "https://example.com/",
+				Headers: Headers{{Name: "Host", Value: "example.com"}},
+			},
+		},
+		{
+			name: "raw response",
+			raw:  "HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nSet-Cookie: a=1\r\nContent-Length: 2\r\n\r\nok",
+			expected: &Request{
+				Headers: Headers{{Name: "Content-Type", Value: "text/plain"}, {Name: "Set-Cookie", Value: "a=1"}, {Name: "Content-Length", Value: "2"}},
+				Body:    []byte("ok"),
+			},
+		},
+		{name: "body shorter than content-length", raw: "POST / HTTP/1.1\r\nHost: a\r\nContent-Length: 10\r\n\r\nabc", expectError: true},
+		{name: "malformed chunk", raw: "POST / HTTP/1.1\r\nHost: a\r\nTransfer-Encoding: chunked\r\n\r\nzz\r\nabc\r\n", expectError: true},
+		{name: "no host", raw: "POST /x HTTP/1.0\r\nContent-Length: 1\r\n\r\na", expectError: true},
+		{name: "curl command", raw: "curl 'https://example.com' --data-raw 'a'", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHTTPRaw(tt.raw)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("parseHTTPRaw() expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseHTTPRaw() returned an unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseHTTPRaw() = %+v; want %+v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunHTTPRaw tests that Run decodes and decompresses the body of a raw HTTP request.
+func TestRunHTTPRaw(t *testing.T) {
+	gz := string(gzipBytes(t, `{"event":"view","items":[1,2]}`))
+	raw := "POST /collect HTTP/1.1\r\n" +
+		"Host: analytics.example.com\r\n" +
+		"Content-Type: application/json\r\n" +
+		"Content-Encoding: gzip\r\n" +
+		"Transfer-Encoding: chunked\r\n" +
+		"\r\n" +
+		"a\r\n" + gz[:10] + "\r\n" +
+		fmt.Sprintf("%x\r\n", len(gz)-10) + gz[10:] + "\r\n" +
+		"0\r\n\r\n"
+
+	got, err := Run(raw, Options{InputFormat: inputFormatHTTPRaw})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if expected := "{\n  \"event\": \"view\",\n  \"items\": [\n    1,\n    2\n  ]\n}"; string(got) != expected {
+		t.Errorf("Run() = %q; want %q", got, expected)
+	}
+
+	_, err = Run("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n", Options{InputFormat: inputFormatHTTPRaw})
+	var extractErr *ExtractError
+	if !errors.As(err, &extractErr) {
+		t.Errorf("Run() on a request without a body error = %v; want an ExtractError", err)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+)
+
+// readCommandFile returns the contents of the input file as a string. With
+// useMmap the file is memory-mapped and the string aliases the mapping instead
+// of holding a copy, so huge captures are not duplicated in memory; release
+// must be called once the command (and anything sliced from it) is no longer
+// used. When mapping fails the file is read with os.ReadFile instead.
+func readCommandFile(name string, useMmap bool) (command string, release func(), err error) {
+	if useMmap {
+		data, unmap, err := mmapFile(name)
+		if err == nil {
+			release = func() {
+				if err := unmap(); err != nil {
+					logger.Warn(fmt.Sprintf("failed to unmap input file %s: %v", name, err), field("file", name), field("error", err))
+				}
+			}
+			if len(data) == 0 {
+				return "", release, nil
+			}
+			return unsafe.String(&data[0], len(data)), release, nil
+		}
+		logger.Warn(fmt.Sprintf("memory-mapping %s failed, falling back to reading it: %v", name, err), field("file", name), field("error", err))
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(data), func() {}, nil
+}
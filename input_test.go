@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestReadCommandFile tests the readCommandFile function with and without memory-mapping.
+func TestReadCommandFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		useMmap bool
+	}{
+		{"read", "curl 'url' --data-raw $'abc'", false},
+		{"mmap", "curl 'url' --data-raw $'abc'", true},
+		{"mmap empty file", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write fixture: %v", err)
+			}
+			got, release, err := readCommandFile(path, tt.useMmap)
+			if err != nil {
+				t.Fatalf("readCommandFile(%q, %v) returned an unexpected error: %v", path, tt.useMmap, err)
+			}
+			defer release()
+			if got != tt.content {
+				t.Errorf("readCommandFile(%q, %v) = %q; want %q", path, tt.useMmap, got, tt.content)
+			}
+			if tt.content != "" {
+				if _, err := Run(got, Options{}); err != nil {
+					t.Errorf("Run() over the input returned an unexpected error: %v", err)
+				}
+			}
+		})
+	}
+
+	if _, _, err := readCommandFile(filepath.Join(dir, "missing.txt"), true); err == nil {
+		t.Errorf("readCommandFile() for a missing file should have returned an error, but got nil")
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/url"
+	"unicode/utf8"
+)
+
+// insomniaExport is the subset of an Insomnia v4 export (Application >
+// Preferences > Data > Import Data) needed to import one request.
+type insomniaExport struct {
+	Type         string             `json:"_type"`
+	ExportFormat int                `json:"__export_format"`
+	ExportSource string             `json:"__export_source"`
+	Resources    []insomniaResource `json:"resources"`
+}
+
+// insomniaResource is a workspace or request of an Insomnia export. The
+// workspace leaves the request-only fields empty.
+type insomniaResource struct {
+	ID       string           `json:"_id"`
+	Type     string           `json:"_type"`
+	ParentID *string          `json:"parentId"`
+	Name     string           `json:"name"`
+	Method   string           `json:"method,omitempty"`
+	URL      string           `json:"url,omitempty"`
+	Body     *insomniaBody    `json:"body,omitempty"`
+	Headers  []insomniaHeader `json:"headers,omitempty"`
+}
+
+// insomniaBody is the body of an Insomnia request: its MIME type and text.
+type insomniaBody struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// insomniaHeader is one header of an Insomnia request.
+type insomniaHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Resource IDs of the emitted workspace and request.
+const (
+	insomniaWorkspaceID = "wrk_curldataextractor"
+	insomniaRequestID   = "req_curldataextractor"
+)
+
+// emitInsomnia renders r as an Insomnia v4 export holding a workspace with
+// the request. The body keeps the MIME type of the Content-Type header, or
+// curl's default application/x-www-form-urlencoded without one. Insomnia
+// stores bodies as text, so a body that is not valid UTF-8, such as a gzip
+// payload, cannot be exported.
+func emitInsomnia(r *Request) ([]byte, error) {
+	workspaceID := insomniaWorkspaceID
+	request := insomniaResource{
+		ID:       insomniaRequestID,
+		Type:     "request",
+		ParentID: &workspaceID,
+		Name:     r.Method + " " + r.URL,
+		Method:   r.Method,
+		URL:      r.URL,
+		Headers:  []insomniaHeader{},
+	}
+	if u, err := url.Parse(r.URL); err == nil && u.Path != "" {
+		request.Name = r.Method + " " + u.Path
+	}
+	for _, h := range r.Headers {
+		request.Headers = append(request.Headers, insomniaHeader{Name: h.Name, Value: h.Value})
+	}
+	if r.Body != nil {
+		if !utf8.Valid(r.Body) {
+			return nil, errors.New("emitInsomnia: the body is not text (is it compressed?); Insomnia bodies must be UTF-8")
+		}
+		mimeType := "application/x-www-form-urlencoded"
+		if ct := r.Headers.Get("Content-Type"); ct != "" {
+			if mt, _, err := mime.ParseMediaType(ct); err == nil {
+				mimeType = mt
+			}
+		}
+		request.Body = &insomniaBody{MimeType: mimeType, Text: string(r.Body)}
+	}
+	export := insomniaExport{
+		Type:         "export",
+		ExportFormat: 4,
+		ExportSource: "cURLDataExtractor",
+		Resources: []insomniaResource{
+			{ID: workspaceID, Type: "workspace", Name: "Imported curl command"},
+			request,
+		},
+	}
+	out, err := json.MarshalIndent(export, "", defaultIndent)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Report describes a decoded request for -inspect.
+type Report struct {
+	Method          string `json:"method"`
+	URL             string `json:"url"`
+	CanonicalURL    string `json:"canonical_url"`
+	Origin          string `json:"origin"`
+	Referer         string `json:"referer"`
+	ContentType     string `json:"content_type"`
+	ContentEncoding string `json:"content_encoding"`
+	Compression     string `json:"compression"`
+	RawSize         int    `json:"raw_size"`
+	BodySize        int    `json:"body_size"`
+	IsJSON          bool   `json:"is_json"`
+	SHA256          string `json:"sha256"`
+}
+
+// buildReport collects the Report of a decoded body and the request it was
+// sent with, whose Origin and Referer tell where a browser capture came
+// from. CanonicalURL is canonicalizeURL's, so captures of the same request
+// whose query parameters are ordered differently report the same one. The
+// content type is bodyMediaType's, so it is sniffed when the request does
+// not declare one, and SHA256 is the digest of the body.
+func buildReport(res *DecodeResult, r *Request) (Report, error) {
+	digest, err := digestOf("sha256", res.Decompressed)
+	if err != nil {
+		return Report{}, err
+	}
+	canonicalURL, _ := canonicalizeURL(r.URL) // "", shown as missing, when the URL does not parse.
+	compression := res.Algorithm
+	if compression == algoNone {
+		compression = "none"
+	}
+	return Report{
+		Method:          r.Method,
+		URL:             r.URL,
+		CanonicalURL:    canonicalURL,
+		Origin:          r.Origin(),
+		Referer:         r.Referer(),
+		ContentType:     bodyMediaType(res),
+		ContentEncoding: r.Headers.Get("Content-Encoding"),
+		Compression:     compression,
+		RawSize:         len(res.Raw),
+		BodySize:        len(res.Decompressed),
+		IsJSON:          res.IsJSON,
+		SHA256:          digest,
+	}, nil
+}
+
+// Table renders the report as an aligned two-column table, one property per
+// row, with the SHA-256 shortened to summaryDigestLength digits.
+func (r Report) Table() string {
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	for _, row := range [][2]string{
+		{"method", r.Method},
+		{"url", r.URL},
+		{"canonical url", r.CanonicalURL},
+		{"origin", r.Origin},
+		{"referer", r.Referer},
+		{"content-type", r.ContentType},
+		{"content-encoding", r.ContentEncoding},
+		{"compression", r.Compression},
+		{"raw size", strconv.Itoa(r.RawSize) + " bytes"},
+		{"body size", strconv.Itoa(r.BodySize) + " bytes"},
+		{"is json", strconv.FormatBool(r.IsJSON)},
+		{"sha256", r.SHA256[:min(summaryDigestLength, len(r.SHA256))]},
+	} {
+		value := row[1]
+		if value == "" {
+			value = "-"
+		}
+		tw.Write([]byte(row[0] + "\t" + value + "\n"))
+	}
+	tw.Flush()
+	return sb.String()
+}
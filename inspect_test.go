@@ -0,0 +1,46 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+// TestRunInspect tests that the -inspect table of Run lists the request's and
+// body's properties, aligned in two columns.
+func TestRunInspect(t *testing.T) {
+	command := "curl 'https://Example.com/api?b=2&a=1' -H 'Origin: https://app.example.com' -e 'https://app.example.com/page?id=1' -H 'Content-Encoding: gzip' --data-raw $'" + hexEscape(gzipBytes(t, `{"a":1}`)) + "'"
+	got, err := Run(command, Options{Inspect: true})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	for _, row := range []string{
+		`method +POST`,
+		`url +https://Example\.com/api\?b=2&a=1`,
+		`canonical url +https://example\.com/api\?a=1&b=2`,
+		`origin +https://app\.example\.com`,
+		`referer +https://app\.example\.com/page\?id=1`,
+		`content-type +application/json`,
+		`content-encoding +gzip`,
+		`compression +gzip`,
+		`body size +7 bytes`,
+		`is json +true`,
+		`sha256 +015abd7f5cc5`,
+	} {
+		if !regexp.MustCompile(`(?m)^` + row + `$`).Match(got) {
+			t.Errorf("Run() = %q; want a row matching %q", got, row)
+		}
+	}
+	if !regexp.MustCompile(`(?m)\A(?:[a-z0-9 -]{16}  \S.*\n){12}\z`).Match(got) {
+		t.Errorf("Run() = %q; want twelve rows with the values aligned", got)
+	}
+}
+
+// TestReportTable tests that Report.Table shows missing values as a dash.
+func TestReportTable(t *testing.T) {
+	got := Report{Compression: "none", SHA256: "ab"}.Table()
+	for _, row := range []string{`method +-`, `canonical url +-`, `origin +-`, `referer +-`, `content-encoding +-`, `raw size +0 bytes`, `sha256 +ab`} {
+		if !regexp.MustCompile(`(?m)^` + row + `$`).MatchString(got) {
+			t.Errorf("Report.Table() = %q; want a row matching %q", got, row)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultIndent is the indentation of pretty-printed JSON unless -indent
+// gives another one.
+const defaultIndent = "  "
+
+// maxIndentWidth is the largest number of spaces -indent accepts.
+const maxIndentWidth = 16
+
+// parseIndent parses an -indent value: a number of spaces from 1 to
+// maxIndentWidth, or "tab" for a tab character.
+func parseIndent(value string) (string, error) {
+	if value == "tab" {
+		return "\t", nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > maxIndentWidth {
+		return "", fmt.Errorf("indent %q must be a number of spaces from 1 to %d or tab", value, maxIndentWidth)
+	}
+	return strings.Repeat(" ", n), nil
+}
+
+// jsonIndent returns the indentation for pretty-printed JSON: opts.Indent, or
+// defaultIndent when it is unset.
+func jsonIndent(opts Options) string {
+	if opts.Indent == "" {
+		return defaultIndent
+	}
+	return opts.Indent
+}
+
+// interpretBody formats the processed body according to its Content-Type,
+// with the handler registered in contentHandlers for it: JSON is
+// pretty-printed, form-urlencoded and multipart bodies are parsed into a
+// pretty-printed JSON view, XML and HTML are re-indented, and any other
+// declared type without a handler (see RegisterContentHandler) is kept raw.
+// When no Content-Type is known, the body is sniffed for JSON, then XML.
+func interpretBody(contentType string, data []byte, opts Options) ([]byte, error) {
+	if contentType == "" {
+		if !opts.RequireJSON && !json.Valid(data) && looksLikeXML(data) {
+			return formatXML(data, opts)
+		}
+		return formatJSON(data, opts)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Could not parse Content-Type %q, sniffing the body instead: %v", contentType, err), field("content_type", contentType), field("error", err))
+		return formatJSON(data, opts)
+	}
+	if opts.RequireJSON && !isJSONMediaType(mediaType) {
+		return nil, &NotJSONError{Err: fmt.Errorf("Content-Type is %s", mediaType)}
+	}
+	if handler, ok := lookupContentHandler(mediaType); ok {
+		return handler(data, params, opts)
+	}
+	fmt.Fprintf(previews, "Content-Type is %s, saving raw processed data to output file.\n", mediaType)
+	return data, nil
+}
+
+// unwrapJSONString replaces a JSON string whose contents are themselves valid
+// JSON, as in "{\"a\":1}", by the value it encodes, repeatedly up to
+// maxDepth times. It returns the innermost value and how many levels were
+// removed. Strings that do not hold JSON are returned as they are.
+func unwrapJSONString(v interface{}, maxDepth int) (interface{}, int) {
+	depth := 0
+	for ; depth < maxDepth; depth++ {
+		s, ok := v.(string)
+		if !ok {
+			break
+		}
+		var inner interface{}
+		if err := json.Unmarshal([]byte(s), &inner); err != nil {
+			break
+		}
+		v = inner
+	}
+	return v, depth
+}
+
+// formatJSON pretty-prints data when it is valid JSON and returns it unchanged
+// otherwise (or fails with a NotJSONError when opts.RequireJSON is set).
+func formatJSON(data []byte, opts Options) ([]byte, error) {
+	// Try to parse as JSON. If it fails, treat it as plain text.
+	var jsonData interface{} // To accept any valid JSON structure
+	err := json.Unmarshal(data, &jsonData)
+	if err != nil && opts.JSONRepair {
+		if repaired, repairErr := repairJSON(data); repairErr == nil {
+			logger.Warn("Data is not valid JSON; repaired it (trailing commas, unquoted keys, single quotes or comments) with -json-repair.")
+			fmt.Fprintln(previews, "Note: the JSON below was repaired (-json-repair); it differs from the captured body.")
+			err = json.Unmarshal(repaired, &jsonData)
+		} else {
+			logger.Info(fmt.Sprintf("Could not repair the JSON (-json-repair): %v", repairErr), field("error", repairErr))
+		}
+	}
+	if err != nil {
+		if opts.RequireJSON {
+			return nil, &NotJSONError{Err: err}
+		}
+		logger.Warn(fmt.Sprintf("Data is not valid JSON, treating as plain text: %v", err), field("error", err))
+		// If it's not JSON, the raw processed bytes are written to the output file.
+		fmt.Fprintln(previews, "Saving raw processed string to output file.")
+		return data, nil
+	}
+
+	if opts.UnwrapJSONString {
+		var depth int
+		limit := maxDepth(opts)
+		if jsonData, depth = unwrapJSONString(jsonData, limit); depth > 0 {
+			logger.Info(fmt.Sprintf("Unwrapped %d level(s) of JSON encoded as a JSON string.", depth), field("depth", depth))
+		}
+		if _, more := unwrapJSONString(jsonData, 1); more > 0 {
+			return nil, &DepthError{Feature: "-unwrap-json-string", Limit: limit}
+		}
+	}
+	if len(opts.Fields) > 0 {
+		jsonData = projectFields(jsonData, opts.Fields)
+	}
+
+	if opts.Canonical {
+		canonical, err := canonicalJSON(jsonData)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(previews, "Canonical JSON data:")
+		fmt.Fprintln(previews, previewJSON(canonical, opts))
+		return canonical, nil
+	}
+
+	// Pretty-print the JSON data (like indent=2 in Python, unless -indent says otherwise)
+	prettyJSON, err := json.MarshalIndent(jsonData, "", jsonIndent(opts))
+	if err != nil {
+		return nil, fmt.Errorf("marshalling JSON to pretty format: %w", err)
+	}
+	fmt.Fprintln(previews, "Parsed JSON data:")
+	fmt.Fprintln(previews, previewJSON(prettyJSON, opts))
+	return prettyJSON, nil
+}
+
+// formatForm parses an application/x-www-form-urlencoded body and renders it
+// as a pretty-printed JSON object mapping each key to its list of values.
+func formatForm(data []byte, opts Options) ([]byte, error) {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("formatForm: failed to parse form data: %w", err)
+	}
+	prettyJSON, err := json.MarshalIndent(values, "", jsonIndent(opts))
+	if err != nil {
+		return nil, fmt.Errorf("formatForm: marshalling form data: %w", err)
+	}
+	fmt.Fprintln(previews, "Parsed form data:")
+	fmt.Fprintln(previews, previewJSON(prettyJSON, opts))
+	return prettyJSON, nil
+}
+
+// multipartPart is the JSON view of one part of a multipart/form-data body.
+// Text values are stored in Value, binary ones base64-encoded in ValueBase64.
+type multipartPart struct {
+	Name        string `json:"name"`
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Value       string `json:"value,omitempty"`
+	ValueBase64 string `json:"value_base64,omitempty"`
+}
+
+// formatMultipart parses a multipart/form-data body with the given boundary
+// and renders its parts as a pretty-printed JSON array.
+func formatMultipart(data []byte, boundary string, opts Options) ([]byte, error) {
+	if boundary == "" {
+		return nil, fmt.Errorf("formatMultipart: Content-Type has no boundary parameter")
+	}
+	reader := multipart.NewReader(bytes.NewReader(data), boundary)
+	parts := []multipartPart{}
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("formatMultipart: failed to read part: %w", err)
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, fmt.Errorf("formatMultipart: failed to read part %q: %w", part.FormName(), err)
+		}
+		p := multipartPart{Name: part.FormName(), Filename: part.FileName(), ContentType: part.Header.Get("Content-Type")}
+		if isText(content) {
+			p.Value = string(content)
+		} else {
+			p.ValueBase64 = base64.StdEncoding.EncodeToString(content)
+		}
+		parts = append(parts, p)
+	}
+	prettyJSON, err := json.MarshalIndent(parts, "", jsonIndent(opts))
+	if err != nil {
+		return nil, fmt.Errorf("formatMultipart: marshalling parts: %w", err)
+	}
+	fmt.Fprintln(previews, "Parsed multipart data:")
+	fmt.Fprintln(previews, previewJSON(prettyJSON, opts))
+	return prettyJSON, nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestInterpretBody tests the interpretBody function for each supported Content-Type.
+func TestInterpretBody(t *testing.T) {
+	multipartBody := "--XyZ\r\n" +
+		"Content-Disposition: form-data; name=\"field\"\r\n\r\n" +
+		"value\r\n" +
+		"--XyZ\r\n" +
+		"Content-Disposition: form-data; name=\"file\"; filename=\"a.bin\"\r\n" +
+		"Content-Type: application/octet-stream\r\n\r\n" +
+		"\x00\x01\r\n" +
+		"--XyZ--\r\n"
+
+	tests := []struct {
+		name        string
+		contentType string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{"json", "application/json", `{"a":1}`, "{\n  \"a\": 1\n}", false},
+		{"json with charset", "application/json; charset=utf-8", `[1]`, "[\n  1\n]", false},
+		{"vendor json", "application/vnd.api+json", `{}`, "{}", false},
+		{"invalid json kept raw", "application/json", `{nope`, `{nope`, false},
+		{"form", "application/x-www-form-urlencoded", "a=1&b=x%20y&a=2", "{\n  \"a\": [\n    \"1\",\n    \"2\"\n  ],\n  \"b\": [\n    \"x y\"\n  ]\n}", false},
+		{"bad form", "application/x-www-form-urlencoded", "a=%zz", "", true},
+		{"multipart", "multipart/form-data; boundary=XyZ", multipartBody,
+			"[\n  {\n    \"name\": \"field\",\n    \"value\": \"value\"\n  },\n  {\n    \"name\": \"file\",\n    \"filename\": \"a.bin\",\n    \"content_type\": \"application/octet-stream\",\n    \"value_base64\": \"AAE=\"\n  }\n]", false},
+		{"multipart without boundary", "multipart/form-data", multipartBody, "", true},
+		{"text kept raw", "text/plain", `{"a":1}`, `{"a":1}`, false},
+		{"no content type sniffs json", "", `{"a":1}`, "{\n  \"a\": 1\n}", false},
+		{"no content type raw", "", "a=1", "a=1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := interpretBody(tt.contentType, []byte(tt.input), Options{})
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("interpretBody(%q, %q) should have returned an error, but got nil", tt.contentType, tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("interpretBody(%q, %q) returned an unexpected error: %v", tt.contentType, tt.input, err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("interpretBody(%q, %q) = %q; want %q", tt.contentType, tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunUsesContentType tests that Run picks the interpretation from the command's Content-Type header.
+func TestRunUsesContentType(t *testing.T) {
+	command := "curl 'url' -H 'content-type: application/x-www-form-urlencoded' --data-raw $'k=v'"
+	got, err := Run(command, Options{})
+	if err != nil {
+		t.Fatalf("Run(%q) returned an unexpected error: %v", command, err)
+	}
+	if expected := "{\n  \"k\": [\n    \"v\"\n  ]\n}"; string(got) != expected {
+		t.Errorf("Run(%q) = %q; want %q", command, got, expected)
+	}
+
+	command = "curl 'url' -H 'Content-Type: text/plain' --data-raw $'hi'"
+	if _, err := Run(command, Options{RequireJSON: true}); err == nil || !strings.Contains(err.Error(), "text/plain") {
+		t.Errorf("Run(%q) with RequireJSON error = %v; want a NotJSONError naming text/plain", command, err)
+	}
+}
+
+// TestUnwrapJSONString tests the unwrapJSONString function.
+func TestUnwrapJSONString(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		maxDepth      int
+		expected      string
+		expectedDepth int
+	}{
+		{"not a string", `{"a":1}`, 8, `{"a":1}`, 0},
+		{"plain string", `"hello"`, 8, `"hello"`, 0},
+		{"single wrapping", `"{\"a\":1}"`, 8, `{"a":1}`, 1},
+		{"double wrapping", `"\"{\\\"a\\\":[1,2]}\""`, 8, `{"a":[1,2]}`, 2},
+		{"depth limit", `"\"{\\\"a\\\":1}\""`, 1, `"{\"a\":1}"`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(tt.input), &v); err != nil {
+				t.Fatalf("Failed to parse fixture: %v", err)
+			}
+			got, depth := unwrapJSONString(v, tt.maxDepth)
+			encoded, _ := json.Marshal(got)
+			if string(encoded) != tt.expected || depth != tt.expectedDepth {
+				t.Errorf("unwrapJSONString(%s, %d) = (%s, %d); want (%s, %d)", tt.input, tt.maxDepth, encoded, depth, tt.expected, tt.expectedDepth)
+			}
+		})
+	}
+}
+
+// TestRunUnwrapJSONString tests that -unwrap-json-string pretty-prints the inner JSON of a wrapped body.
+func TestRunUnwrapJSONString(t *testing.T) {
+	command := `curl 'u' --data-raw $'"\\"{\\\\\\"a\\\\\\":1}\\""'`
+	got, err := Run(command, Options{UnwrapJSONString: true})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if expected := "{\n  \"a\": 1\n}"; string(got) != expected {
+		t.Errorf("Run() = %q; want %q", got, expected)
+	}
+
+	got, err = Run(command, Options{})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if expected := `"\"{\\\"a\\\":1}\""`; string(got) != expected {
+		t.Errorf("Run() without the option = %q; want the string kept as %q", got, expected)
+	}
+}
+
+// TestParseIndent tests the parseIndent function.
+func TestParseIndent(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expected    string
+		expectError bool
+	}{
+		{"default width", "2", "  ", false},
+		{"four spaces", "4", "    ", false},
+		{"no indent", "0", "", true},
+		{"tab", "tab", "\t", false},
+		{"negative", "-1", "", true},
+		{"too wide", "17", "", true},
+		{"word", "tabs", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIndent(tt.value)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parseIndent(%q) error = %v, expectError %v", tt.value, err, tt.expectError)
+			}
+			if got != tt.expected {
+				t.Errorf("parseIndent(%q) = %q; want %q", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunIndent tests that Run pretty-prints JSON with Options.Indent.
+func TestRunIndent(t *testing.T) {
+	command := `curl 'u' --data-raw $'{"a":{"b":[1]}}'`
+	tests := []struct {
+		name     string
+		indent   string
+		expected string
+	}{
+		{"default", "", "{\n  \"a\": {\n    \"b\": [\n      1\n    ]\n  }\n}"},
+		{"four spaces", "    ", "{\n    \"a\": {\n        \"b\": [\n            1\n        ]\n    }\n}"},
+		{"tab", "\t", "{\n\t\"a\": {\n\t\t\"b\": [\n\t\t\t1\n\t\t]\n\t}\n}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(command, Options{Indent: tt.indent})
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonListEntry is the result for one command of an -input-format jsonlist
+// array, of a command split at --next or of a -scan. Output holds the decoded body as JSON when it is valid JSON, and as
+// a JSON string otherwise (with invalid UTF-8 replaced); failing commands have
+// Error and ExitCode instead. Line is the line a scanned command starts on.
+type jsonListEntry struct {
+	Index    int             `json:"index"`
+	Line     int             `json:"line,omitempty"`
+	Output   json.RawMessage `json:"output,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	ExitCode int             `json:"exit_code,omitempty"`
+}
+
+// runJSONList decodes every command of a JSON array of cURL command strings,
+// as exported by some capture tools, with decodeCommandList.
+func runJSONList(input string, opts Options) ([]byte, error) {
+	var commands []string
+	if err := json.Unmarshal([]byte(input), &commands); err != nil {
+		return nil, &ExtractError{Err: fmt.Errorf("input is not a JSON array of command strings: %w", err)}
+	}
+	return decodeCommandList(commands, nil, "the JSON list", opts)
+}
+
+// decodeCommandList decodes every one of commands and combines the results
+// into one indented JSON array of jsonListEntry values in input order. A
+// command that fails is recorded in its entry and does not stop the others.
+// lines, when not nil, are the source lines of the commands. source describes
+// where the commands came from in log messages.
+func decodeCommandList(commands []string, lines []int, source string, opts Options) ([]byte, error) {
+	opts.InputFormat = inputFormatCurl
+	entries := make([]jsonListEntry, 0, len(commands))
+	for i, command := range commands {
+		logger.Info(fmt.Sprintf("Decoding command %d of %d from %s.", i+1, len(commands), source), field("index", i))
+		entry := jsonListEntry{Index: i}
+		if lines != nil {
+			entry.Line = lines[i]
+		}
+		// decode rather than Decode: -gzip-output applies to the combined
+		// output only, not to each entry.
+		res, err := decode(command, opts)
+		switch {
+		case err != nil:
+			entry.Error, entry.ExitCode = err.Error(), exitCodeFor(err)
+		case json.Valid(res.Output):
+			entry.Output = res.Output
+		default:
+			entry.Output, _ = json.Marshal(string(res.Output)) // Replaces invalid UTF-8 with U+FFFD.
+		}
+		entries = append(entries, entry)
+	}
+	return json.MarshalIndent(entries, "", jsonIndent(opts))
+}
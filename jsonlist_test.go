@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestRunJSONList tests that Run decodes every command of a JSON array.
+func TestRunJSONList(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "two commands with JSON-escaped quoting",
+			input: `["curl 'https://example.com/a' --data-raw $'{\"a\":\\x31}'",
+			        "curl 'https://example.com/b' -H 'Content-Type: text/plain' --data-raw $'line one\\nline \\'two\\''"]`,
+			expected: "[\n  {\n    \"index\": 0,\n    \"output\": {\n      \"a\": 1\n    }\n  },\n" +
+				"  {\n    \"index\": 1,\n    \"output\": \"line one\\nline 'two'\"\n  }\n]",
+		},
+		{
+			name:  "failing command is recorded",
+			input: `["curl 'u'", "curl 'u' --data-raw $'[1]'"]`,
+			expected: "[\n  {\n    \"index\": 0,\n    \"error\": \"extraction failed: failed to extract data-raw part\",\n    \"exit_code\": 2\n  },\n" +
+				"  {\n    \"index\": 1,\n    \"output\": [\n      1\n    ]\n  }\n]",
+		},
+		{name: "empty array", input: `[]`, expected: "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(tt.input, Options{InputFormat: inputFormatJSONList})
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %s; want %s", got, tt.expected)
+			}
+		})
+	}
+
+	for _, input := range []string{`{"cmd": "curl"}`, `[1, 2]`, `curl 'u'`} {
+		_, err := Run(input, Options{InputFormat: inputFormatJSONList})
+		var extractErr *ExtractError
+		if !errors.As(err, &extractErr) {
+			t.Errorf("Run(%q) error = %v; want an ExtractError", input, err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep is one selector of a compiled JSONPath expression.
+type jsonPathStep struct {
+	recursive bool   // Applied to every descendant (..) instead of the direct children.
+	wildcard  bool   // Selects all members or elements (* or [*]).
+	key       string // Member name, when isIndex and wildcard are false.
+	isIndex   bool
+	index     int // Array index; negative values count from the end.
+}
+
+// compileJSONPath parses the JSONPath subset used by -extract: the root $,
+// .name and ['name'] member access, [n] array indexes (negative from the end),
+// the * wildcard in both forms and .. recursive descent.
+func compileJSONPath(expr string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("JSONPath %q must start with $", expr)
+	}
+	var steps []jsonPathStep
+	rest := expr[1:]
+	for rest != "" {
+		var step jsonPathStep
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			step.recursive = true
+			rest = rest[2:]
+			if strings.HasPrefix(rest, "[") {
+				break
+			}
+			fallthrough
+		case strings.HasPrefix(rest, "."):
+			if !step.recursive {
+				rest = rest[1:]
+			}
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+			if name == "" {
+				return nil, fmt.Errorf("JSONPath %q has an empty member name", expr)
+			}
+			if name == "*" {
+				step.wildcard = true
+			} else {
+				step.key = name
+			}
+			steps = append(steps, step)
+			continue
+		}
+		if !strings.HasPrefix(rest, "[") {
+			return nil, fmt.Errorf("JSONPath %q: unexpected %q", expr, rest)
+		}
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("JSONPath %q has an unterminated [", expr)
+		}
+		selector := strings.TrimSpace(rest[1:end])
+		rest = rest[end+1:]
+		switch {
+		case selector == "*":
+			step.wildcard = true
+		case len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0]:
+			step.key = selector[1 : len(selector)-1]
+		default:
+			n, err := strconv.Atoi(selector)
+			if err != nil {
+				return nil, fmt.Errorf("JSONPath %q: invalid selector [%s]", expr, selector)
+			}
+			step.isIndex, step.index = true, n
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// evalJSONPath evaluates expr against a document decoded by encoding/json and
+// returns the matched values in document order (object members by sorted key).
+func evalJSONPath(expr string, doc interface{}) ([]interface{}, error) {
+	steps, err := compileJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []interface{}{doc}
+	for _, step := range steps {
+		if step.recursive {
+			var all []interface{}
+			for _, node := range nodes {
+				all = appendDescendants(all, node)
+			}
+			nodes = all
+		}
+		var next []interface{}
+		for _, node := range nodes {
+			next = step.apply(next, node)
+		}
+		nodes = next
+	}
+	return nodes, nil
+}
+
+// apply appends the children of node selected by the step to matches.
+func (s jsonPathStep) apply(matches []interface{}, node interface{}) []interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if s.wildcard {
+			for _, key := range sortedKeys(v) {
+				matches = append(matches, v[key])
+			}
+		} else if child, ok := v[s.key]; ok && !s.isIndex {
+			matches = append(matches, child)
+		}
+	case []interface{}:
+		if s.wildcard {
+			matches = append(matches, v...)
+		} else if s.isIndex {
+			i := s.index
+			if i < 0 {
+				i += len(v)
+			}
+			if i >= 0 && i < len(v) {
+				matches = append(matches, v[i])
+			}
+		}
+	}
+	return matches
+}
+
+// appendDescendants appends node and all values nested in it, depth first.
+func appendDescendants(all []interface{}, node interface{}) []interface{} {
+	all = append(all, node)
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			all = appendDescendants(all, v[key])
+		}
+	case []interface{}:
+		for _, child := range v {
+			all = appendDescendants(all, child)
+		}
+	}
+	return all
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
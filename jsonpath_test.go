@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestEvalJSONPath tests the evalJSONPath function.
+func TestEvalJSONPath(t *testing.T) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(`{
+		"data": {"token": "abc", "user": {"id": 7, "token": "nested"}},
+		"items": [{"id": 1}, {"id": 2}, {"id": 3}],
+		"odd key": true
+	}`), &doc); err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		expr        string
+		expected    []interface{}
+		expectError bool
+	}{
+		{name: "root", expr: "$", expected: []interface{}{doc}},
+		{name: "dot member", expr: "$.data.token", expected: []interface{}{"abc"}},
+		{name: "bracket member", expr: "$['odd key']", expected: []interface{}{true}},
+		{name: "index", expr: "$.items[1].id", expected: []interface{}{2.0}},
+		{name: "negative index", expr: "$.items[-1].id", expected: []interface{}{3.0}},
+		{name: "wildcard", expr: "$.items[*].id", expected: []interface{}{1.0, 2.0, 3.0}},
+		{name: "dot wildcard", expr: "$.data.user.*", expected: []interface{}{7.0, "nested"}},
+		{name: "recursive descent", expr: "$..token", expected: []interface{}{"abc", "nested"}},
+		{name: "recursive index", expr: "$..[0].id", expected: []interface{}{1.0}},
+		{name: "missing member", expr: "$.data.missing", expected: nil},
+		{name: "index out of range", expr: "$.items[5]", expected: nil},
+		{name: "no root", expr: "data.token", expectError: true},
+		{name: "unterminated bracket", expr: "$.items[0", expectError: true},
+		{name: "invalid selector", expr: "$.items[a]", expectError: true},
+		{name: "empty member", expr: "$.data.", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalJSONPath(tt.expr, doc)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("evalJSONPath(%q) error = %v; expectError %v", tt.expr, err, tt.expectError)
+			}
+			if !tt.expectError && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("evalJSONPath(%q) = %#v; want %#v", tt.expr, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// repairJSON turns "almost JSON", as copied from a JavaScript object literal,
+// into valid JSON: trailing commas before } and ] are dropped, unquoted
+// object keys and 'single-quoted' strings get double quotes, and // and /* */
+// comments are removed. It fails when the result is still not valid JSON.
+func repairJSON(data []byte) ([]byte, error) {
+	var out bytes.Buffer
+	out.Grow(len(data) + len(data)/8)
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '"' || c == '\'':
+			end, ok := jsonStringEnd(data, i)
+			if !ok {
+				return nil, errors.New("body has an unterminated string")
+			}
+			if c == '"' {
+				out.Write(data[i:end])
+			} else {
+				writeSingleQuoted(&out, data[i+1:end-1])
+			}
+			i = end
+		case c == '/' && i+1 < len(data) && (data[i+1] == '/' || data[i+1] == '*'):
+			i = jsonCommentEnd(data, i)
+		case c == ',':
+			next := skipJSONSpace(data, i+1)
+			if next < len(data) && (data[next] == '}' || data[next] == ']') {
+				i++ // A trailing comma.
+				continue
+			}
+			out.WriteByte(c)
+			i++
+		case isIdentStart(c):
+			end := i + 1
+			for end < len(data) && (isIdentStart(data[end]) || data[end] >= '0' && data[end] <= '9') {
+				end++
+			}
+			word := data[i:end]
+			next := skipJSONSpace(data, end)
+			if next < len(data) && data[next] == ':' {
+				out.WriteByte('"')
+				out.Write(word)
+				out.WriteByte('"')
+			} else {
+				out.Write(word) // true, false, null or something repair cannot fix.
+			}
+			i = end
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	if !json.Valid(out.Bytes()) {
+		return nil, errors.New("body is not valid JSON even after repair")
+	}
+	return out.Bytes(), nil
+}
+
+// jsonStringEnd returns the index just past the string that starts with the
+// quote at data[start], and whether the string is terminated.
+func jsonStringEnd(data []byte, start int) (int, bool) {
+	quote := data[start]
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1, true
+		}
+	}
+	return len(data), false
+}
+
+// writeSingleQuoted writes the contents of a 'single-quoted' string to out as
+// a double-quoted JSON string: \' becomes ' and " is escaped.
+func writeSingleQuoted(out *bytes.Buffer, content []byte) {
+	out.WriteByte('"')
+	for i := 0; i < len(content); i++ {
+		switch c := content[i]; {
+		case c == '\\' && i+1 < len(content) && content[i+1] == '\'':
+			out.WriteByte('\'')
+			i++
+		case c == '\\' && i+1 < len(content):
+			out.Write(content[i : i+2])
+			i++
+		case c == '"':
+			out.WriteString(`\"`)
+		default:
+			out.WriteByte(c)
+		}
+	}
+	out.WriteByte('"')
+}
+
+// jsonCommentEnd returns the index just past the // or /* */ comment that
+// starts at data[start].
+func jsonCommentEnd(data []byte, start int) int {
+	if data[start+1] == '/' {
+		if end := bytes.IndexByte(data[start:], '\n'); end >= 0 {
+			return start + end
+		}
+		return len(data)
+	}
+	if end := bytes.Index(data[start+2:], []byte("*/")); end >= 0 {
+		return start + 2 + end + 2
+	}
+	return len(data)
+}
+
+// skipJSONSpace returns the index of the first byte at or after i that is
+// neither JSON whitespace nor part of a comment.
+func skipJSONSpace(data []byte, i int) int {
+	for i < len(data) {
+		switch {
+		case data[i] == ' ' || data[i] == '\t' || data[i] == '\n' || data[i] == '\r':
+			i++
+		case data[i] == '/' && i+1 < len(data) && (data[i+1] == '/' || data[i+1] == '*'):
+			i = jsonCommentEnd(data, i)
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// isIdentStart reports whether c can start a JavaScript identifier used as
+// an unquoted object key (ASCII letters, _ and $).
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '$'
+}
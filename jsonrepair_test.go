@@ -0,0 +1,71 @@
+package main
+
+import "testing"
+
+// TestRepairJSON tests the repairJSON function.
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{"trailing comma in object", `{"a":1,}`, `{"a":1}`, false},
+		{"trailing comma in array", "[1, 2, \n]", "[1, 2 \n]", false},
+		{"nested trailing commas", `{"a":[1,],"b":{"c":2,},}`, `{"a":[1],"b":{"c":2}}`, false},
+		{"single-quoted strings", `{'a':'it\'s "x"'}`, `{"a":"it's \"x\""}`, false},
+		{"single-quoted string keeps escapes", `['a\nb']`, `["a\nb"]`, false},
+		{"unquoted keys", `{a: 1, $b_2 : true, c: null}`, `{"a": 1, "$b_2" : true, "c": null}`, false},
+		{"comments", "{\"a\":1, // note\n/* x */ \"b\":2}", "{\"a\":1, \n \"b\":2}", false},
+		{"comma before a comment and brace", "{\"a\":1, // last\n}", "{\"a\":1 \n}", false},
+		{"strings left alone", `{"a,}":"b: 'c'"}`, `{"a,}":"b: 'c'"}`, false},
+		{"valid json unchanged", `{"a":[1,2]}`, `{"a":[1,2]}`, false},
+		{"unterminated string", `{'a':1`, "", true},
+		{"not json at all", "hello world", "", true},
+		{"missing value", `{"a":}`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repairJSON([]byte(tt.input))
+			if (err != nil) != tt.expectError {
+				t.Fatalf("repairJSON(%q) error = %v, expectError %v", tt.input, err, tt.expectError)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("repairJSON(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunJSONRepair tests that -json-repair pretty-prints an almost-JSON body
+// and that strict parsing stays the default.
+func TestRunJSONRepair(t *testing.T) {
+	command := `curl u -H 'Content-Type: application/json' --data-raw $'{\'a\': [1, 2,], b: \'x\',}'`
+	tests := []struct {
+		name     string
+		opts     Options
+		expected string
+	}{
+		{"repaired", Options{JSONRepair: true}, "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": \"x\"\n}"},
+		{"strict by default", Options{}, `{'a': [1, 2,], b: 'x',}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(command, tt.opts)
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+	if _, err := Run(command, Options{RequireJSON: true}); err == nil {
+		t.Error("Run() with -require-json and without -json-repair should fail")
+	}
+	if _, err := Run(command, Options{RequireJSON: true, JSONRepair: true}); err != nil {
+		t.Errorf("Run() with -require-json and -json-repair returned an unexpected error: %v", err)
+	}
+}
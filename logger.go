@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+// Log formats accepted by -log-format.
+const (
+	logFormatText = "text" // Human-readable lines with a timestamp, as printed by the standard log package.
+	logFormatJSON = "json" // One {"level", "msg", "fields"} object per line for log pipelines.
+)
+
+// Log levels of the notices.
+const (
+	levelInfo  = "info"
+	levelWarn  = "warn"
+	levelError = "error"
+)
+
+// logField is a key/value pair attached to a notice. Fields are only written
+// in the JSON format; text lines carry the same information in the message.
+type logField struct {
+	Key   string
+	Value interface{}
+}
+
+// field returns a logField, storing errors as their message so they survive
+// JSON encoding.
+func field(key string, value interface{}) logField {
+	if err, ok := value.(error); ok && err != nil {
+		value = err.Error()
+	}
+	return logField{Key: key, Value: value}
+}
+
+// Logger writes the informational notices, warnings and errors of a run in
+// either the text or the JSON log format.
+type Logger struct {
+	mu     sync.Mutex
+	out    io.Writer
+	format string
+	text   *log.Logger
+}
+
+// logger is the Logger used throughout the program. The CLI replaces it
+// according to -log-format; it defaults to text on standard error.
+var logger = newLogger(os.Stderr, logFormatText)
+
+// newLogger returns a Logger writing to out in the given format.
+func newLogger(out io.Writer, format string) *Logger {
+	return &Logger{out: out, format: format, text: log.New(out, "", log.LstdFlags)}
+}
+
+// Info logs an informational notice.
+func (l *Logger) Info(msg string, fields ...logField) { l.log(levelInfo, msg, fields) }
+
+// Warn logs a recoverable problem.
+func (l *Logger) Warn(msg string, fields ...logField) { l.log(levelWarn, msg, fields) }
+
+// Error logs a failure.
+func (l *Logger) Error(msg string, fields ...logField) { l.log(levelError, msg, fields) }
+
+func (l *Logger) log(level, msg string, fields []logField) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.format != logFormatJSON {
+		switch level {
+		case levelWarn:
+			msg = "Warning: " + msg
+		case levelError:
+			msg = "Error: " + msg
+		}
+		l.text.Println(msg)
+		return
+	}
+
+	entry := struct {
+		Level  string                 `json:"level"`
+		Msg    string                 `json:"msg"`
+		Fields map[string]interface{} `json:"fields,omitempty"`
+	}{Level: level, Msg: msg}
+	if len(fields) > 0 {
+		entry.Fields = make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			entry.Fields[f.Key] = f.Value
+		}
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		line, _ = json.Marshal(struct {
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}{level, fmt.Sprintf("%s (fields not encodable: %v)", msg, err)})
+	}
+	l.out.Write(append(line, '\n'))
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestLoggerFormats tests the text and JSON output of the Logger.
+func TestLoggerFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		log      func(l *Logger)
+		expected string
+	}{
+		{"text info", logFormatText, func(l *Logger) { l.Info("hello", field("n", 1)) }, "hello\n"},
+		{"text warn", logFormatText, func(l *Logger) { l.Warn("careful") }, "Warning: careful\n"},
+		{"text error", logFormatText, func(l *Logger) { l.Error("broken") }, "Error: broken\n"},
+		{"json info", logFormatJSON, func(l *Logger) { l.Info("hello") }, `{"level":"info","msg":"hello"}` + "\n"},
+		{"json fields", logFormatJSON, func(l *Logger) { l.Warn("careful", field("n", 1), field("error", errors.New("boom"))) },
+			`{"level":"warn","msg":"careful","fields":{"error":"boom","n":1}}` + "\n"},
+	}
+
+	timestamp := regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} `)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(newLogger(&buf, tt.format))
+			got := buf.String()
+			if tt.format == logFormatText {
+				if !timestamp.MatchString(got) {
+					t.Fatalf("text log line %q has no timestamp", got)
+				}
+				got = timestamp.ReplaceAllString(got, "")
+			}
+			if got != tt.expected {
+				t.Errorf("log output = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunJSONLogging tests that Run's notices are emitted as JSON objects with the JSON log format.
+func TestRunJSONLogging(t *testing.T) {
+	var buf bytes.Buffer
+	saved := logger
+	logger = newLogger(&buf, logFormatJSON)
+	defer func() { logger = saved }()
+
+	command := "curl 'url' --data-raw $' " + hexEscape(gzipBytes(t, `{"a":1}`)) + "'"
+	if _, err := Run(command, Options{}); err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+
+	type entry struct {
+		Level  string                 `json:"level"`
+		Msg    string                 `json:"msg"`
+		Fields map[string]interface{} `json:"fields"`
+	}
+	var entries []entry
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("log line %q is not a JSON object: %v", line, err)
+		}
+		entries = append(entries, e)
+	}
+
+	expected := []entry{
+		{Level: levelInfo, Msg: "Trimmed whitespace from extracted data-raw content. Original length: 129, New length: 128 (leading: 1, trailing: 0)",
+			Fields: map[string]interface{}{"original_length": 129.0, "new_length": 128.0, "leading": 1.0, "trailing": 0.0}},
+		{Level: levelInfo, Msg: "Detected potential gzip header. Attempting decompression.",
+			Fields: map[string]interface{}{"algorithm": "gzip"}},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("JSON log entries = %+v; want %+v", entries, expected)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// lz4FrameMagic starts every LZ4 frame (0x184D2204, little endian).
+var lz4FrameMagic = []byte{0x04, 0x22, 0x4d, 0x18}
+
+// LZ4 frame descriptor flags (the FLG byte).
+const (
+	lz4FlagVersionMask     = 0xc0
+	lz4FlagVersion         = 0x40
+	lz4FlagBlockChecksum   = 0x10
+	lz4FlagContentSize     = 0x08
+	lz4FlagContentChecksum = 0x04
+	lz4FlagDictID          = 0x01
+)
+
+// lz4UncompressedBlock is set in a block size when the block is stored as is.
+const lz4UncompressedBlock = 1 << 31
+
+// decompressLZ4Data decompresses one or more concatenated LZ4 frames, as
+// written by the lz4 command-line tool. Skippable frames are skipped, and the
+// header and content checksums are verified when present. Frames that need an
+// external dictionary are rejected.
+//
+// This is a small implementation of the LZ4 frame format
+// (https://github.com/lz4/lz4/blob/dev/doc/lz4_Frame_format.md) so that the
+// tool keeps building without third-party modules.
+func decompressLZ4Data(data []byte) ([]byte, error) {
+	var out []byte
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("decompressLZ4Data: %d trailing byte(s) after the last frame", len(data))
+		}
+		magic := binary.LittleEndian.Uint32(data)
+		if magic&0xfffffff0 == 0x184d2a50 { // Skippable frame.
+			if len(data) < 8 {
+				return nil, errors.New("decompressLZ4Data: truncated skippable frame")
+			}
+			size := uint64(binary.LittleEndian.Uint32(data[4:]))
+			if uint64(len(data)-8) < size {
+				return nil, errors.New("decompressLZ4Data: truncated skippable frame")
+			}
+			data = data[8+size:]
+			continue
+		}
+		if magic != binary.LittleEndian.Uint32(lz4FrameMagic) {
+			return nil, fmt.Errorf("decompressLZ4Data: bad frame magic %#08x", magic)
+		}
+		var err error
+		if out, data, err = decodeLZ4Frame(out, data[4:]); err != nil {
+			return nil, fmt.Errorf("decompressLZ4Data: %w", err)
+		}
+	}
+	return out, nil
+}
+
+// decodeLZ4Frame decodes the frame following the magic number at the start of
+// data, appending its content to out, and returns the bytes after the frame.
+func decodeLZ4Frame(out, data []byte) ([]byte, []byte, error) {
+	if len(data) < 3 {
+		return nil, nil, errors.New("truncated frame descriptor")
+	}
+	flg := data[0]
+	if flg&lz4FlagVersionMask != lz4FlagVersion {
+		return nil, nil, fmt.Errorf("unsupported frame version %d", flg>>6)
+	}
+	descriptorLen := 2
+	if flg&lz4FlagContentSize != 0 {
+		descriptorLen += 8
+	}
+	if flg&lz4FlagDictID != 0 {
+		descriptorLen += 4
+	}
+	if len(data) < descriptorLen+1 {
+		return nil, nil, errors.New("truncated frame descriptor")
+	}
+	if want := byte(xxh32(data[:descriptorLen], 0) >> 8); data[descriptorLen] != want {
+		return nil, nil, fmt.Errorf("frame descriptor checksum %#02x, want %#02x", data[descriptorLen], want)
+	}
+	if flg&lz4FlagDictID != 0 {
+		return nil, nil, errors.New("frames compressed with a dictionary are not supported")
+	}
+	var contentSize uint64
+	if flg&lz4FlagContentSize != 0 {
+		contentSize = binary.LittleEndian.Uint64(data[2:])
+	}
+	data = data[descriptorLen+1:]
+
+	start := len(out)
+	for {
+		if len(data) < 4 {
+			return nil, nil, errors.New("truncated block header")
+		}
+		size := binary.LittleEndian.Uint32(data)
+		data = data[4:]
+		if size == 0 { // End mark.
+			break
+		}
+		stored := size&lz4UncompressedBlock != 0
+		size &^= lz4UncompressedBlock
+		if uint64(len(data)) < uint64(size) {
+			return nil, nil, errors.New("truncated block")
+		}
+		block := data[:size]
+		data = data[size:]
+		if flg&lz4FlagBlockChecksum != 0 {
+			if len(data) < 4 {
+				return nil, nil, errors.New("truncated block checksum")
+			}
+			if got, want := binary.LittleEndian.Uint32(data), xxh32(block, 0); got != want {
+				return nil, nil, fmt.Errorf("block checksum %#08x, want %#08x", got, want)
+			}
+			data = data[4:]
+		}
+		if stored {
+			out = append(out, block...)
+			continue
+		}
+		// Blocks may reference earlier blocks of the same frame, which
+		// decoding into one buffer supports directly.
+		var err error
+		if out, err = decodeLZ4Block(out, block, start); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	content := out[start:]
+	if flg&lz4FlagContentSize != 0 && uint64(len(content)) != contentSize {
+		return nil, nil, fmt.Errorf("frame declares %d content bytes but holds %d", contentSize, len(content))
+	}
+	if flg&lz4FlagContentChecksum != 0 {
+		if len(data) < 4 {
+			return nil, nil, errors.New("truncated content checksum")
+		}
+		if got, want := binary.LittleEndian.Uint32(data), xxh32(content, 0); got != want {
+			return nil, nil, fmt.Errorf("content checksum %#08x, want %#08x", got, want)
+		}
+		data = data[4:]
+	}
+	return out, data, nil
+}
+
+// decodeLZ4Block decodes one compressed LZ4 block, appending it to out.
+// Matches may not reach before out[frameStart].
+func decodeLZ4Block(out, block []byte, frameStart int) ([]byte, error) {
+	i := 0
+	for i < len(block) {
+		token := block[i]
+		i++
+
+		literals := int(token >> 4)
+		if literals == 15 {
+			n, next, err := lz4ExtendedLength(block, i)
+			if err != nil {
+				return nil, err
+			}
+			literals += n
+			i = next
+		}
+		if len(block)-i < literals {
+			return nil, errors.New("literals run past the end of the block")
+		}
+		out = append(out, block[i:i+literals]...)
+		i += literals
+		if i == len(block) { // The last sequence has no match.
+			return out, nil
+		}
+
+		if len(block)-i < 2 {
+			return nil, errors.New("truncated match offset")
+		}
+		offset := int(binary.LittleEndian.Uint16(block[i:]))
+		i += 2
+		if offset == 0 || offset > len(out)-frameStart {
+			return nil, fmt.Errorf("invalid match offset %d", offset)
+		}
+		length := int(token&0x0f) + 4
+		if token&0x0f == 15 {
+			n, next, err := lz4ExtendedLength(block, i)
+			if err != nil {
+				return nil, err
+			}
+			length += n
+			i = next
+		}
+		// Byte by byte, since the match may overlap the bytes it produces.
+		from := len(out) - offset
+		for k := 0; k < length; k++ {
+			out = append(out, out[from+k])
+		}
+	}
+	return nil, errors.New("block does not end with literals")
+}
+
+// lz4ExtendedLength reads the 255-continued length bytes starting at
+// block[i] and returns their sum and the index after them.
+func lz4ExtendedLength(block []byte, i int) (int, int, error) {
+	n := 0
+	for {
+		if i >= len(block) {
+			return 0, 0, errors.New("truncated length")
+		}
+		b := block[i]
+		i++
+		n += int(b)
+		if b != 255 {
+			return n, i, nil
+		}
+	}
+}
+
+// xxHash32 primes.
+const (
+	xxhPrime1 uint32 = 2654435761
+	xxhPrime2 uint32 = 2246822519
+	xxhPrime3 uint32 = 3266489917
+	xxhPrime4 uint32 = 668265263
+	xxhPrime5 uint32 = 374761393
+)
+
+// xxh32 computes the 32-bit xxHash of data, which LZ4 frames use for their
+// checksums.
+func xxh32(data []byte, seed uint32) uint32 {
+	n := len(data)
+	var h uint32
+	if n >= 16 {
+		v1 := seed + xxhPrime1 + xxhPrime2
+		v2 := seed + xxhPrime2
+		v3 := seed
+		v4 := seed - xxhPrime1
+		for len(data) >= 16 {
+			v1 = xxh32Round(v1, binary.LittleEndian.Uint32(data[0:]))
+			v2 = xxh32Round(v2, binary.LittleEndian.Uint32(data[4:]))
+			v3 = xxh32Round(v3, binary.LittleEndian.Uint32(data[8:]))
+			v4 = xxh32Round(v4, binary.LittleEndian.Uint32(data[12:]))
+			data = data[16:]
+		}
+		h = bits.RotateLeft32(v1, 1) + bits.RotateLeft32(v2, 7) + bits.RotateLeft32(v3, 12) + bits.RotateLeft32(v4, 18)
+	} else {
+		h = seed + xxhPrime5
+	}
+	h += uint32(n)
+	for len(data) >= 4 {
+		h += binary.LittleEndian.Uint32(data) * xxhPrime3
+		h = bits.RotateLeft32(h, 17) * xxhPrime4
+		data = data[4:]
+	}
+	for _, b := range data {
+		h += uint32(b) * xxhPrime5
+		h = bits.RotateLeft32(h, 11) * xxhPrime1
+	}
+	h ^= h >> 15
+	h *= xxhPrime2
+	h ^= h >> 13
+	h *= xxhPrime3
+	h ^= h >> 16
+	return h
+}
+
+// xxh32Round mixes one 4-byte lane into an xxHash32 accumulator.
+func xxh32Round(acc, lane uint32) uint32 {
+	acc += lane * xxhPrime2
+	return bits.RotateLeft32(acc, 13) * xxhPrime1
+}
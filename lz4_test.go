@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// lz4EventsFixture returns the content of the events*.jsonl.lz4 fixtures in
+// testdata/lz4, which were written by the lz4 1.9.4 command-line tool.
+func lz4EventsFixture() []byte {
+	var sb strings.Builder
+	for i := 0; i < 3000; i++ {
+		fmt.Fprintf(&sb, "{\"seq\":%d,\"event\":\"view\",\"path\":\"/items/%d\"}\n", i, i*7%1000)
+	}
+	return []byte(sb.String())
+}
+
+// lz4StoredFrame builds an LZ4 frame holding content in a single uncompressed block.
+func lz4StoredFrame(content []byte, flg byte) []byte {
+	descriptor := []byte{flg, 0x40}
+	frame := append(append([]byte{}, lz4FrameMagic...), descriptor...)
+	frame = append(frame, byte(xxh32(descriptor, 0)>>8))
+	frame = binary.LittleEndian.AppendUint32(frame, uint32(len(content))|lz4UncompressedBlock)
+	frame = append(frame, content...)
+	frame = binary.LittleEndian.AppendUint32(frame, 0)
+	if flg&lz4FlagContentChecksum != 0 {
+		frame = binary.LittleEndian.AppendUint32(frame, xxh32(content, 0))
+	}
+	return frame
+}
+
+// TestDecompressLZ4Data tests the decompressLZ4Data function.
+func TestDecompressLZ4Data(t *testing.T) {
+	allBytes := make([]byte, 256)
+	for i := range allBytes {
+		allBytes[i] = byte(i)
+	}
+	stored := lz4StoredFrame([]byte("hello"), lz4FlagVersion|lz4FlagContentChecksum)
+	badChecksum := append([]byte{}, stored...)
+	badChecksum[len(badChecksum)-1] ^= 0xff
+	skippable := append([]byte{0x5a, 0x2a, 0x4d, 0x18, 3, 0, 0, 0, 'x', 'y', 'z'}, stored...)
+
+	tests := []struct {
+		name        string
+		fixture     string
+		input       []byte
+		expected    []byte
+		expectError bool
+	}{
+		{name: "lz4 cli defaults", fixture: "events.jsonl.lz4", expected: lz4EventsFixture()},
+		{name: "64 KiB linked blocks with block checksums and content size", fixture: "events-linked-checksummed.jsonl.lz4", expected: lz4EventsFixture()},
+		{name: "incompressible data in a stored block", fixture: "bytes.bin.lz4", expected: allBytes},
+		{name: "stored block with content checksum", input: stored, expected: []byte("hello")},
+		{name: "concatenated frames", input: append(append([]byte{}, stored...), stored...), expected: []byte("hellohello")},
+		{name: "skippable frame", input: skippable, expected: []byte("hello")},
+		{name: "bad content checksum", input: badChecksum, expectError: true},
+		{name: "truncated", input: stored[:len(stored)-6], expectError: true},
+		{name: "not lz4", input: []byte("hello world"), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := tt.input
+			if tt.fixture != "" {
+				var err error
+				if input, err = os.ReadFile(filepath.Join("testdata", "lz4", tt.fixture)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := decompressLZ4Data(input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("decompressLZ4Data() expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decompressLZ4Data() returned an unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.expected) {
+				t.Errorf("decompressLZ4Data() = %d bytes; want %d bytes", len(got), len(tt.expected))
+			}
+		})
+	}
+}
+
+// TestXXH32 tests the xxh32 function against the reference implementation.
+func TestXXH32(t *testing.T) {
+	tests := []struct {
+		input    string
+		seed     uint32
+		expected uint32
+	}{
+		{"", 0, 0x02cc5d05},
+		{"a", 0, 0x550d7456},
+		{"abc", 0, 0x32d153ff},
+		{"Nobody inspects the spammish repetition", 0, 0xe2293b2f},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := xxh32([]byte(tt.input), tt.seed); got != tt.expected {
+				t.Errorf("xxh32(%q, %d) = %#08x; want %#08x", tt.input, tt.seed, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunDecompressesLZ4 tests that Run detects and decompresses an LZ4 frame.
+func TestRunDecompressesLZ4(t *testing.T) {
+	command := "curl 'url' --data-raw $'" + hexEscape(lz4StoredFrame([]byte(`{"a":1}`), lz4FlagVersion)) + "'"
+	got, err := Run(command, Options{Canonical: true})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if expected := `{"a":1}`; string(got) != expected {
+		t.Errorf("Run() = %q; want %q", got, expected)
+	}
+}
@@ -3,16 +3,17 @@ package main
 import (
 	"bytes"
 	"compress/gzip"
-	"encoding/hex"
 	"encoding/json"
-	"flag" // Added for command-line flag parsing
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"net/url"
 	"os"
-	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -46,35 +47,230 @@ func reprBytes(b []byte) string {
 	return sb.String()
 }
 
+// reprLines is reprBytes(b) split into b'...' literals of at most width
+// characters each, which Python concatenates back into one bytes value when
+// they are written on consecutive lines inside parentheses. Escape sequences
+// are never split, so a width too narrow for one still gets one per line. A
+// width of 0 or less keeps the single literal.
+func reprLines(b []byte, width int) []string {
+	repr := reprBytes(b)
+	if width <= 0 || len(repr) <= width {
+		return []string{repr}
+	}
+	content := repr[2 : len(repr)-1]
+	var lines []string
+	start := 0
+	for i := 0; i < len(content); {
+		n := 1
+		if content[i] == '\\' {
+			n = 2 // \n, \r, \t, \', \\
+			if content[i+1] == 'x' {
+				n = 4 // \xHH
+			}
+		}
+		if i > start && len("b''")+i+n-start > width {
+			lines = append(lines, "b'"+content[start:i]+"'")
+			start = i
+		}
+		i += n
+	}
+	return append(lines, "b'"+content[start:]+"'")
+}
+
+// DataMatch is the --data-raw payload found in a cURL command together with its
+// location: curlCommand[Start:End] == Value, excluding the surrounding $'...' quotes.
+type DataMatch struct {
+	Value string
+	Start int
+	End   int
+}
+
+// findDataRaw locates the $'...' argument of --data-raw in a cURL command.
+// The scan is quote-aware: a backslash escapes the following character, so an
+// escaped quote (\') does not terminate the payload and a quote belonging to a
+// later argument is never swallowed.
+func findDataRaw(curlCommand string) (DataMatch, error) {
+	const flagName = "--data-raw"
+	for searchFrom := 0; ; {
+		idx := strings.Index(curlCommand[searchFrom:], flagName)
+		if idx < 0 {
+			return DataMatch{}, fmt.Errorf("failed to extract data-raw part")
+		}
+		i := searchFrom + idx + len(flagName)
+		searchFrom = i
+
+		// The flag must be followed by whitespace and then the ANSI-C quote $'.
+		j := i
+		for j < len(curlCommand) && (curlCommand[j] == ' ' || curlCommand[j] == '\t') {
+			j++
+		}
+		if j == i || !strings.HasPrefix(curlCommand[j:], "$'") {
+			continue
+		}
+
+		start := j + 2
+		for k := start; k < len(curlCommand); k++ {
+			switch curlCommand[k] {
+			case '\\':
+				k++ // Skip the escaped character; decodeRawData interprets it later.
+			case '\'':
+				return DataMatch{Value: curlCommand[start:k], Start: start, End: k}, nil
+			}
+		}
+		return DataMatch{}, fmt.Errorf("failed to extract data-raw part: unterminated $' quote starting at byte %d", j)
+	}
+}
+
 // extractDataRaw extracts the --data-raw content from a cURL command.
 func extractDataRaw(curlCommand string) (string, error) {
-	// Python: re.search(r"--data-raw \$'(.*)'", curl_command, re.DOTALL)
-	// In Go, the (?s) flag is equivalent to re.DOTALL. \$ matches the literal $ character.
-	// The single quotes in \$' are literal characters.
-	re := regexp.MustCompile(`(?s)--data-raw \$'(.*)'`)
-	matches := re.FindStringSubmatch(curlCommand)
-	if len(matches) < 2 {
-		return "", fmt.Errorf("failed to extract data-raw part")
+	match, err := findDataRaw(curlCommand)
+	if err != nil {
+		return "", err
+	}
+	return match.Value, nil
+}
+
+// extractPayload returns the body argument of a cURL command. The $'...'
+// argument of --data-raw is located with findDataRaw, which avoids tokenizing
+// huge commands; otherwise the first data option (-d, --data, --data-raw,
+// --data-binary, ...) found by the tokenizer is used, whatever its quoting,
+// unless the body is read from a here-document with @-. A --data-raw
+// argument made of several adjacent quoted segments, such as $'a'$'b', is
+// left to the tokenizer, which joins them as the shell does. The custom option
+// names in dataFlags (see withDataFlags) count as data options too.
+// The returned Token's ANSIC field tells whether Value still holds escapes.
+func extractPayload(curlCommand string, dataFlags []string) (Token, error) {
+	match, err := findDataRaw(curlCommand)
+	if err == nil {
+		if wordEndsAt(curlCommand, match.End+1) {
+			return Token{Value: match.Value, ANSIC: true, Start: match.Start - 2, End: match.End + 1}, nil
+		}
+		// The argument goes on after the closing quote, as in $'a'$'b' or
+		// $'a''b', so the tokenizer has to join its segments.
+		err = errors.New("failed to extract data-raw part")
+	}
+	if body, ok, heredocErr := findHeredocBody(curlCommand); heredocErr != nil {
+		return Token{}, heredocErr
+	} else if ok {
+		return body, nil
+	}
+
+	tokens, tokErr := tokenizeCurl(curlCommand)
+	if tokErr != nil {
+		return Token{}, fmt.Errorf("%w (%v)", err, tokErr)
+	}
+	isData := withDataFlags(dataFlags)
+	flags, _ := scanFlagsWith(tokens, isData)
+	for _, f := range flags {
+		if isData[f.Name] && f.HasValue {
+			return f.Value, nil
+		}
 	}
-	return matches[1], nil
+	return Token{}, err
+}
+
+// wordEndsAt reports whether a shell word of command ends at byte i: at the
+// end of the command, at whitespace or at a backslash-newline continuation.
+func wordEndsAt(command string, i int) bool {
+	rest := command[i:]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\n' || rest[0] == '\r' ||
+		strings.HasPrefix(rest, "\\\n") || strings.HasPrefix(rest, "\\\r\n")
 }
 
 // decompressGzipData decompresses gzip-compressed byte data.
+// Readers are taken from gzipReaders and reset onto data. When only the
+// trailer's CRC-32 or length does not match, the decompressed data is returned
+// along with an error wrapping gzip.ErrChecksum.
 func decompressGzipData(data []byte) ([]byte, error) {
 	reader := bytes.NewReader(data)
-	gzReader, err := gzip.NewReader(reader)
+	gzReader, ok := gzipReaders.Get().(*gzip.Reader)
+	var err error
+	if ok {
+		err = gzReader.Reset(reader)
+	} else {
+		gzReader, err = gzip.NewReader(reader)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("decompressGzipData: failed to create gzip reader: %w", err)
 	}
-	defer gzReader.Close()
+	defer func() {
+		gzReader.Close()
+		gzipReaders.Put(gzReader)
+	}()
 
 	decompressedData, err := io.ReadAll(gzReader)
+	if errors.Is(err, gzip.ErrChecksum) {
+		return decompressedData, fmt.Errorf("decompressGzipData: %w", err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("decompressGzipData: failed to decompress data: %w", err)
 	}
 	return decompressedData, nil
 }
 
+// isHexDigit reports whether b is an ASCII hexadecimal digit.
+func isHexDigit(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F')
+}
+
+// allHexDigits reports whether every byte of b is an ASCII hexadecimal digit.
+func allHexDigits(b []byte) bool {
+	for _, c := range b {
+		if !isHexDigit(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// strictHexEscapeError describes why the input following a \x escape is not
+// the two hex digits the Python dialect requires, telling running out of input
+// apart from an invalid character and naming the offending character.
+func strictHexEscapeError(rest []byte) error {
+	const requirement = "\\x requires two hex digits"
+	char := func(b []byte) rune {
+		r, _ := utf8.DecodeRune(b)
+		return r
+	}
+	switch {
+	case len(rest) == 0:
+		return fmt.Errorf("decodeRawData: incomplete hex escape: %s; ran out of input after \\x", requirement)
+	case !isHexDigit(rest[0]):
+		return fmt.Errorf("decodeRawData: invalid hex escape: %s; got invalid %q", requirement, char(rest))
+	case len(rest) == 1:
+		return fmt.Errorf("decodeRawData: incomplete hex escape: %s; got %q then ran out of input", requirement, rest[0])
+	default:
+		return fmt.Errorf("decodeRawData: invalid hex escape: %s; got %q then invalid %q", requirement, rest[0], char(rest[1:]))
+	}
+}
+
+// escapeDisabled reports whether the escape introduced by code (the character
+// after the backslash) is turned off in opts. \X counts as a hex escape.
+func escapeDisabled(code byte, opts Options) bool {
+	switch {
+	case code == 'x' || code == 'X':
+		return opts.DisableHex
+	case code == 'u' || code == 'U':
+		return opts.DisableUnicode
+	case code >= '0' && code <= '7':
+		return opts.DisableOctal
+	default:
+		return false
+	}
+}
+
+// hexValue returns the value of the hexadecimal digit b, which must satisfy isHexDigit.
+func hexValue(b byte) byte {
+	switch {
+	case b >= 'a':
+		return b - 'a' + 10
+	case b >= 'A':
+		return b - 'A' + 10
+	default:
+		return b - '0'
+	}
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -82,15 +278,103 @@ func min(a, b int) int {
 	return b
 }
 
+// Escape dialects understood by decodeRawDataWith.
+const (
+	DialectPython   = "python"   // Python's unicode_escape codec; the strict default.
+	DialectBash     = "bash"     // Bash ANSI-C quoting as used by $'...' strings.
+	DialectTolerant = "tolerant" // Python's rules plus fixes for non-standard generators.
+)
+
+// Ways of handling invalid UTF-8 in the payload, selected by -on-invalid.
+const (
+	OnInvalidError   = "error"   // Fail with a DecodeError (the default).
+	OnInvalidReplace = "replace" // Write U+FFFD (EF BF BD) in place of the byte.
+	OnInvalidSkip    = "skip"    // Drop the byte.
+)
+
+// Line ending conversions of text output, selected by -newline.
+const (
+	NewlineKeep = "keep" // Keep the line endings as decoded (the default).
+	NewlineLF   = "lf"   // Turn CRLF line endings into LF.
+	NewlineCRLF = "crlf" // Turn LF line endings into CRLF.
+)
+
+// dialects describes the escape dialects accepted by -dialect, keyed by name.
+var dialects = map[string]string{
+	DialectPython:   "Python's unicode_escape codec; \\x takes exactly two hex digits (default)",
+	DialectBash:     "bash ANSI-C quoting; \\x takes one or two hex digits",
+	DialectTolerant: "python, but \\X is accepted as \\x, one space or tab may follow \\x, and \\/ and \\? stand for / and ?, as some non-standard exporters emit them",
+}
+
+// escapeSequence documents one escape sequence decodeRawDataWith understands.
+type escapeSequence struct {
+	Sequence    string
+	Description string
+}
+
+// escapeSequences lists the escape sequences decodeRawDataWith understands, in
+// the order its switch handles them.
+var escapeSequences = []escapeSequence{
+	{`\n`, "line feed (0x0a)"},
+	{`\r`, "carriage return (0x0d)"},
+	{`\t`, "tab (0x09)"},
+	{`\b`, "backspace (0x08)"},
+	{`\f`, "form feed (0x0c)"},
+	{`\v`, "vertical tab (0x0b)"},
+	{`\a`, "bell (0x07)"},
+	{`\\`, "backslash"},
+	{`\'`, "single quote"},
+	{`\"`, "double quote"},
+	{`\xHH`, "byte with the given hex value"},
+	{`\XHH`, "same as \\xHH in the tolerant dialect; kept verbatim in the others"},
+	{`\/`, "slash, as in JSON strings, in the tolerant dialect; kept verbatim in the others"},
+	{`\?`, "question mark in the tolerant dialect; kept verbatim in the others"},
+	{`\uHHHH`, "Latin-1 code point U+0000-U+00FF"},
+	{`\UHHHHHHHH`, "Latin-1 code point U+0000-U+00FF"},
+	{`\OOO`, "byte with the given octal value (1-3 digits)"},
+}
+
+// dialectNames returns the names of the registered dialects in sorted order.
+func dialectNames() []string {
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // if they represent codepoints within that range.
 // decodeRawData converts an escaped string into a byte slice, mimicking Python's
 // `data.encode('latin1').decode('unicode_escape').encode('latin1')` behavior.
 func decodeRawData(s string) ([]byte, error) {
+	return decodeRawDataWith(s, Options{})
+}
+
+// decodeRawDataWith is decodeRawData with the escape dialect taken from opts.
+// The bash dialect differs from the Python one in that \x accepts one or two
+// hex digits, as bash does for $'\x4', and the tolerant dialect reads \X as \x
+// and skips one space or tab between \x and its two digits (\x 41). It also
+// reads the \/ of JSON strings and the \? some generators emit as / and ?.
+// Elsewhere \X, \/ and \?, like any unrecognized escape, are kept verbatim
+// with their backslash, as are the escape types turned off with
+// opts.DisableOctal, opts.DisableHex and opts.DisableUnicode. opts.OnInvalid
+// decides what happens to literal bytes that are not valid UTF-8.
+func decodeRawDataWith(s string, opts Options) ([]byte, error) {
+	return decodeRawDataLimit(s, opts, -1)
+}
+
+// decodeRawDataLimit is decodeRawDataWith that stops decoding once at least
+// limit bytes were produced, when limit is not negative. The rest of s is
+// then neither decoded nor validated.
+func decodeRawDataLimit(s string, opts Options, limit int) ([]byte, error) {
 	var result bytes.Buffer
-	inputBytes := []byte(s) // Work with the raw bytes of the input string
-	i := 0                  // Current index in inputBytes
+	inputBytes := []byte(s)      // Work with the raw bytes of the input string
+	i := 0                       // Current index in inputBytes
+	result.Grow(len(inputBytes)) // Decoding never produces more bytes than it consumes.
+	invalid := 0                 // Invalid UTF-8 bytes replaced or skipped per opts.OnInvalid.
 
-	for i < len(inputBytes) {
+	for i < len(inputBytes) && (limit < 0 || result.Len() < limit) {
 		if inputBytes[i] == '\\' {
 			// This is the start of an escape sequence
 			i++ // Move past '\'
@@ -99,6 +383,15 @@ func decodeRawData(s string) ([]byte, error) {
 			}
 
 			escapeCode := inputBytes[i] // The character determining the escape type
+			if escapeDisabled(escapeCode, opts) {
+				result.WriteByte('\\') // Kept verbatim; the digits that follow are copied as literals.
+				result.WriteByte(escapeCode)
+				i++
+				continue
+			}
+			if escapeCode == 'X' && opts.Dialect == DialectTolerant {
+				escapeCode = 'x' // Some exporters write \X41; elsewhere \X is unrecognized and kept.
+			}
 
 			switch escapeCode {
 			case 'n':
@@ -131,16 +424,39 @@ func decodeRawData(s string) ([]byte, error) {
 			case '"':
 				result.WriteByte('"')
 				i++
+			case '/', '?':
+				if opts.Dialect != DialectTolerant {
+					result.WriteByte('\\') // Unrecognized outside the tolerant dialect; kept verbatim.
+				}
+				result.WriteByte(escapeCode)
+				i++
 			case 'x':
-				i++                         // Move past 'x'
-				if i+1 >= len(inputBytes) { // Need two hex digits (inputBytes[i] and inputBytes[i+1])
-					return nil, fmt.Errorf("decodeRawData: incomplete hex escape \\x (need 2 digits, got: %q)", string(inputBytes[i:]))
+				i++ // Move past 'x'
+				if opts.Dialect == DialectBash {
+					// Bash greedily consumes one or two hex digits.
+					n := 0
+					for n < 2 && i+n < len(inputBytes) && isHexDigit(inputBytes[i+n]) {
+						n++
+					}
+					if n == 0 {
+						if i >= len(inputBytes) {
+							return nil, fmt.Errorf("decodeRawData: incomplete hex escape \\x (need 1 or 2 digits)")
+						}
+						return nil, fmt.Errorf("decodeRawData: invalid hex escape \\x%s", string(inputBytes[i:min(i+2, len(inputBytes))]))
+					}
+					val, _ := strconv.ParseUint(string(inputBytes[i:i+n]), 16, 8)
+					result.WriteByte(byte(val))
+					i += n
+					break
 				}
-				val, err := hex.DecodeString(string(inputBytes[i : i+2]))
-				if err != nil {
-					return nil, fmt.Errorf("decodeRawData: invalid hex escape \\x%s: %w", string(inputBytes[i:i+2]), err)
+				if opts.Dialect == DialectTolerant && i+2 < len(inputBytes) && (inputBytes[i] == ' ' || inputBytes[i] == '\t') &&
+					isHexDigit(inputBytes[i+1]) && isHexDigit(inputBytes[i+2]) {
+					i++ // Some generators write \x 41; skip one space or tab before the digits.
+				}
+				if i+1 >= len(inputBytes) || !isHexDigit(inputBytes[i]) || !isHexDigit(inputBytes[i+1]) {
+					return nil, strictHexEscapeError(inputBytes[i:])
 				}
-				result.WriteByte(val[0])
+				result.WriteByte(hexValue(inputBytes[i])<<4 | hexValue(inputBytes[i+1]))
 				i += 2 // Consumed two hex digits
 			case 'u':
 				i++                         // Move past 'u'
@@ -148,6 +464,9 @@ func decodeRawData(s string) ([]byte, error) {
 					return nil, fmt.Errorf("decodeRawData: incomplete unicode escape \\u (need 4 digits, got: %q)", string(inputBytes[i:]))
 				}
 				code, err := strconv.ParseInt(string(inputBytes[i:i+4]), 16, 32)
+				if err == nil && !allHexDigits(inputBytes[i:i+4]) {
+					err = strconv.ErrSyntax // ParseInt accepts a sign, Python does not.
+				}
 				if err != nil {
 					return nil, fmt.Errorf("decodeRawData: invalid unicode escape \\u%s: %w", string(inputBytes[i:i+4]), err)
 				}
@@ -162,6 +481,9 @@ func decodeRawData(s string) ([]byte, error) {
 					return nil, fmt.Errorf("decodeRawData: incomplete unicode escape \\U (need 8 digits, got: %q)", string(inputBytes[i:]))
 				}
 				code, err := strconv.ParseInt(string(inputBytes[i:i+8]), 16, 32)
+				if err == nil && !allHexDigits(inputBytes[i:i+8]) {
+					err = strconv.ErrSyntax
+				}
 				if err != nil {
 					return nil, fmt.Errorf("decodeRawData: invalid unicode escape \\U%s: %w", string(inputBytes[i:i+8]), err)
 				}
@@ -218,13 +540,30 @@ func decodeRawData(s string) ([]byte, error) {
 				result.WriteByte(escapeCode) // Write the character that followed the backslash
 				i++                          // Consumed the escapeCode character
 			}
+		} else if inputBytes[i] < utf8.RuneSelf {
+			// A run of literal ASCII characters is copied as is, in one go.
+			j := i + 1
+			for j < len(inputBytes) && inputBytes[j] != '\\' && inputBytes[j] < utf8.RuneSelf && (limit < 0 || result.Len()+j-i < limit) {
+				j++
+			}
+			result.Write(inputBytes[i:j])
+			i = j
 		} else {
 			// Not a backslash. This is a literal character (or start of one).
 			// Decode the rune and its size from inputBytes starting at current 'i'.
 			r, size := utf8.DecodeRune(inputBytes[i:])
 
 			if r == utf8.RuneError && size == 1 {
-				return nil, fmt.Errorf("decodeRawData: invalid UTF-8 sequence for a literal character at byte index %d", i)
+				switch opts.OnInvalid {
+				case OnInvalidReplace:
+					result.WriteString(string(utf8.RuneError))
+				case OnInvalidSkip:
+				default:
+					return nil, fmt.Errorf("decodeRawData: invalid UTF-8 sequence for a literal character at byte index %d", i)
+				}
+				invalid++
+				i++
+				continue
 			}
 
 			if r <= 0xFF { // Mimic Python's char.encode('latin1') behavior for the rune
@@ -235,86 +574,437 @@ func decodeRawData(s string) ([]byte, error) {
 			i += size // Advance by the number of bytes in the decoded rune
 		}
 	}
+	if invalid > 0 {
+		logger.Warn(fmt.Sprintf("Handled %d invalid UTF-8 byte(s) in the payload with -on-invalid %s.", invalid, opts.OnInvalid), field("invalid", invalid), field("on_invalid", opts.OnInvalid))
+	}
 	return result.Bytes(), nil
 }
 
-func main() {
-	defaultInputFile := "curl_command.txt"
-	defaultOutputFile := "decoded_curl_command.txt" // As per your request for the output filename
-
-	// Define command-line flags
-	inputFile := flag.String("input", defaultInputFile, "Path to the input cURL command file.")
-	outputFile := flag.String("output", defaultOutputFile, "Path to the output file for the decoded data.")
-	flag.Parse() // Parse the command-line flags
-
-	// Log input file usage
-	log.Printf("Using input file: %s", *inputFile)
-	if *inputFile == defaultInputFile {
-		isInputSetByUser := false
-		flag.Visit(func(f *flag.Flag) {
-			if f.Name == "input" {
-				isInputSetByUser = true
-			}
-		})
-		if !isInputSetByUser {
-			log.Println("(This is the default input path as no -input flag was provided)")
+// encodeRawData is the inverse of decodeRawData: it escapes data so that the
+// result, placed inside $'...', decodes back to the same bytes in both the
+// Python and bash dialects. Printable ASCII is kept as is, except for the
+// backslash and single quote; \n, \r and \t use their short escapes and every
+// other byte is written as a two-digit \xNN escape. Leading and trailing
+// spaces are escaped too, so Run's default trim does not strip them.
+func encodeRawData(data []byte) string {
+	var sb strings.Builder
+	sb.Grow(len(data))
+	for i, b := range data {
+		switch {
+		case b == ' ' && (i == 0 || i == len(data)-1):
+			sb.WriteString(`\x20`)
+		case b == '\\':
+			sb.WriteString(`\\`)
+		case b == '\'':
+			sb.WriteString(`\'`)
+		case b == '\n':
+			sb.WriteString(`\n`)
+		case b == '\r':
+			sb.WriteString(`\r`)
+		case b == '\t':
+			sb.WriteString(`\t`)
+		case b >= 32 && b < 127:
+			sb.WriteByte(b)
+		default:
+			fmt.Fprintf(&sb, "\\x%02x", b)
 		}
 	}
+	return sb.String()
+}
 
-	// Log output file usage
-	log.Printf("Using output file: %s", *outputFile)
-	if *outputFile == defaultOutputFile {
-		isOutputSetByUser := false
-		flag.Visit(func(f *flag.Flag) {
-			if f.Name == "output" {
-				isOutputSetByUser = true
-			}
-		})
-		if !isOutputSetByUser {
-			log.Println("(This is the default output path as no -output flag was provided)")
-		}
-	}
+// Options controls how Run processes a cURL command.
+type Options struct {
+	// RequireJSON makes Run fail with a NotJSONError when the processed data is not valid JSON.
+	RequireJSON bool
+	// Dialect selects the escape dialect used to decode the payload (DialectPython when empty).
+	Dialect string
+	// Emit, when set, renders the parsed request as a snippet for the named
+	// emitter (see emitters) instead of decoding the body.
+	Emit string
+	// Canonical writes JSON bodies as RFC 8785 canonical JSON instead of
+	// pretty-printing them, so identical payloads hash identically.
+	Canonical bool
+	// Color colorizes the JSON and reprBytes previews printed to stdout. The
+	// returned output is never colorized.
+	Color bool
+	// Format, when set, renders the final body bytes with the named output
+	// format (see outputFormats) instead of interpreting them by Content-Type.
+	Format string
+	// CArrayWidth is the number of bytes per line for the carray format.
+	CArrayWidth int
+	// JSONRepair repairs an almost-JSON body (trailing commas, unquoted keys,
+	// single-quoted strings, comments) with repairJSON instead of treating it
+	// as plain text.
+	JSONRepair bool
+	// UnwrapJSONString decodes a JSON body that is a string holding JSON,
+	// such as "{\"a\":1}", to the inner value (repeatedly, up to a limit).
+	UnwrapJSONString bool
+	// Fields reduces JSON bodies to these dotted paths (e.g. "id", "user.name")
+	// before they are written; missing paths are omitted.
+	Fields []string
+	// Recompress gzips the decoded body again before it is rendered, e.g. to
+	// write it back with -format escaped after editing it.
+	Recompress bool
+	// GzipLevel is the gzip level used by Recompress and GzipOutput, from 0
+	// (no compression) to 9 (best compression).
+	GzipLevel int
+	// KeepGzipHeader copies the FNAME, comment, time and OS fields of the
+	// original gzip stream into the recompressed one.
+	KeepGzipHeader bool
+	// GzipOutput gzips the final output, after rendering, for archiving it
+	// compressed; see gzipOutput.
+	GzipOutput bool
+	// Replay sends the reconstructed request and processes the response body
+	// instead of the captured request body.
+	Replay bool
+	// Retries is the number of times a replayed request is retried after a
+	// connection error or a 5xx response.
+	Retries int
+	// RetryDelay is the delay before the first retry; it doubles for each
+	// following one.
+	RetryDelay time.Duration
+	// URLDecodeInput percent-decodes the whole command (url.QueryUnescape)
+	// before parsing, for commands an intermediate tool URL-encoded. It is
+	// opt-in because it would corrupt literal % and + in ordinary commands.
+	URLDecodeInput bool
+	// DataFlags names extra options, such as a cURL wrapper's "--payload",
+	// whose value is the request body like --data-raw's. When set, the first
+	// word of the command is taken as the wrapper's name, whatever it is.
+	DataFlags []string
+	// FindCurl locates the first curl invocation in the input, such as a
+	// shell script around the command, and decodes only that command.
+	FindCurl bool
+	// Scan decodes every curl command sending data found anywhere in the
+	// input, such as a log file, into a JSON array; see runScan.
+	Scan bool
+	// Env substitutes $NAME and ${NAME} references outside '...' and $'...'
+	// quoting with the values of the current environment before parsing.
+	Env bool
+	// InputFormat selects how the input is parsed: inputFormatCurl (when
+	// empty) for a cURL command, inputFormatHTTPRaw for a raw HTTP/1.x
+	// request as captured by a proxy, inputFormatHTTPie for an HTTPie
+	// command, inputFormatJSONList for a JSON array of cURL commands,
+	// whose results are combined by runJSONList, or inputFormatB64Cmd for a
+	// base64-encoded cURL command.
+	InputFormat string
+	// SSE splits the body into Server-Sent Events and pretty-prints them as a
+	// JSON array, keeping the body raw when it is not an event stream.
+	SSE bool
+	// GRPCWeb de-frames a grpc-web body (binary or base64 text) and reports
+	// each message's length and bytes as a hex dump.
+	GRPCWeb bool
+	// GRPC de-frames a gRPC body, decompressing gzip-compressed messages, and
+	// reports each message's length and bytes as a hex dump.
+	GRPC bool
+	// Extract is a JSONPath expression; when set, only the values it matches
+	// in the JSON body are written, one per line.
+	Extract string
+	// Grep is a regular expression; when set, only the body lines matching it
+	// are written.
+	Grep string
+	// OnInvalid selects how decodeRawDataWith handles literal bytes that are
+	// not valid UTF-8: OnInvalidError (when empty), OnInvalidReplace or
+	// OnInvalidSkip.
+	OnInvalid string
+	// Newline converts the line endings of text output: NewlineKeep (when
+	// empty), NewlineLF or NewlineCRLF. Output that is not text, such as a
+	// binary body or a recompressed one, is never converted.
+	Newline string
+	// DisableOctal, DisableHex and DisableUnicode make decodeRawDataWith keep
+	// octal (\0, \101), hex (\x41) and unicode (\u0041, \U00000041) escapes
+	// verbatim, backslash included, instead of decoding them, to find out
+	// which escape handling corrupts a body.
+	DisableOctal   bool
+	DisableHex     bool
+	DisableUnicode bool
+	// RawInput takes the whole input as the content of a $'...' payload,
+	// without a surrounding curl command, so it has no headers.
+	RawInput bool
+	// PercentDecode percent-decodes the body (url.QueryUnescape, so + is a
+	// space) after decompression, for form values that were also
+	// percent-encoded. It is opt-in because it would mangle a literal %.
+	PercentDecode bool
+	// PostDecode, when set, is called with the body right after decompression
+	// (or with the decoded body, when it was not compressed), e.g. to decrypt
+	// it. Its result replaces the body for everything that follows: IsJSON,
+	// digests and the Content-Type driven interpretation and formatting. An
+	// error from it is returned by Run.
+	PostDecode func(body []byte) ([]byte, error)
+	// SnappyRaw decompresses a body without known magic bytes as a raw snappy
+	// block, which has no header to detect it by.
+	SnappyRaw bool
+	// Indent is the indentation of pretty-printed JSON, as returned by
+	// parseIndent; two spaces when empty.
+	Indent string
+	// ReprWidth wraps b'...' previews and output into lines of at most this
+	// many characters; 0 keeps them on one line.
+	ReprWidth int
+	// Offset and Length select a window of the final body: when either is
+	// positive, only the Length bytes (all of them when Length is 0) from
+	// Offset on are output, clamped to the body, as reprBytes notation unless
+	// Format is set. With NoDecompress, decoding stops at the window's end.
+	Offset int
+	Length int
+	// Stream outputs the decompressed body as is, without interpreting it.
+	// RunTo then copies gzip bodies straight from the gzip reader to its
+	// writer when no other option needs the whole body (see streamable).
+	Stream bool
+	// IgnoreGzipCRC keeps the decompressed gzip data, with a warning, when
+	// only the CRC-32 or length in the gzip trailer is wrong, as when a
+	// capture tool clobbered the last bytes.
+	IgnoreGzipCRC bool
+	// Decompress forces the decompressor, by a name from decompressors,
+	// instead of detecting it from the magic bytes; DecompressNone never
+	// decompresses. Decompression failing is then a DecompressError. Empty or
+	// DecompressAuto detects it.
+	Decompress string
+	// NoDecompress skips decompression and writes the decoded, still
+	// compressed body as is (or in Format, when set).
+	NoDecompress bool
+	// Recurse is how many levels of curl commands nested in the body are
+	// decoded in turn; see decodeNested. 0 disables it.
+	Recurse int
+	// MaxDepth bounds every recursive step (Recurse, UnwrapJSONString): going
+	// deeper fails with a DepthError. 0 means defaultMaxDepth.
+	MaxDepth int
+	// Template, when set, is a Go text/template executed against the parsed
+	// request and the decode result instead of rendering the body; see
+	// renderTemplate.
+	Template string
+	// ScanSecrets warns about likely secrets (JWTs, AWS keys, bearer tokens,
+	// high-entropy strings) in the decompressed body; see scanSecrets.
+	ScanSecrets bool
+	// Redact scans for secrets like ScanSecrets and replaces them with
+	// [REDACTED] in the output.
+	Redact bool
+	// Summary replaces the output with the one-line summaryLine of the body
+	// (type, size, algorithm and SHA-256 prefix) instead of the body itself.
+	Summary bool
+	// Inspect replaces the output with a table of the request's and body's
+	// properties (see Report) for interactive triage.
+	Inspect bool
+	// Digest names a hash in digests ("md5", "sha1" or "sha256") whose hex
+	// digest of the processed body is printed and stored in
+	// DecodeResult.Digest, so captures can be compared by payload.
+	Digest string
+	// CountPattern, when set, is a byte pattern (see parsePattern) whose
+	// occurrences in the processed body are counted and printed, with their
+	// first offsets, and stored in DecodeResult.PatternCount.
+	CountPattern []byte
+	// StrictLength fails with an ExtractError when a Content-Length header
+	// disagrees with the decoded body instead of only warning about it.
+	StrictLength bool
+	// Verbose logs extra diagnostics, such as the exact whitespace bytes
+	// trimmed from the payload.
+	Verbose bool
+	// NoTrim keeps leading/trailing whitespace of the extracted data-raw content.
+	NoTrim bool
+	// BinaryConcat joins the arguments of all data options byte for byte into
+	// the payload instead of using the first one, for bodies split over
+	// several options.
+	BinaryConcat bool
+}
+
+// DecodeResult describes the body decoded from a cURL command at each stage.
+type DecodeResult struct {
+	// Raw is the payload after escape decoding, before decompression.
+	Raw []byte
+	// Decompressed is the body after decompression; it is Raw itself when
+	// the payload was not compressed or could not be decompressed.
+	Decompressed []byte
+	// Algorithm is the compression that was undone ("gzip", "deflate", "lz4",
+	// "snappy" or "snappy-raw"), or "" when Decompressed is Raw.
+	Algorithm string
+	// ContentType is the command's Content-Type header, if any.
+	ContentType string
+	// IsJSON reports whether Decompressed is valid JSON.
+	IsJSON bool
+	// Trimmed reports whether whitespace was trimmed from the extracted payload.
+	Trimmed bool
+	// Digest is the hex-encoded Options.Digest hash of Decompressed, or ""
+	// when no digest was requested.
+	Digest string
+	// PatternCount is how many times Options.CountPattern occurs in
+	// Decompressed, and PatternOffsets the offsets of the first
+	// maxPatternOffsets occurrences.
+	PatternCount   int
+	PatternOffsets []int
+	// Secrets lists the likely secrets found in Decompressed with
+	// Options.ScanSecrets or Options.Redact.
+	Secrets []SecretFinding
+	// Nested is the result of decoding the curl command that Decompressed
+	// itself contains, with Options.Recurse.
+	Nested *DecodeResult
+	// Warnings lists problems with the capture that did not stop decoding,
+	// such as a Content-Length header that disagrees with the body.
+	Warnings []string
+	// Output is the bytes that should be written to the output file.
+	Output []byte
+
+	// partial is set when decoding stopped at the end of the Offset/Length
+	// window, so Raw may be a prefix of the body.
+	partial bool
+}
 
-	// Read the cURL command from the specified input file
-	curlCommandBytes, err := os.ReadFile(*inputFile)
+// Run extracts the --data-raw payload from curlCommand, decodes its escape
+// sequences, decompresses it when it looks gzip or zlib compressed and pretty-prints it when it
+// is JSON. It returns the bytes that should be written to the output file.
+// Errors are wrapped in ExtractError, DecodeError, DecompressError or
+// NotJSONError so callers can tell the failing stage apart.
+func Run(curlCommand string, opts Options) ([]byte, error) {
+	res, err := Decode(curlCommand, opts)
 	if err != nil {
-		log.Fatalf("Error reading input file %s: %v", *inputFile, err)
+		return nil, err
 	}
-	curlCommand := string(curlCommandBytes)
+	return res.Output, nil
+}
 
-	// Extract the data-raw part
-	dataRaw, err := extractDataRaw(curlCommand)
+// RunTo is Run writing the output to w (a file or os.Stdout) instead of
+// returning it. With opts.Stream, gzip bodies are streamed to w without
+// holding the decompressed body in memory.
+func RunTo(w io.Writer, curlCommand string, opts Options) error {
+	if streamable(opts) {
+		if streamed, err := streamGzip(w, curlCommand, opts); streamed || err != nil {
+			return err
+		}
+	}
+	output, err := Run(curlCommand, opts)
 	if err != nil {
-		log.Fatalf("Error during extraction: %v", err)
+		return err
 	}
+	return writeOutput(w, output)
+}
 
-	// !!! ADDEDWhitespaceTrimming !!!
-	// Remove leading/trailing whitespace from the extracted data-raw content
-	// This handles cases like $' \u001f...' where a leading space can corrupt the gzip stream.
-	originalExtractedLength := len(dataRaw)
-	dataRaw = strings.TrimSpace(dataRaw)
-	if len(dataRaw) != originalExtractedLength {
-		log.Printf("Trimmed whitespace from extracted data-raw content. Original length: %d, New length: %d", originalExtractedLength, len(dataRaw))
+// prepareCommand applies the input-wide options to curlCommand before it is
+// parsed: base64-decoding (-input-format b64cmd, after which opts reads the
+// input as a cURL command), URL-decoding, locating the curl command in a
+// larger text and environment variable substitution.
+func prepareCommand(curlCommand string, opts Options) (string, Options, error) {
+	if opts.InputFormat == inputFormatB64Cmd {
+		command, err := decodeB64Command(curlCommand)
+		if err != nil {
+			return "", opts, &ExtractError{Err: err}
+		}
+		logger.Info(fmt.Sprintf("Base64-decoded a %d-byte command from the input.", len(command)), field("length", len(command)))
+		curlCommand, opts.InputFormat = command, inputFormatCurl
 	}
-	// !!! End of ADDEDWhitespaceTrimming !!!
+	if opts.URLDecodeInput {
+		decoded, err := url.QueryUnescape(curlCommand)
+		if err != nil {
+			return "", opts, &ExtractError{Err: fmt.Errorf("URL-decoding the input: %w", err)}
+		}
+		logger.Info("URL-decoded the whole input command.")
+		curlCommand = decoded
+	}
+	if opts.FindCurl {
+		command, line, err := findCurlCommand(curlCommand)
+		if err != nil {
+			return "", opts, &ExtractError{Err: err}
+		}
+		logger.Info(fmt.Sprintf("Found a curl command on line %d of the input.", line), field("line", line))
+		curlCommand = command
+	}
+	if opts.Env {
+		expanded, missing := expandEnvRefs(curlCommand, os.LookupEnv)
+		for _, name := range missing {
+			logger.Warn(fmt.Sprintf("Environment variable %s is not set; leaving the reference as is.", name), field("variable", name))
+		}
+		curlCommand = expanded
+	}
+	return curlCommand, opts, nil
+}
 
-	fmt.Println("Extracted data-raw part (first 100 characters, after trim):") // Log message updated
-	if len(dataRaw) > 100 {
-		fmt.Printf("%q\n", dataRaw[:100])
-	} else {
-		fmt.Printf("%q\n", dataRaw)
+// Decode is Run returning the whole DecodeResult instead of only the output.
+// With opts.Emit or opts.Replay only Output is set. With opts.GzipOutput,
+// Output is gzipped.
+func Decode(curlCommand string, opts Options) (*DecodeResult, error) {
+	res, err := decode(curlCommand, opts)
+	if err != nil || !opts.GzipOutput {
+		return res, err
 	}
+	if res.Output, err = gzipOutput(res.Output, opts); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
 
-	// Decode the raw data
-	decodedData, err := decodeRawData(dataRaw)
+// decode is Decode without opts.GzipOutput.
+func decode(curlCommand string, opts Options) (*DecodeResult, error) {
+	curlCommand, opts, err := prepareCommand(curlCommand, opts)
 	if err != nil {
-		log.Fatalf("Error during decoding raw data: %v", err)
+		return nil, err
 	}
-	fmt.Println("Decoded data (first 100 bytes):")
-	if len(decodedData) > 100 {
-		fmt.Println(reprBytes(decodedData[:100]))
+	if opts.Scan {
+		output, err := runScan(curlCommand, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &DecodeResult{Output: output, IsJSON: true}, nil
+	}
+	if opts.InputFormat == inputFormatJSONList {
+		output, err := runJSONList(curlCommand, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &DecodeResult{Output: output, IsJSON: true}, nil
+	}
+	if opts.Emit != "" || opts.Replay {
+		run := runEmit
+		if opts.Replay {
+			run = runReplay
+		}
+		output, err := run(curlCommand, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &DecodeResult{Output: output}, nil
+	}
+	if (opts.InputFormat == "" || opts.InputFormat == inputFormatCurl) && !opts.RawInput {
+		if commands, err := splitCurlNext(curlCommand, opts.DataFlags); err == nil && len(commands) > 1 {
+			logger.Info(fmt.Sprintf("The command holds %d requests separated by --next. Decoding each one.", len(commands)), field("requests", len(commands)))
+			output, err := decodeCommandList(commands, nil, "the --next separated command", opts)
+			if err != nil {
+				return nil, err
+			}
+			return &DecodeResult{Output: output, IsJSON: true}, nil
+		}
+	}
+	res := &DecodeResult{}
+
+	var decodedData []byte
+	var headers Headers
+	if opts.InputFormat == inputFormatHTTPRaw || opts.InputFormat == inputFormatHTTPie {
+		r, err := parseCommand(curlCommand, opts)
+		if err != nil {
+			return nil, err
+		}
+		what := fmt.Sprintf("%s request %s %s", opts.InputFormat, r.Method, r.URL)
+		if r.Method == "" {
+			what = opts.InputFormat + " response"
+		}
+		if len(r.Body) == 0 {
+			return nil, &ExtractError{Err: fmt.Errorf("%s has no body", what)}
+		}
+		logger.Info(fmt.Sprintf("Parsed %s with a %d-byte body.", what, len(r.Body)), field("method", r.Method), field("url", r.URL), field("length", len(r.Body)))
+		decodedData, headers = r.Body, r.Headers
 	} else {
-		fmt.Println(reprBytes(decodedData))
+		if decodedData, err = decodeCurlPayload(res, curlCommand, opts); err != nil {
+			var extractErr *ExtractError
+			if !errors.As(err, &extractErr) || opts.RawInput {
+				return nil, err
+			}
+			r, parseErr := parseCurl(curlCommand, opts)
+			if parseErr != nil || !isDataURI([]byte(r.URL)) {
+				return nil, err
+			}
+			logger.Info("The command sends no data but its URL is a data: URI. Decoding it as the body.")
+			decodedData = []byte(r.URL)
+		}
+		if opts.RawInput {
+			// There is no command, so there are no headers.
+		} else if headers, err = extractHeaders(curlCommand); err != nil {
+			logger.Warn(fmt.Sprintf("Could not parse the command's headers, sniffing the body instead: %v", err), field("error", err))
+		}
 	}
 
 	// *** DECOMPRESSION LOGIC MODIFICATION START ***
@@ -329,75 +1019,397 @@ func main() {
 	// You could add logic here to check for 'Content-Encoding: gzip' header in the curl command.
 	// For this specific problem, we know it's not gzipped.
 
-	// Try to decompress only if it seems like gzipped data
-	// A simple heuristic (not foolproof) is to check for gzip magic bytes (0x1f 0x8b)
-	if len(decodedData) >= 2 && decodedData[0] == 0x1f && decodedData[1] == 0x8b {
-		log.Println("Detected potential gzip header. Attempting decompression.")
-		decompressedData, err := decompressGzipData(decodedData)
+	// Try to decompress only if it seems like compressed data.
+	// A simple heuristic (not foolproof) is to check for magic bytes (gzip: 0x1f 0x8b, zlib: 0x78 ..),
+	// tolerating a few leading whitespace bytes that survived trimming.
+	// Bodies such as analytics beacons may also carry compressed data as base64 text.
+	// The Content-Encoding header is only a hint checked against the magic
+	// bytes, and the Content-Type header drives the interpretation below;
+	// without it, the body is sniffed.
+	res.ContentType = headers.Get("Content-Type")
+	if isDataURI(decodedData) {
+		mediaType, data, err := parseDataURI(string(decodedData))
 		if err != nil {
-			// Log the error but don't fatally exit, in case it's not gzip after all.
-			log.Printf("Warning: Decompression failed, data might not be gzipped or is corrupted: %v", err)
+			logger.Warn(fmt.Sprintf("The body starts with data: but is not a valid data: URI, keeping it as is: %v", err), field("error", err))
+		} else {
+			logger.Info(fmt.Sprintf("The body is a data: URI. Using its %d-byte %s content as the body.", len(data), mediaType), field("media_type", mediaType), field("length", len(data)))
+			decodedData = data
+			if res.ContentType == "" {
+				res.ContentType = mediaType
+			}
+		}
+	}
+	contentEncoding := headers.Get("Content-Encoding")
+	if !res.partial {
+		if err := applyContentLength(res, headers, decodedData, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	compressedData := decodedData
+	forced := opts.Decompress != "" && opts.Decompress != DecompressAuto
+	var algorithm string
+	var skip int
+	switch {
+	case opts.Decompress == DecompressNone:
+		algorithm = algoNone
+	case forced:
+		algorithm = forcedCompression(opts.Decompress, decodedData)
+		logger.Info(fmt.Sprintf("Forcing %s decompression (-decompress %s).", algorithm, opts.Decompress), field("algorithm", algorithm))
+	default:
+		algorithm, skip = detectCompression(decodedData)
+		if algorithm == algoNone {
+			if inner, innerAlgorithm := detectBase64Compression(decodedData); innerAlgorithm != algoNone {
+				logger.Info(fmt.Sprintf("Detected base64-encoded %s data. Decoding base64 before decompression.", innerAlgorithm), field("algorithm", innerAlgorithm))
+				compressedData, algorithm = inner, innerAlgorithm
+			}
+		}
+		if algorithm == algoNone && opts.SnappyRaw {
+			algorithm = algoSnappyRaw
+		}
+		reconcileContentEncoding(contentEncoding, algorithm)
+	}
+	if opts.NoDecompress {
+		if algorithm != algoNone {
+			logger.Info(fmt.Sprintf("Detected %s data. Keeping it compressed (-no-decompress).", algorithm), field("algorithm", algorithm))
+		}
+		finalProcessedData = decodedData
+	} else if algorithm != algoNone {
+		if skip > 0 {
+			logger.Info(fmt.Sprintf("Skipped %d leading whitespace byte(s) before the %s magic bytes.", skip, algorithm), field("skipped", skip), field("algorithm", algorithm))
+		}
+		logger.Info(fmt.Sprintf("Detected potential %s header. Attempting decompression.", algorithm), field("algorithm", algorithm))
+		decompressedData, err := decompressData(algorithm, compressedData[skip:])
+		if err != nil && opts.IgnoreGzipCRC && errors.Is(err, gzip.ErrChecksum) {
+			warning := fmt.Sprintf("The gzip trailer does not match the %d decompressed bytes (wrong CRC-32 or length); keeping them anyway (-ignore-gzip-crc).", len(decompressedData))
+			res.Warnings = append(res.Warnings, warning)
+			logger.Warn(warning, field("length", len(decompressedData)))
+			err = nil
+		}
+		if err != nil && forced {
+			return nil, &DecompressError{Err: fmt.Errorf("forced %s decompression: %w", algorithm, err)}
+		}
+		if err != nil {
+			// Log the error but don't fatally exit, in case it's not compressed after all.
+			logger.Warn(fmt.Sprintf("Decompression failed, data might not be %s compressed or is corrupted: %v", algorithm, err), field("algorithm", algorithm), field("error", err))
 			finalProcessedData = decodedData // Use original data if decompression fails
 		} else {
 			finalProcessedData = decompressedData
+			res.Algorithm = algorithm
 
-			fmt.Println("Decompressed data (first 100 bytes):")
+			fmt.Fprintln(previews, "Decompressed data (first 100 bytes):")
 			if len(finalProcessedData) > 100 {
-				fmt.Println(reprBytes(finalProcessedData[:100]))
+				fmt.Fprintln(previews, previewRepr(finalProcessedData[:100], opts))
 			} else {
-				fmt.Println(reprBytes(finalProcessedData))
+				fmt.Fprintln(previews, previewRepr(finalProcessedData, opts))
 			}
 		}
+	} else if opts.Decompress == DecompressNone {
+		logger.Info("Skipping decompression (-decompress none).")
+		finalProcessedData = decodedData
 	} else {
-		log.Println("Data does not appear to be gzip compressed (missing magic bytes). Skipping decompression.")
+		logger.Info("Data does not appear to be compressed (missing magic bytes). Skipping decompression.")
 		finalProcessedData = decodedData // Use the decoded data directly
 	}
 	// *** DECOMPRESSION LOGIC MODIFICATION END ***
 
+	if opts.PostDecode != nil {
+		if finalProcessedData, err = opts.PostDecode(finalProcessedData); err != nil {
+			return nil, fmt.Errorf("PostDecode: %w", err)
+		}
+	}
+
+	if opts.PercentDecode {
+		unescaped, err := url.QueryUnescape(string(finalProcessedData))
+		if err != nil {
+			return nil, &DecodeError{Err: fmt.Errorf("percent-decoding the body: %w", err)}
+		}
+		logger.Info(fmt.Sprintf("Percent-decoded the body: %d -> %d bytes.", len(finalProcessedData), len(unescaped)), field("original_length", len(finalProcessedData)), field("new_length", len(unescaped)))
+		finalProcessedData = []byte(unescaped)
+	}
+
 	// Convert the processed data to a string (assuming UTF-8, as in the Python script)
 	// If it was gzipped, this is the decompressed string.
 	// If not gzipped, this is the raw decoded string.
 	processedString := string(finalProcessedData)
-	fmt.Println("Processed string (first 100 characters):")
+	fmt.Fprintln(previews, "Processed string (first 100 characters):")
 	if len(processedString) > 100 {
-		fmt.Printf("%q\n", processedString[:100])
+		fmt.Fprintf(previews, "%q\n", processedString[:100])
 	} else {
-		fmt.Printf("%q\n", processedString)
+		fmt.Fprintf(previews, "%q\n", processedString)
 	}
 
-	// For URL-encoded data, we typically don't parse it as JSON directly.
-	// We would instead parse it using net/url.ParseQuery.
-	// Since your original code assumed JSON, we'll add a check.
+	res.Raw, res.Decompressed = decodedData, finalProcessedData
+	res.IsJSON = json.Valid(finalProcessedData)
+	if opts.Digest != "" {
+		if res.Digest, err = digestOf(opts.Digest, finalProcessedData); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(previews, "%s of the processed body: %s\n", opts.Digest, res.Digest)
+	}
+	if opts.CountPattern != nil {
+		res.PatternCount, res.PatternOffsets = findPattern(finalProcessedData, opts.CountPattern, maxPatternOffsets)
+		fmt.Fprintf(previews, "Pattern %s occurs %d time(s) in the processed body.\n", reprBytes(opts.CountPattern), res.PatternCount)
+		if res.PatternCount > 0 {
+			offsets := make([]string, len(res.PatternOffsets))
+			for i, offset := range res.PatternOffsets {
+				offsets[i] = strconv.Itoa(offset)
+			}
+			more := ""
+			if res.PatternCount > len(res.PatternOffsets) {
+				more = fmt.Sprintf(" (first %d)", len(res.PatternOffsets))
+			}
+			fmt.Fprintf(previews, "Offsets%s: %s\n", more, strings.Join(offsets, ", "))
+		}
+	}
 
-	// Try to parse as JSON. If it fails, treat it as plain text or URL-encoded.
-	var jsonData interface{} // To accept any valid JSON structure
-	err = json.Unmarshal([]byte(processedString), &jsonData)
-	if err != nil {
-		log.Printf("Warning: Data is not valid JSON, treating as plain text or URL-encoded: %v", err)
-		// If it's not JSON, write the raw processed string to the output file.
-		// Or you could add logic here to specifically parse URL-encoded data.
+	body := finalProcessedData
+	if opts.ScanSecrets || opts.Redact {
+		res.Secrets = scanSecrets(finalProcessedData)
+		for _, f := range res.Secrets {
+			logger.Warn(fmt.Sprintf("Possible %s (%d bytes) at offset %d of the body.", f.Type, f.Length, f.Offset), field("type", f.Type), field("offset", f.Offset), field("length", f.Length))
+		}
+		if opts.Redact && len(res.Secrets) > 0 {
+			body = redactSecrets(body, res.Secrets)
+			logger.Info(fmt.Sprintf("Redacted %d possible secret(s) in the output.", len(res.Secrets)), field("redacted", len(res.Secrets)))
+		}
+	}
 
-		fmt.Println("Saving raw processed string to output file.")
-		err = os.WriteFile(*outputFile, finalProcessedData, 0644)
+	if opts.Inspect {
+		report, err := buildReport(res, parseRequestFor("-inspect", curlCommand, headers, opts))
+		if err != nil {
+			return nil, err
+		}
+		res.Output = []byte(report.Table())
+		return res, nil
+	}
+	if opts.Summary {
+		line, err := summaryLine(res)
 		if err != nil {
-			log.Fatalf("Error saving processed data to file %s: %v", *outputFile, err)
+			return nil, err
 		}
-		fmt.Printf("Processed data (not JSON) has been saved to %s\n", *outputFile)
-		return // Exit the program here if it's not JSON
+		res.Output = []byte(line)
+		return res, nil
 	}
 
-	// Pretty-print the JSON data (like indent=2 in Python)
-	prettyJSON, err := json.MarshalIndent(jsonData, "", "  ")
+	windowed := opts.Offset > 0 || opts.Length > 0
+	if windowed {
+		body = byteWindow(body, opts.Offset, opts.Length)
+		logger.Info(fmt.Sprintf("Writing the %d-byte window at offset %d of the %d-byte body.", len(body), opts.Offset, len(finalProcessedData)), field("offset", opts.Offset), field("length", len(body)), field("body_length", len(finalProcessedData)))
+	}
+	if opts.Recompress {
+		var header *gzip.Header
+		if opts.KeepGzipHeader && res.Algorithm == algoGzip {
+			if header, err = gzipHeaderOf(compressedData[skip:]); err != nil {
+				return nil, &DecompressError{Err: err}
+			}
+		}
+		if body, err = compressGzipDataWithHeader(body, opts.GzipLevel, header); err != nil {
+			return nil, err
+		}
+		logger.Info(fmt.Sprintf("Recompressed the body with gzip level %d: %d -> %d bytes.", opts.GzipLevel, len(finalProcessedData), len(body)), field("level", opts.GzipLevel), field("original_length", len(finalProcessedData)), field("new_length", len(body)))
+	}
+	if opts.Template != "" {
+		r := parseRequestFor("-template", curlCommand, headers, opts)
+		if res.Output, err = renderTemplate(opts.Template, templateData{DecodeResult: res, Method: r.Method, URL: r.URL, Headers: r.Headers, Body: body}); err != nil {
+			return nil, err
+		}
+	} else if windowed && opts.Format == "" {
+		res.Output = []byte(strings.Join(reprLines(body, opts.ReprWidth), "\n"))
+	} else if (opts.NoDecompress || opts.Stream) && opts.Format == "" {
+		res.Output = body
+	} else if res.Output, err = renderBody(res, body, opts); err != nil {
+		return nil, err
+	}
+	if opts.Recurse > 0 {
+		if err := decodeNested(res, opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Newline != "" && opts.Newline != NewlineKeep && !opts.Recompress && isText(res.Output) {
+		res.Output = normalizeNewlines(res.Output, opts.Newline)
+	}
+	return res, nil
+}
+
+// byteWindow returns the length bytes of data from offset on, or all of them
+// when length is 0, clamped to the end of data.
+func byteWindow(data []byte, offset, length int) []byte {
+	offset = min(offset, len(data))
+	end := len(data)
+	if length > 0 {
+		end = min(offset+length, end)
+	}
+	return data[offset:end]
+}
+
+// trimPayload trims leading and trailing whitespace from payload as
+// strings.TrimSpace does and also returns what was removed from each end.
+func trimPayload(payload string) (trimmed, leading, trailing string) {
+	trimmed = strings.TrimLeftFunc(payload, unicode.IsSpace)
+	leading = payload[:len(payload)-len(trimmed)]
+	rest := trimmed
+	trimmed = strings.TrimRightFunc(rest, unicode.IsSpace)
+	trailing = rest[len(trimmed):]
+	return trimmed, leading, trailing
+}
+
+// decodeCurlPayload extracts the data-raw payload of curlCommand, unwraps line
+// continuations, trims it (recording that in res) and decodes its escape
+// sequences. With opts.RawInput, curlCommand is the content of the payload's
+// $'...' quotes itself; with opts.BinaryConcat, the payload is every data
+// option's argument joined together.
+func decodeCurlPayload(res *DecodeResult, curlCommand string, opts Options) ([]byte, error) {
+	// Extract the data-raw part
+	payload := Token{Value: curlCommand, ANSIC: true}
+	var err error
+	if opts.RawInput {
+		logger.Info("Treating the whole input as the $'...' payload content (-raw-input).")
+	} else if opts.BinaryConcat {
+		payloads, err := extractPayloads(curlCommand, opts.DataFlags)
+		if err != nil {
+			return nil, &ExtractError{Err: err}
+		}
+		payload = concatPayloads(payloads)
+		if len(payloads) > 1 {
+			logger.Info(fmt.Sprintf("Concatenated %d data option arguments as raw bytes (-binary-concat).", len(payloads)), field("segments", len(payloads)))
+		}
+	} else if payload, err = extractPayload(curlCommand, opts.DataFlags); err != nil {
+		return nil, &ExtractError{Err: err}
+	}
+	dataRaw := payload.Value
+	if payload.ANSIC {
+		var unwrapped int
+		if dataRaw, unwrapped = unwrapContinuations(dataRaw); unwrapped > 0 {
+			logger.Info(fmt.Sprintf("Removed %d backslash-newline line continuation(s) from the data-raw content.", unwrapped), field("continuations", unwrapped))
+		}
+	}
+
+	// !!! ADDEDWhitespaceTrimming !!!
+	// Remove leading/trailing whitespace from the extracted data-raw content
+	// This handles cases like $' \u001f...' where a leading space can corrupt the gzip stream.
+	if !opts.NoTrim {
+		originalExtractedLength := len(dataRaw)
+		var leading, trailing string
+		dataRaw, leading, trailing = trimPayload(dataRaw)
+		if len(dataRaw) != originalExtractedLength {
+			res.Trimmed = true
+			logger.Info(fmt.Sprintf("Trimmed whitespace from extracted data-raw content. Original length: %d, New length: %d (leading: %d, trailing: %d)", originalExtractedLength, len(dataRaw), len(leading), len(trailing)),
+				field("original_length", originalExtractedLength), field("new_length", len(dataRaw)), field("leading", len(leading)), field("trailing", len(trailing)))
+			if opts.Verbose {
+				logger.Info(fmt.Sprintf("Trimmed leading %s and trailing %s.", reprBytes([]byte(leading)), reprBytes([]byte(trailing))), field("leading_bytes", leading), field("trailing_bytes", trailing))
+			}
+		}
+	}
+	// !!! End of ADDEDWhitespaceTrimming !!!
+
+	fmt.Fprintln(previews, "Extracted data-raw part (first 100 characters, after trim):") // Log message updated
+	if len(dataRaw) > 100 {
+		fmt.Fprintf(previews, "%q\n", dataRaw[:100])
+	} else {
+		fmt.Fprintf(previews, "%q\n", dataRaw)
+	}
+
+	// Decode the raw data. Only $'...' payloads contain escape sequences; other
+	// quoting styles already hold the literal body.
+	decodedData := []byte(dataRaw)
+	if payload.ANSIC {
+		limit := -1
+		if opts.NoDecompress && opts.Length > 0 {
+			limit = opts.Offset + opts.Length // Only the window is output.
+		}
+		decodedData, err = decodeRawDataLimit(dataRaw, opts, limit)
+		if limit >= 0 && len(decodedData) >= limit {
+			res.partial = true
+			logger.Info(fmt.Sprintf("Stopped decoding after the first %d bytes, the end of the -offset/-length window.", len(decodedData)), field("length", len(decodedData)))
+		}
+		if err != nil {
+			return nil, &DecodeError{Err: err}
+		}
+	} else {
+		logger.Info("Payload is not ANSI-C quoted ($'...'); using it verbatim.")
+	}
+	fmt.Fprintln(previews, "Decoded data (first 100 bytes):")
+	if len(decodedData) > 100 {
+		fmt.Fprintln(previews, previewRepr(decodedData[:100], opts))
+	} else {
+		fmt.Fprintln(previews, previewRepr(decodedData, opts))
+	}
+	return decodedData, nil
+}
+
+// renderBody produces the output for data, the decoded body: the -format
+// representation, one of the special views (-sse, -grpcweb, -extract, -grep)
+// or, by default, the Content-Type driven interpretation.
+func renderBody(res *DecodeResult, data []byte, opts Options) ([]byte, error) {
+	switch {
+	case opts.Format != "":
+		format, ok := outputFormats[opts.Format]
+		if !ok {
+			return nil, fmt.Errorf("unknown output format %q (want one of %s)", opts.Format, strings.Join(outputFormatNames(), ", "))
+		}
+		return format(data, opts)
+	case opts.SSE:
+		return formatSSE(data, opts)
+	case opts.GRPCWeb:
+		return formatGRPCWeb(data, opts)
+	case opts.GRPC:
+		return formatGRPC(data, opts)
+	case opts.Extract != "":
+		return extractJSONPath(data, opts.Extract, opts)
+	case opts.Grep != "":
+		return grepLines(data, opts.Grep)
+	default:
+		return interpretBody(res.ContentType, data, opts)
+	}
+}
+
+// runEmit parses curlCommand into a Request and renders it with the emitter
+// selected by opts.Emit instead of decoding the body.
+func runEmit(curlCommand string, opts Options) ([]byte, error) {
+	emit, ok := emitters[opts.Emit]
+	if !ok {
+		return nil, fmt.Errorf("unknown emit mode %q (want one of %s)", opts.Emit, strings.Join(emitModes(), ", "))
+	}
+	r, err := parseCommand(curlCommand, opts)
 	if err != nil {
-		log.Fatalf("Error marshalling JSON to pretty format: %v", err)
+		return nil, err
 	}
-	fmt.Println("Parsed JSON data:")
-	fmt.Println(string(prettyJSON))
+	return emit(r)
+}
 
-	// Save the pretty JSON data to the specified output file
-	err = os.WriteFile(*outputFile, prettyJSON, 0644) // 0644 gives read/write to owner, read to others
+// parseRequestFor is parseCommand for an option (named by flag in the log)
+// that only reports on the request: when the command cannot be parsed, the
+// Request has headers, as found while decoding, and no method or URL.
+func parseRequestFor(flag, curlCommand string, headers Headers, opts Options) *Request {
+	r, err := parseCommand(curlCommand, opts)
 	if err != nil {
-		log.Fatalf("Error saving decoded data to file %s: %v", *outputFile, err)
+		logger.Warn(fmt.Sprintf("Could not parse the request for %s, leaving its method and URL empty: %v", flag, err), field("error", err))
+		return &Request{Headers: headers}
+	}
+	return r
+}
+
+// parseCommand is parseCurl (or parseHTTPRaw or parseHTTPie, according to
+// opts.InputFormat) with its errors categorized for Run: decoding failures
+// stay DecodeErrors and anything else becomes an ExtractError.
+func parseCommand(curlCommand string, opts Options) (*Request, error) {
+	var r *Request
+	var err error
+	switch opts.InputFormat {
+	case inputFormatHTTPRaw:
+		r, err = parseHTTPRaw(curlCommand)
+	case inputFormatHTTPie:
+		r, err = parseHTTPie(curlCommand)
+	default:
+		r, err = parseCurl(curlCommand, opts)
+	}
+	if err != nil {
+		var decodeErr *DecodeError
+		if errors.As(err, &decodeErr) {
+			return nil, err
+		}
+		return nil, &ExtractError{Err: err}
 	}
-	fmt.Printf("Decoded data has been saved to %s\n", *outputFile)
+	return r, nil
 }
@@ -3,6 +3,12 @@ package main
 import (
 	"bytes"
 	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -23,6 +29,10 @@ func TestReprBytes(t *testing.T) {
 		{"tab", []byte("line\tbreak"), "b'line\\tbreak'"},
 		{"non-printable ASCII", []byte{0x00, 0x1f}, "b'\\x00\\x1f'"},
 		{"mixed", []byte("a\nb'\\c\x01"), "b'a\\nb\\'\\\\c\\x01'"},
+		{"DEL", []byte{0x7f}, "b'\\x7f'"},
+		{"first high byte", []byte{0x80}, "b'\\x80'"},
+		{"last high byte", []byte{0xff}, "b'\\xff'"},
+		{"UTF-8 sequence", []byte("é"), "b'\\xc3\\xa9'"},
 	}
 
 	for _, tt := range tests {
@@ -35,6 +45,91 @@ func TestReprBytes(t *testing.T) {
 	}
 }
 
+// TestReprBytesFullRange tests that reprBytes never emits a raw byte outside
+// printable ASCII, so binary data always renders as escapes.
+func TestReprBytesFullRange(t *testing.T) {
+	for i := 0; i < 256; i++ {
+		b := byte(i)
+		got := reprBytes([]byte{b})
+		for j := 0; j < len(got); j++ {
+			if got[j] < 0x20 || got[j] >= 0x7f {
+				t.Errorf("reprBytes([0x%02x]) = %q contains raw byte 0x%02x", b, got, got[j])
+			}
+		}
+		if (b < 0x20 || b >= 0x7f) && b != '\n' && b != '\r' && b != '\t' {
+			if expected := fmt.Sprintf("b'\\x%02x'", b); got != expected {
+				t.Errorf("reprBytes([0x%02x]) = %q; want %q", b, got, expected)
+			}
+		}
+	}
+}
+
+// TestReprLines tests the reprLines function.
+func TestReprLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		width    int
+		expected []string
+	}{
+		{"no wrapping", []byte("hello world"), 0, []string{"b'hello world'"}},
+		{"fits", []byte("hello"), 8, []string{"b'hello'"}},
+		{"plain text", []byte("hello world"), 8, []string{"b'hello'", "b' worl'", "b'd'"}},
+		{"escapes are not split", []byte("ab\x00\x01c'"), 8, []string{"b'ab'", `b'\x00'`, `b'\x01c'`, `b'\''`}},
+		{"narrower than an escape", []byte(`\x`), 3, []string{`b'\\'`, "b'x'"}},
+		{"empty", nil, 5, []string{"b''"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reprLines(tt.input, tt.width)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("reprLines(%q, %d) = %q; want %q", tt.input, tt.width, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestEncodeRawData tests the encodeRawData function and that it round-trips through decodeRawData.
+func TestEncodeRawData(t *testing.T) {
+	allBytes := make([]byte, 256)
+	for i := range allBytes {
+		allBytes[i] = byte(i)
+	}
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		{"empty", []byte{}, ""},
+		{"printable ASCII", []byte(`{"a": 1}`), `{"a": 1}`},
+		{"quote and backslash", []byte(`it's a\b`), `it\'s a\\b`},
+		{"short escapes", []byte("a\nb\rc\td"), `a\nb\rc\td`},
+		{"control and high bytes", []byte{0x00, 0x1f, 0x7f, 0x80, 0xff}, `\x00\x1f\x7f\x80\xff`},
+		{"gzip magic followed by hex digit", []byte{0x1f, 0x8b, 'a'}, `\x1f\x8ba`},
+		{"edge spaces", []byte(" a b "), `\x20a b\x20`},
+		{"full range", allBytes, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := encodeRawData(tt.input)
+			if tt.expected != "" && got != tt.expected {
+				t.Errorf("encodeRawData(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+			for _, dialect := range []string{DialectPython, DialectBash, DialectTolerant} {
+				decoded, err := decodeRawDataWith(got, Options{Dialect: dialect})
+				if err != nil {
+					t.Fatalf("decodeRawDataWith(%q, %s) returned an unexpected error: %v", got, dialect, err)
+				}
+				if !bytes.Equal(decoded, tt.input) {
+					t.Errorf("%s round trip of %q = %q", dialect, tt.input, decoded)
+				}
+			}
+		})
+	}
+}
+
 // TestExtractDataRaw tests the extractDataRaw function.
 func TestExtractDataRaw(t *testing.T) {
 	tests := []struct {
@@ -106,6 +201,175 @@ func TestExtractDataRaw(t *testing.T) {
 	}
 }
 
+// TestFindDataRaw tests the findDataRaw function, including the reported offsets.
+func TestFindDataRaw(t *testing.T) {
+	tests := []struct {
+		name        string
+		curlCommand string
+		expected    string
+		expectError bool
+	}{
+		{"only flag", "--data-raw $'abc'", "abc", false},
+		{"surrounding flags", "curl 'url' -H 'A: b' --data-raw $'{\"k\":1}' --compressed -H 'X: y'", "{\"k\":1}", false},
+		{"escaped quote", "curl 'url' --data-raw $'it\\'s' -H 'X: y'", "it\\'s", false},
+		{"escaped backslash before quote", "curl 'url' --data-raw $'a\\\\' -H 'X: y'", "a\\\\", false},
+		{"multiple spaces", "curl 'url' --data-raw   $'abc'", "abc", false},
+		{"second occurrence", "curl 'url' --data-raw 'x' --data-raw $'abc'", "abc", false},
+		{"unterminated", "curl 'url' --data-raw $'abc", "", true},
+		{"no space", "curl 'url' --data-raw$'abc'", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findDataRaw(tt.curlCommand)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("findDataRaw(%q) should have returned an error, but got nil", tt.curlCommand)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("findDataRaw(%q) returned an unexpected error: %v", tt.curlCommand, err)
+			}
+			if got.Value != tt.expected {
+				t.Errorf("findDataRaw(%q).Value = %q; want %q", tt.curlCommand, got.Value, tt.expected)
+			}
+			if sub := tt.curlCommand[got.Start:got.End]; sub != tt.expected {
+				t.Errorf("findDataRaw(%q) offsets [%d:%d] select %q; want %q", tt.curlCommand, got.Start, got.End, sub, tt.expected)
+			}
+			if tt.curlCommand[got.Start-2:got.Start] != "$'" || tt.curlCommand[got.End] != '\'' {
+				t.Errorf("findDataRaw(%q) offsets [%d:%d] do not sit inside the $'...' quotes", tt.curlCommand, got.Start, got.End)
+			}
+		})
+	}
+}
+
+// TestExtractPayload tests the extractPayload function.
+func TestExtractPayload(t *testing.T) {
+	tests := []struct {
+		name          string
+		curlCommand   string
+		expected      string
+		expectedANSIC bool
+		expectError   bool
+	}{
+		{"ansi-c data-raw", "curl 'url' --data-raw $'a\\nb'", "a\\nb", true, false},
+		{"single-quoted data-raw", "curl 'url' --data-raw 'a\\nb'", "a\\nb", false, false},
+		{"double-quoted data", "curl 'url' -d \"x=\\\"1\\\"\"", "x=\"1\"", false, false},
+		{"data-binary", "curl 'url' --data-binary 'raw'", "raw", false, false},
+		{"first data option wins", "curl 'url' -d 'one' -d 'two'", "one", false, false},
+		{"concatenated ansi-c segments", "curl 'url' --data-raw $'foo'$'bar' -H 'A: b'", "foobar", true, false},
+		{"mixed segments", "curl 'url' --data-raw $'a\\n''b\\c'$'\\x41'", "a\\nb\\\\c\\x41", true, false},
+		{"segments before a continuation", "curl 'url' --data-raw $'a'$'b' \\\n -H 'A: b'", "ab", true, false},
+		{"no data", "curl 'url' -H 'A: b'", "", false, true},
+		{"unterminated quote", "curl 'url' -d 'abc", "", false, true},
+		{"unterminated second segment", "curl 'url' --data-raw $'a'$'b", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractPayload(tt.curlCommand, nil)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("extractPayload(%q) should have returned an error, but got nil", tt.curlCommand)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractPayload(%q) returned an unexpected error: %v", tt.curlCommand, err)
+			}
+			if got.Value != tt.expected || got.ANSIC != tt.expectedANSIC {
+				t.Errorf("extractPayload(%q) = (%q, ANSIC=%v); want (%q, ANSIC=%v)", tt.curlCommand, got.Value, got.ANSIC, tt.expected, tt.expectedANSIC)
+			}
+		})
+	}
+}
+
+// TestRunConcatenatedSegments tests that Run decodes every segment of a
+// --data-raw argument made of adjacent quoted strings and joins them.
+func TestRunConcatenatedSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		expected string
+	}{
+		{"ansi-c segments", "curl 'u' --data-raw $'foo'$'bar'", "foobar"},
+		{"ansi-c and single-quoted", "curl 'u' --data-raw $'a\\t''b\\t' -H 'X: y'", "a\tb\\t"},
+		{"gzip split across segments", "curl 'u' --data-raw $'" + hexEscape(gzipBytes(t, "joined")[:10]) + "'$'" + hexEscape(gzipBytes(t, "joined")[10:]) + "'", "joined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(tt.command, Options{})
+			if err != nil {
+				t.Fatalf("Run(%q) returned an unexpected error: %v", tt.command, err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run(%q) = %q; want %q", tt.command, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestExtractPayloadDataFlags tests extractPayload with custom data option names.
+func TestExtractPayloadDataFlags(t *testing.T) {
+	tests := []struct {
+		name          string
+		curlCommand   string
+		dataFlags     []string
+		expected      string
+		expectedANSIC bool
+		expectError   bool
+	}{
+		{"long custom flag", "mycurl 'url' --payload $'a\\x41'", []string{"--payload"}, "a\\x41", true, false},
+		{"bare name", "mycurl --payload=abc 'url'", []string{"payload"}, "abc", false, false},
+		{"short custom flag", "mycurl -P 'abc' 'url'", []string{"-P"}, "abc", false, false},
+		{"built-ins still apply", "mycurl 'url' -d 'x'", []string{"--payload"}, "x", false, false},
+		{"data-raw ansi-c preferred", "mycurl --payload 'p' 'url' --data-raw $'d'", []string{"--payload"}, "d", true, false},
+		{"not configured", "mycurl 'url' --payload 'abc'", nil, "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractPayload(tt.curlCommand, tt.dataFlags)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("extractPayload(%q, %q) should have returned an error, but got %q", tt.curlCommand, tt.dataFlags, got.Value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractPayload(%q, %q) returned an unexpected error: %v", tt.curlCommand, tt.dataFlags, err)
+			}
+			if got.Value != tt.expected || got.ANSIC != tt.expectedANSIC {
+				t.Errorf("extractPayload(%q, %q) = (%q, ANSIC=%v); want (%q, ANSIC=%v)", tt.curlCommand, tt.dataFlags, got.Value, got.ANSIC, tt.expected, tt.expectedANSIC)
+			}
+		})
+	}
+}
+
+// TestRunDataFlags tests that a custom data option is decoded like --data-raw,
+// also when the whole request is parsed for -emit.
+func TestRunDataFlags(t *testing.T) {
+	command := "apicurl 'https://example.com/x' --payload $'{\"a\":\\x31}'"
+	opts := Options{DataFlags: []string{"--payload"}, Canonical: true}
+	got, err := Run(command, opts)
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if expected := `{"a":1}`; string(got) != expected {
+		t.Errorf("Run() = %q; want %q", got, expected)
+	}
+
+	r, err := parseCurl(command, opts)
+	if err != nil {
+		t.Fatalf("parseCurl() returned an unexpected error: %v", err)
+	}
+	if r.Method != "POST" || r.URL != "https://example.com/x" || string(r.Body) != `{"a":1}` {
+		t.Errorf("parseCurl() = %+v; want a POST to https://example.com/x with body {\"a\":1}", r)
+	}
+}
+
 // TestDecodeRawData tests the decodeRawData function.
 func TestDecodeRawData(t *testing.T) {
 	tests := []struct {
@@ -128,6 +392,9 @@ func TestDecodeRawData(t *testing.T) {
 		{"vertical tab", "\\v", []byte("\v"), false, ""},
 		{"alert", "\\a", []byte("\a"), false, ""},
 		{"valid hex", "\\x48\\x65", []byte("He"), false, ""},
+		{"mixed-case hex", "\\xaB\\xFf\\x0a", []byte{0xab, 0xff, 0x0a}, false, ""},
+		{"literal run around escapes", "abc\\tdef\\x00", []byte("abc\tdef\x00"), false, ""},
+		{"non-latin1 literal after ascii run", "abc€", nil, true, "outside Latin-1 range"},
 		{"incomplete hex", "\\x4", nil, true, "incomplete hex escape"},
 		{"invalid hex char", "\\x4G", nil, true, "invalid hex escape"},
 		{"valid unicode (latin1)", "\\u0041", []byte("A"), false, ""},
@@ -139,6 +406,8 @@ func TestDecodeRawData(t *testing.T) {
 		{"U unicode outside latin1", "\\U00000100", nil, true, "outside Latin-1 range"},
 		{"incomplete U unicode", "\\U0000006", nil, true, "incomplete unicode escape"},
 		{"invalid U unicode char", "\\U0000006G", nil, true, "invalid unicode escape"},
+		{"signed unicode", "\\u+0ff", nil, true, "invalid unicode escape"},
+		{"negative U unicode", "\\U-0000001", nil, true, "invalid unicode escape"},
 		{"octal 1 digit", "\\0", []byte{0}, false, ""},
 		{"octal 2 digits", "\\77", []byte{0x3f}, false, ""}, // '?'
 		{"octal 3 digits", "\\101", []byte{'A'}, false, ""},
@@ -177,6 +446,202 @@ func TestDecodeRawData(t *testing.T) {
 	}
 }
 
+// TestDecodeRawDataDisabledEscapes tests that decodeRawDataWith keeps the
+// escape types turned off in Options verbatim and still decodes the others.
+func TestDecodeRawDataDisabledEscapes(t *testing.T) {
+	input := `\101\x42C\U00000044\0\n`
+	tests := []struct {
+		name     string
+		opts     Options
+		expected string
+	}{
+		{"all enabled", Options{}, "ABCD\x00\n"},
+		{"octal disabled", Options{DisableOctal: true}, `\101BCD\0` + "\n"},
+		{"hex disabled", Options{DisableHex: true}, `A\x42CD` + "\x00\n"},
+		{"unicode disabled", Options{DisableUnicode: true}, `ABC\U00000044` + "\x00\n"},
+		{"all disabled", Options{DisableOctal: true, DisableHex: true, DisableUnicode: true}, `\101\x42C\U00000044\0` + "\n"},
+		{"bash hex disabled", Options{Dialect: DialectBash, DisableHex: true}, `A\x42CD` + "\x00\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeRawDataWith(input, tt.opts)
+			if err != nil {
+				t.Fatalf("decodeRawDataWith(%q) returned an unexpected error: %v", input, err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("decodeRawDataWith(%q) = %q; want %q", input, got, tt.expected)
+			}
+		})
+	}
+
+	if got, err := decodeRawDataWith(`\X42`, Options{Dialect: DialectTolerant, DisableHex: true}); err != nil || string(got) != `\X42` {
+		t.Errorf("decodeRawDataWith() of \\X42 with the tolerant dialect and hex disabled = %q, %v; want it verbatim", got, err)
+	}
+	// A disabled escape is not validated either.
+	if got, err := decodeRawDataWith(`\xZZ\u12`, Options{DisableHex: true, DisableUnicode: true}); err != nil || string(got) != `\xZZ\u12` {
+		t.Errorf("decodeRawDataWith() with malformed disabled escapes = %q, %v; want them verbatim", got, err)
+	}
+}
+
+// TestDecodeRawDataDialects tests decodeRawDataWith under the python and bash dialects.
+func TestDecodeRawDataDialects(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		dialect     string
+		expected    []byte
+		expectError bool
+		errorMsg    string
+	}{
+		{"python single hex digit", "\\x4", DialectPython, nil, true, "incomplete hex escape"},
+		{"bash single hex digit", "\\x4", DialectBash, []byte{0x04}, false, ""},
+		{"python hex then non-hex", "\\x4G", DialectPython, nil, true, "invalid hex escape"},
+		{"bash hex then non-hex", "\\x4G", DialectBash, []byte{0x04, 'G'}, false, ""},
+		{"python no hex digits", "\\xZZ", DialectPython, nil, true, "invalid hex escape"},
+		{"bash no hex digits", "\\xZZ", DialectBash, nil, true, "invalid hex escape \\xZZ"},
+		{"bash two hex digits", "\\x41\\x4a", DialectBash, []byte("AJ"), false, ""},
+		{"bash stops after two digits", "\\x414", DialectBash, []byte("A4"), false, ""},
+		{"bash hex at end of input", "a\\x", DialectBash, nil, true, "incomplete hex escape"},
+		{"empty dialect is python", "\\x4", "", nil, true, "incomplete hex escape"},
+		{"tolerant capital X", "\\X41\\Xe4", DialectTolerant, []byte{'A', 0xe4}, false, ""},
+		{"tolerant lowercase x", "\\x41", DialectTolerant, []byte("A"), false, ""},
+		{"tolerant capital X is strict about digits", "\\X4G", DialectTolerant, nil, true, "invalid hex escape"},
+		{"tolerant space after x", "\\x 41", DialectTolerant, []byte("A"), false, ""},
+		{"tolerant tab after capital X", "\\X\t41\\x42", DialectTolerant, []byte("AB"), false, ""},
+		{"tolerant skips only one space", "\\x  41", DialectTolerant, nil, true, "invalid hex escape"},
+		{"tolerant space before one digit", "\\x 4", DialectTolerant, nil, true, "invalid hex escape"},
+		{"python space after x", "\\x 41", DialectPython, nil, true, "invalid hex escape"},
+		{"bash space after x", "\\x 41", DialectBash, nil, true, "invalid hex escape"},
+		{"python keeps capital X verbatim", "\\X41", DialectPython, []byte("\\X41"), false, ""},
+		{"bash keeps capital X verbatim", "\\X41", DialectBash, []byte("\\X41"), false, ""},
+		{"tolerant escaped slash", `{"u":"https:\/\/a\/b"}`, DialectTolerant, []byte(`{"u":"https://a/b"}`), false, ""},
+		{"tolerant escaped question mark", `a\?b=1`, DialectTolerant, []byte("a?b=1"), false, ""},
+		{"python keeps escaped slash verbatim", `https:\/\/a`, DialectPython, []byte(`https:\/\/a`), false, ""},
+		{"python keeps escaped question mark verbatim", `a\?b`, DialectPython, []byte(`a\?b`), false, ""},
+		{"bash keeps escaped slash verbatim", `a\/b`, DialectBash, []byte(`a\/b`), false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeRawDataWith(tt.input, Options{Dialect: tt.dialect})
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("decodeRawDataWith(%q, %q) should have returned an error, but got nil", tt.input, tt.dialect)
+				} else if tt.errorMsg != "" && !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("decodeRawDataWith(%q, %q) error = %v, want error containing %q", tt.input, tt.dialect, err, tt.errorMsg)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("decodeRawDataWith(%q, %q) returned an unexpected error: %v", tt.input, tt.dialect, err)
+				}
+				if !bytes.Equal(got, tt.expected) {
+					t.Errorf("decodeRawDataWith(%q, %q) = %x; want %x", tt.input, tt.dialect, got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+// TestDecodeRawDataOnInvalid tests decodeRawDataWith's handling of invalid
+// UTF-8 for each Options.OnInvalid mode.
+func TestDecodeRawDataOnInvalid(t *testing.T) {
+	input := string([]byte{'A', 0xff, 'B', '\\', 'x', '4', '1', 0xc3, 0xa4, 0xfe})
+	tests := []struct {
+		name        string
+		onInvalid   string
+		expected    []byte
+		expectError bool
+	}{
+		{"default errors", "", nil, true},
+		{"error", OnInvalidError, nil, true},
+		{"replace", OnInvalidReplace, []byte("A\xef\xbf\xbdBA\xe4\xef\xbf\xbd"), false},
+		{"skip", OnInvalidSkip, []byte("ABA\xe4"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeRawDataWith(input, Options{OnInvalid: tt.onInvalid})
+			if tt.expectError {
+				if err == nil || !strings.Contains(err.Error(), "invalid UTF-8 sequence") {
+					t.Errorf("decodeRawDataWith() error = %v; want an invalid UTF-8 error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeRawDataWith() returned an unexpected error: %v", err)
+			}
+			if !bytes.Equal(got, tt.expected) {
+				t.Errorf("decodeRawDataWith() = %s; want %s", reprBytes(got), reprBytes(tt.expected))
+			}
+		})
+	}
+}
+
+// TestDecodeRawDataHexEscapesVerbatim tests that \x escapes are written
+// byte for byte, so escaped UTF-8 sequences are never folded into one rune,
+// while literal characters go through the rune/Latin-1 conversion. This holds
+// for every dialect and -on-invalid mode.
+func TestDecodeRawDataHexEscapesVerbatim(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []byte
+	}{
+		{"escaped utf-8 for ä", "\\xC3\\xA4", []byte{0xc3, 0xa4}},
+		{"literal ä", "ä", []byte{0xe4}},
+		{"escaped and literal side by side", "\\xc3\\xa4ä", []byte{0xc3, 0xa4, 0xe4}},
+		{"escaped 3-byte sequence for €", "\\xE2\\x82\\xAC", []byte{0xe2, 0x82, 0xac}},
+		{"escaped lone continuation byte", "\\x80", []byte{0x80}},
+		{"escaped invalid utf-8", "\\xff\\xfe", []byte{0xff, 0xfe}},
+	}
+
+	for _, dialect := range []string{DialectPython, DialectBash} {
+		for _, onInvalid := range []string{OnInvalidError, OnInvalidReplace, OnInvalidSkip} {
+			opts := Options{Dialect: dialect, OnInvalid: onInvalid}
+			for _, tt := range tests {
+				t.Run(dialect+"/"+onInvalid+"/"+tt.name, func(t *testing.T) {
+					got, err := decodeRawDataWith(tt.input, opts)
+					if err != nil {
+						t.Fatalf("decodeRawDataWith(%q) returned an unexpected error: %v", tt.input, err)
+					}
+					if !bytes.Equal(got, tt.expected) {
+						t.Errorf("decodeRawDataWith(%q) = %s; want %s", tt.input, reprBytes(got), reprBytes(tt.expected))
+					}
+				})
+			}
+		}
+	}
+}
+
+// TestStrictHexEscapeErrors tests the messages for malformed \x escapes in the Python dialect.
+func TestStrictHexEscapeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"hex then non-hex", "\\x4G", `decodeRawData: invalid hex escape: \x requires two hex digits; got '4' then invalid 'G'`},
+		{"non-hex first", "\\xG4", `decodeRawData: invalid hex escape: \x requires two hex digits; got invalid 'G'`},
+		{"hex then escape", "\\x4\\n", `decodeRawData: invalid hex escape: \x requires two hex digits; got '4' then invalid '\\'`},
+		{"hex then non-ascii", "\\x4é", `decodeRawData: invalid hex escape: \x requires two hex digits; got '4' then invalid 'é'`},
+		{"one digit at end", "ab\\x4", `decodeRawData: incomplete hex escape: \x requires two hex digits; got '4' then ran out of input`},
+		{"no digits at end", "ab\\x", `decodeRawData: incomplete hex escape: \x requires two hex digits; ran out of input after \x`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeRawData(tt.input)
+			if err == nil {
+				t.Fatalf("decodeRawData(%q) should have returned an error, but got nil", tt.input)
+			}
+			if err.Error() != tt.expected {
+				t.Errorf("decodeRawData(%q) error = %q; want %q", tt.input, err, tt.expected)
+			}
+		})
+	}
+}
+
 // TestDecompressGzipData tests the decompressGzipData function.
 func TestDecompressGzipData(t *testing.T) {
 	// Helper function to create gzipped data
@@ -251,3 +716,400 @@ func TestMin(t *testing.T) {
 		})
 	}
 }
+
+// TestTrimPayload tests the trimPayload function.
+func TestTrimPayload(t *testing.T) {
+	tests := []struct {
+		name             string
+		payload          string
+		expected         string
+		expectedLeading  string
+		expectedTrailing string
+	}{
+		{"crlf-prefixed stream", "\r\n\x1f\x8b", "\x1f\x8b", "\r\n", ""},
+		{"both ends", " \t{}\n\n", "{}", " \t", "\n\n"},
+		{"trailing only", "abc \r\n", "abc", "", " \r\n"},
+		{"nothing to trim", "a b", "a b", "", ""},
+		{"only whitespace", " \n ", "", " \n ", ""},
+		{"empty", "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, leading, trailing := trimPayload(tt.payload)
+			if got != tt.expected || leading != tt.expectedLeading || trailing != tt.expectedTrailing {
+				t.Errorf("trimPayload(%q) = (%q, %q, %q); want (%q, %q, %q)", tt.payload, got, leading, trailing, tt.expected, tt.expectedLeading, tt.expectedTrailing)
+			}
+		})
+	}
+}
+
+// TestRunReportsTrimmedBytes tests that Run logs the leading and trailing trim
+// counts, and the trimmed bytes themselves with Verbose.
+func TestRunReportsTrimmedBytes(t *testing.T) {
+	command := "curl 'url' --data-raw $'\r\n{\"a\":1} '"
+	tests := []struct {
+		name       string
+		opts       Options
+		expected   []string
+		unexpected string
+	}{
+		{"counts", Options{}, []string{"(leading: 2, trailing: 1)"}, "Trimmed leading"},
+		{"verbose", Options{Verbose: true}, []string{"(leading: 2, trailing: 1)", `Trimmed leading b'\r\n' and trailing b' '.`}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			saved := logger
+			logger = newLogger(&logs, logFormatText)
+			defer func() { logger = saved }()
+
+			if _, err := Run(command, tt.opts); err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			for _, want := range tt.expected {
+				if !strings.Contains(logs.String(), want) {
+					t.Errorf("Run() logs = %q; want them to contain %q", logs.String(), want)
+				}
+			}
+			if tt.unexpected != "" && strings.Contains(logs.String(), tt.unexpected) {
+				t.Errorf("Run() logs = %q; want no %q", logs.String(), tt.unexpected)
+			}
+		})
+	}
+}
+
+// TestMultilinePayload tests that literal newlines and escaped ones keep their
+// distinct meaning from extraction through decoding and JSON parsing.
+func TestMultilinePayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string // Content between $' and '.
+		expected []byte // Decoded bytes.
+	}{
+		{"literal newline", "line1\nline2", []byte("line1\nline2")},
+		{"escaped newline", `line1\nline2`, []byte("line1\nline2")},
+		{"escaped backslash then n", `line1\\nline2`, []byte(`line1\nline2`)},
+		{"literal CRLF", "line1\r\nline2", []byte("line1\r\nline2")},
+		{"mixed", "a\n" + `b\n` + "c" + `\\n`, []byte("a\nb\nc\\n")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := "curl 'url' \\\n  --data-raw $'" + tt.payload + "' \\\n  --compressed"
+			extracted, err := extractDataRaw(command)
+			if err != nil {
+				t.Fatalf("extractDataRaw(%q) returned an unexpected error: %v", command, err)
+			}
+			if extracted != tt.payload {
+				t.Errorf("extractDataRaw(%q) = %q; want %q", command, extracted, tt.payload)
+			}
+			got, err := decodeRawData(extracted)
+			if err != nil {
+				t.Fatalf("decodeRawData(%q) returned an unexpected error: %v", extracted, err)
+			}
+			if !bytes.Equal(got, tt.expected) {
+				t.Errorf("decodeRawData(%q) = %q; want %q", extracted, got, tt.expected)
+			}
+		})
+	}
+
+	// A pretty-printed JSON body: literal newlines separate the fields, while the
+	// escaped \\n inside the string value is JSON's own newline escape.
+	command := "curl 'url' --data-raw $'{\n  \"text\": \"line1\\\\nline2\",\n  \"n\": 1\n}'"
+	got, err := Run(command, Options{Canonical: true})
+	if err != nil {
+		t.Fatalf("Run(%q) returned an unexpected error: %v", command, err)
+	}
+	if expected := `{"n":1,"text":"line1\nline2"}`; string(got) != expected {
+		t.Errorf("Run(%q) = %s; want %s", command, got, expected)
+	}
+}
+
+// TestRunURLDecodeInput tests that -urldecode-input recovers a fully percent-encoded command.
+func TestRunURLDecodeInput(t *testing.T) {
+	command := "curl 'https://example.com/api?q=1' -H 'Content-Type: application/json' --data-raw $'" + hexEscape(gzipBytes(t, `{"pct":"100%"}`)) + "'"
+	encoded := url.QueryEscape(command)
+
+	tests := []struct {
+		name         string
+		input        string
+		opts         Options
+		expected     string
+		expectedCode int
+	}{
+		{"percent-encoded command", encoded, Options{URLDecodeInput: true, Canonical: true}, `{"pct":"100%"}`, exitOK},
+		{"%20 spaces", strings.ReplaceAll(encoded, "+", "%20"), Options{URLDecodeInput: true, Canonical: true}, `{"pct":"100%"}`, exitOK},
+		{"encoded command without the flag", encoded, Options{}, "", exitExtract},
+		{"invalid escape", "curl%ZZ", Options{URLDecodeInput: true}, "", exitExtract},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(tt.input, tt.opts)
+			if code := exitCodeFor(err); code != tt.expectedCode {
+				t.Fatalf("Run() error = %v (exit code %d); want exit code %d", err, code, tt.expectedCode)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// BenchmarkDecodeRawData measures decodeRawData over representative payloads:
+// plain ASCII JSON, a hex-escaped gzip body and a mix of both.
+func BenchmarkDecodeRawData(b *testing.B) {
+	ascii := strings.Repeat(`{"event":"page_view","path":"/docs/getting-started","ts":1700000000},`, 1500)
+	var escaped strings.Builder
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&escaped, "\\x%02x", byte(i*31))
+	}
+	mixed := strings.Repeat(`{"msg":"line\nbreak","tab":"\t","quote":"\'","latin1":"café"},`, 1500)
+
+	benchmarks := []struct {
+		name  string
+		input string
+	}{
+		{"ascii", ascii},
+		{"hex-escaped", escaped.String()},
+		{"mixed", mixed},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.SetBytes(int64(len(bm.input)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := decodeRawData(bm.input); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// TestDecode tests the DecodeResult fields Decode reports for representative inputs.
+func TestDecode(t *testing.T) {
+	gzipped := gzipBytes(t, `{"a":1}`)
+	tests := []struct {
+		name     string
+		command  string
+		opts     Options
+		expected DecodeResult
+	}{
+		{
+			name:    "gzipped JSON with Content-Type",
+			command: "curl 'u' -H 'Content-Type: application/json' --data-raw $'" + hexEscape(gzipped) + "'",
+			opts:    Options{Canonical: true},
+			expected: DecodeResult{Raw: gzipped, Decompressed: []byte(`{"a":1}`), Algorithm: algoGzip,
+				ContentType: "application/json", IsJSON: true, Output: []byte(`{"a":1}`)},
+		},
+		{
+			name:    "trimmed plain text",
+			command: "curl 'u' --data-raw $'  hello\\n'",
+			expected: DecodeResult{Raw: []byte("hello\n"), Decompressed: []byte("hello\n"),
+				Trimmed: true, Output: []byte("hello\n")},
+		},
+		{
+			name:    "corrupt gzip falls back",
+			command: "curl 'u' --data-raw $'\\x1f\\x8bxx'",
+			expected: DecodeResult{Raw: []byte("\x1f\x8bxx"), Decompressed: []byte("\x1f\x8bxx"),
+				Output: []byte("\x1f\x8bxx")},
+		},
+		{
+			name:    "no-decompress keeps the gzip bytes",
+			command: "curl 'u' -H 'Content-Type: application/json' --data-raw $'" + hexEscape(gzipped) + "'",
+			opts:    Options{NoDecompress: true},
+			expected: DecodeResult{Raw: gzipped, Decompressed: gzipped,
+				ContentType: "application/json", Output: gzipped},
+		},
+		{
+			name:     "emit sets only the output",
+			command:  "curl 'https://example.com'",
+			opts:     Options{Emit: "powershell"},
+			expected: DecodeResult{Output: []byte("Invoke-WebRequest -Uri 'https://example.com' -Method GET\n")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(tt.command, tt.opts)
+			if err != nil {
+				t.Fatalf("Decode(%q) returned an unexpected error: %v", tt.command, err)
+			}
+			if !reflect.DeepEqual(*got, tt.expected) {
+				t.Errorf("Decode(%q) =\n%+v\nwant\n%+v", tt.command, *got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunWindow tests that Run writes only the -offset/-length window of the body.
+func TestRunWindow(t *testing.T) {
+	gzipped := "curl 'u' --data-raw $'" + hexEscape(gzipBytes(t, "0123456789")) + "'"
+	plain := "curl 'u' --data-raw $'0123\\x004567\\n89'"
+	tests := []struct {
+		name     string
+		command  string
+		opts     Options
+		expected string
+	}{
+		{"start", gzipped, Options{Length: 3}, "b'012'"},
+		{"middle", gzipped, Options{Offset: 4, Length: 3}, "b'456'"},
+		{"offset to the end", gzipped, Options{Offset: 7}, "b'789'"},
+		{"clamped past the end", gzipped, Options{Offset: 8, Length: 10}, "b'89'"},
+		{"offset past the end", gzipped, Options{Offset: 20, Length: 2}, "b''"},
+		{"with a format", gzipped, Options{Offset: 1, Length: 2, Format: "hexstring"}, "3132"},
+		{"decode only", plain, Options{Offset: 3, Length: 3, NoDecompress: true}, "b'3\\x004'"},
+		{"decode only past the end", plain, Options{Offset: 9, Length: 5, NoDecompress: true}, "b'\\n89'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(tt.command, tt.opts)
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestDecodeRawDataLimit tests the decodeRawDataLimit function.
+func TestDecodeRawDataLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		limit    int
+		expected string
+	}{
+		{"no limit", `ab\x00cd`, -1, "ab\x00cd"},
+		{"stops in a literal run", `abcdef\x00`, 3, "abc"},
+		{"stops after an escape", `a\x00\x01bc`, 2, "a\x00"},
+		{"skips invalid bytes past the limit", "ab\xff", 2, "ab"},
+		{"limit past the end", "ab", 10, "ab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeRawDataLimit(tt.input, Options{}, tt.limit)
+			if err != nil {
+				t.Fatalf("decodeRawDataLimit(%q, %d) returned an unexpected error: %v", tt.input, tt.limit, err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("decodeRawDataLimit(%q, %d) = %q; want %q", tt.input, tt.limit, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunPostDecode tests that Run calls Options.PostDecode after decompression.
+func TestRunPostDecode(t *testing.T) {
+	command := "curl 'u' -H 'Content-Type: text/plain' --data-raw $'" + hexEscape(gzipBytes(t, "hello")) + "'"
+	hookErr := errors.New("bad key")
+	tests := []struct {
+		name          string
+		hook          func([]byte) ([]byte, error)
+		expected      string
+		expectedError error
+	}{
+		{"uppercase", func(b []byte) ([]byte, error) { return bytes.ToUpper(b), nil }, "HELLO", nil},
+		{"sees the decompressed body", func(b []byte) ([]byte, error) { return []byte(strconv.Quote(string(b))), nil }, `"hello"`, nil},
+		{"error", func([]byte) ([]byte, error) { return nil, hookErr }, "", hookErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(command, Options{PostDecode: tt.hook})
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Run() error = %v; want %v", err, tt.expectedError)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunRawInput tests that Options.RawInput decodes the whole input as the
+// content of a $'...' payload.
+func TestRunRawInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"bare escaped gzip", hexEscape(gzipBytes(t, `{"a":1}`)) + "\n", "{\n  \"a\": 1\n}"},
+		{"escaped text", `it\'s \x41\n`, "it's A\n"},
+		{"a command is just text", `curl 'u' --data-raw $'x'`, `curl 'u' --data-raw $'x'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(tt.input, Options{RawInput: true})
+			if err != nil {
+				t.Fatalf("Run(%q) returned an unexpected error: %v", tt.input, err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunPercentDecode tests that Options.PercentDecode percent-decodes the
+// body before it is interpreted.
+func TestRunPercentDecode(t *testing.T) {
+	tests := []struct {
+		name          string
+		command       string
+		percentDecode bool
+		expected      string
+		expectError   bool
+	}{
+		{"double-encoded JSON", `curl 'u' --data-raw $'%7B%22a%22%3A%22x+y%22%7D\n'`, true, "{\n  \"a\": \"x y\"\n}", false},
+		{"gzipped percent-encoded JSON", "curl 'u' --data-raw $'" + hexEscape(gzipBytes(t, "%5B1%2C2%5D")) + "'", true, "[\n  1,\n  2\n]", false},
+		{"off by default", `curl 'u' --data-raw $'%7B%7D'`, false, "%7B%7D", false},
+		{"malformed escape", `curl 'u' --data-raw $'100%'`, true, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(tt.command, Options{PercentDecode: tt.percentDecode})
+			var decodeErr *DecodeError
+			if tt.expectError {
+				if !errors.As(err, &decodeErr) {
+					t.Errorf("Run() error = %v; want a DecodeError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// FuzzDecodeRawData checks that decodeRawDataWith returns an error rather
+// than panicking on arbitrary input, in every dialect.
+func FuzzDecodeRawData(f *testing.F) {
+	for _, seed := range []string{`\x1f\x8b`, `é\U000000e9`, `\101\7`, `\x`, `\x4`, `\u12`, `\U0000`, `\`, `a\'b\"c\\`, "\xff\xfe", `\N{LATIN SMALL LETTER E}`, `\c`} {
+		f.Add(seed)
+	}
+	saved := logger
+	logger = newLogger(io.Discard, logFormatText)
+	defer func() { logger = saved }()
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, dialect := range dialectNames() {
+			for _, onInvalid := range []string{OnInvalidError, OnInvalidReplace, OnInvalidSkip} {
+				decodeRawDataWith(s, Options{Dialect: dialect, OnInvalid: onInvalid})
+			}
+		}
+	})
+}
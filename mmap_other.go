@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "errors"
+
+// mmapFile is not supported on this platform; callers fall back to os.ReadFile.
+func mmapFile(name string) (data []byte, release func() error, err error) {
+	return nil, nil, errors.New("mmapFile: memory-mapped input is not supported on this platform")
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// mmapFile maps the named file read-only into memory. The returned release
+// function unmaps it; data must not be used after release has been called.
+func mmapFile(name string) (data []byte, release func() error, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		// mmap rejects zero-length mappings; an empty file needs no mapping.
+		return []byte{}, func() error { return nil }, nil
+	}
+	if int64(int(size)) != size {
+		return nil, nil, fmt.Errorf("mmapFile: %s is too large to map (%d bytes)", name, size)
+	}
+
+	data, err = syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mmapFile: failed to map %s: %w", name, err)
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
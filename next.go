@@ -0,0 +1,51 @@
+package main
+
+import "strings"
+
+// splitCurlNext splits a cURL command at its --next (-:) options, which start
+// a new request with its own URL, method, headers and body, and returns one
+// command per request. Every command after the first gets "curl " prepended.
+// A command without --next is returned as the only element. dataFlags are the
+// custom data options of Options.DataFlags, whose values are not mistaken for
+// a --next.
+func splitCurlNext(command string, dataFlags []string) ([]string, error) {
+	tokens, err := tokenizeCurl(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) > 0 && tokens[0].Value == "curl" {
+		tokens = tokens[1:]
+	}
+	flags, _ := scanFlagsWith(tokens, withDataFlags(dataFlags))
+	var commands []string
+	start, prefix := 0, ""
+	for _, f := range flags {
+		if f.Name != "--next" {
+			continue
+		}
+		commands = append(commands, prefix+trimContinuation(command[start:f.Word.Start]))
+		start, prefix = f.Word.End, "curl "
+	}
+	return append(commands, prefix+trimContinuation(command[start:])), nil
+}
+
+// trimContinuation trims surrounding whitespace from part of a command,
+// including backslash-newline line continuations.
+func trimContinuation(s string) string {
+	for {
+		trimmed := strings.TrimLeft(s, " \t\r\n")
+		if rest, ok := strings.CutPrefix(trimmed, "\\\n"); ok {
+			s = rest
+		} else if rest, ok := strings.CutPrefix(trimmed, "\\\r\n"); ok {
+			s = rest
+		} else {
+			s = trimmed
+			break
+		}
+	}
+	trimmed := strings.TrimRight(s, " \t\r\n")
+	if strings.HasSuffix(trimmed, "\\") && strings.Contains(s[len(trimmed):], "\n") {
+		trimmed = strings.TrimRight(strings.TrimSuffix(trimmed, "\\"), " \t")
+	}
+	return trimmed
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestSplitCurlNext tests the splitCurlNext function.
+func TestSplitCurlNext(t *testing.T) {
+	tests := []struct {
+		name      string
+		command   string
+		dataFlags []string
+		expected  []string
+	}{
+		{"no --next", "curl 'a' -d 'x'", nil, []string{"curl 'a' -d 'x'"}},
+		{"two requests", "curl 'a' -H 'A: 1' -d 'x' --next 'b' -d 'y'", nil, []string{"curl 'a' -H 'A: 1' -d 'x'", "curl 'b' -d 'y'"}},
+		{"short option and continuations", "curl 'a' \\\n  -d 'x' \\\n  -: \\\n  'b' \\\n  -d 'y'", nil, []string{"curl 'a' \\\n  -d 'x'", "curl 'b' \\\n  -d 'y'"}},
+		{"--next as a data value", "curl 'a' -d --next", nil, []string{"curl 'a' -d --next"}},
+		{"--next as a custom data value", "curl 'a' --payload --next", []string{"payload"}, []string{"curl 'a' --payload --next"}},
+		{"quoted --next", "curl 'a' -H 'X: --next' -d x", nil, []string{"curl 'a' -H 'X: --next' -d x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitCurlNext(tt.command, tt.dataFlags)
+			if err != nil {
+				t.Fatalf("splitCurlNext() returned an unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("splitCurlNext(%q) = %q; want %q", tt.command, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunNext tests that Run decodes every request of a command with --next.
+func TestRunNext(t *testing.T) {
+	command := "curl 'https://a.example' -H 'Content-Type: application/json' --data-raw $'" + hexEscape(gzipBytes(t, `{"a":1}`)) + "' \\\n" +
+		"  --next 'https://b.example' -H 'Content-Type: text/plain' --data-raw 'second'"
+	got, err := Run(command, Options{})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	var entries []jsonListEntry
+	if err := json.Unmarshal(got, &entries); err != nil {
+		t.Fatalf("Run() output is not a JSON list: %v\n%s", err, got)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Run() returned %d entries; want 2:\n%s", len(entries), got)
+	}
+	var first map[string]int
+	if err := json.Unmarshal(entries[0].Output, &first); err != nil || first["a"] != 1 {
+		t.Errorf("first output = %s; want {\"a\": 1}", entries[0].Output)
+	}
+	if string(entries[1].Output) != `"second"` {
+		t.Errorf("second output = %s; want \"second\"", entries[1].Output)
+	}
+}
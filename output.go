@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// defaultOutputMode is the permission used for the output file unless -mode
+// says otherwise.
+const defaultOutputMode os.FileMode = 0644
+
+// previews receives the human-readable progress and preview lines printed
+// while decoding. It is os.Stdout unless the decoded output itself goes to
+// stdout (-output -), in which case the CLI points it at os.Stderr so the two
+// do not mix.
+var previews io.Writer = os.Stdout
+
+// stdoutOutput is the -output value that writes the decoded output to stdout.
+const stdoutOutput = "-"
+
+// parseFileMode parses an octal permission string such as "0600" or "644".
+// Only the nine permission bits are accepted; setuid, setgid and sticky bits
+// make no sense for a decoded capture and are rejected.
+func parseFileMode(s string) (os.FileMode, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty mode")
+	}
+	v, err := strconv.ParseUint(s, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("mode %q is not an octal number", s)
+	}
+	if v&^0777 != 0 {
+		return 0, fmt.Errorf("mode %q has bits outside 0777", s)
+	}
+	return os.FileMode(v), nil
+}
+
+// checkOutputPath refuses an output file that is the input file, since
+// writing the decoded data would destroy the captured command. The paths
+// are compared as absolute paths and, when both files exist, by identity, so
+// symlinks and hard links are caught too. force skips the check.
+func checkOutputPath(input, output string, force bool) error {
+	if force || output == stdoutOutput {
+		return nil
+	}
+	same := false
+	if in, err := os.Stat(input); err == nil {
+		if out, err := os.Stat(output); err == nil {
+			same = os.SameFile(in, out)
+		}
+	}
+	if absInput, err := filepath.Abs(input); err == nil {
+		if absOutput, err := filepath.Abs(output); err == nil {
+			same = same || absInput == absOutput
+		}
+	}
+	if same {
+		return fmt.Errorf("output file %s is the input file %s; refusing to overwrite it (use -force to allow it)", output, input)
+	}
+	return nil
+}
+
+// writeOutputFile writes data to name and sets its permissions to perm. The
+// explicit chmod makes perm apply even when the file already exists or the
+// umask would clear some of its bits.
+func writeOutputFile(name string, data []byte, perm os.FileMode) error {
+	if err := os.WriteFile(name, data, perm); err != nil {
+		return err
+	}
+	return os.Chmod(name, perm)
+}
+
+// writeOutputFileWith is writeOutputFile for output produced by write, as
+// with -stream. write writes to a temporary file in the same directory,
+// which replaces name only when write succeeds, so a failure part way leaves
+// an existing file as it was. The error of write is returned as is.
+func writeOutputFileWith(name string, perm os.FileMode, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, name)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// writeOutput writes data to w, e.g. os.Stdout for -output -.
+func writeOutput(w io.Writer, data []byte) error {
+	_, err := w.Write(data)
+	return err
+}
+
+// gzipOutputName returns the -output file name used with -gzip-output: name
+// with .gz appended, unless it already ends with .gz.
+func gzipOutputName(name string) string {
+	if strings.HasSuffix(name, ".gz") {
+		return name
+	}
+	return name + ".gz"
+}
+
+// gzipOutput gzips output at opts.GzipLevel for -gzip-output. Output that is
+// the body kept gzip compressed with -no-decompress is returned as it is, so
+// the original stream is saved rather than compressed twice.
+func gzipOutput(output []byte, opts Options) ([]byte, error) {
+	if algorithm, skip := detectCompression(output); opts.NoDecompress && opts.Format == "" && algorithm == algoGzip && skip == 0 {
+		logger.Info("The output is the original gzip stream (-no-decompress); writing it as is (-gzip-output).")
+		return output, nil
+	}
+	compressed, err := compressGzipData(output, opts.GzipLevel)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info(fmt.Sprintf("Gzipped the output with level %d: %d -> %d bytes.", opts.GzipLevel, len(output), len(compressed)), field("level", opts.GzipLevel), field("original_length", len(output)), field("new_length", len(compressed)))
+	return compressed, nil
+}
+
+// normalizeNewlines converts the line endings of output for -newline: with
+// NewlineLF every CRLF becomes LF, with NewlineCRLF every line ending becomes
+// CRLF, so existing CRLFs are not doubled. Lone CRs are left alone.
+func normalizeNewlines(output []byte, newline string) []byte {
+	lf := bytes.ReplaceAll(output, []byte("\r\n"), []byte("\n"))
+	switch newline {
+	case NewlineLF:
+		return lf
+	case NewlineCRLF:
+		return bytes.ReplaceAll(lf, []byte("\n"), []byte("\r\n"))
+	}
+	return output
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestParseFileMode tests the parseFileMode function.
+func TestParseFileMode(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    os.FileMode
+		expectError bool
+	}{
+		{"0644", 0644, false},
+		{"0600", 0600, false},
+		{"664", 0664, false},
+		{"0", 0, false},
+		{"", 0, true},
+		{"0649", 0, true},
+		{"rw-r--r--", 0, true},
+		{"04755", 0, true},
+		{"-600", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseFileMode(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parseFileMode(%q) error = %v; expectError %v", tt.input, err, tt.expectError)
+			}
+			if got != tt.expected {
+				t.Errorf("parseFileMode(%q) = %o; want %o", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestWriteOutputFile tests that writeOutputFile applies the mode to new and existing files.
+func TestWriteOutputFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permission bits are not supported on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "out.txt")
+	for _, perm := range []os.FileMode{0644, 0600} {
+		if err := writeOutputFile(path, []byte("data"), perm); err != nil {
+			t.Fatalf("writeOutputFile(%o) returned an unexpected error: %v", perm, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		if got := info.Mode().Perm(); got != perm {
+			t.Errorf("writeOutputFile(%o) left mode %o", perm, got)
+		}
+	}
+}
+
+// TestWriteOutputFileWith tests that writeOutputFileWith replaces the file
+// with what RunTo streams, and leaves an existing file as it was when RunTo
+// fails.
+func TestWriteOutputFileWith(t *testing.T) {
+	logger = newLogger(io.Discard, logFormatText)
+	tests := []struct {
+		name         string
+		command      string
+		expectedCode int
+		expectedFile string
+	}{
+		{"streamed", "curl 'u' --data-raw $'" + hexEscape(gzipBytes(t, `{"a":1}`)) + "'", exitOK, `{"a":1}`},
+		{"extraction fails", "curl 'u'", exitExtract, "precious"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "out.json")
+			if err := os.WriteFile(path, []byte("precious"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			err := writeOutputFileWith(path, 0600, func(w io.Writer) error {
+				return RunTo(w, tt.command, Options{Stream: true})
+			})
+			if got := exitCodeFor(err); got != tt.expectedCode {
+				t.Errorf("exitCodeFor(writeOutputFileWith()) = %d (err: %v); want %d", got, err, tt.expectedCode)
+			}
+			written, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(written) != tt.expectedFile {
+				t.Errorf("the output file holds %q; want %q", written, tt.expectedFile)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+				t.Errorf("the directory holds %d files; want only the output file", len(entries))
+			}
+		})
+	}
+}
+
+// TestCheckOutputPath tests that checkOutputPath refuses to overwrite the input file.
+func TestCheckOutputPath(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "curl_command.txt")
+	if err := os.WriteFile(input, []byte("curl 'u'"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.txt")
+	if err := os.Symlink(input, link); err != nil {
+		link = input // Symlinks may need privileges on Windows.
+	}
+	t.Chdir(dir)
+
+	tests := []struct {
+		name        string
+		output      string
+		force       bool
+		expectError bool
+	}{
+		{"same path", input, false, true},
+		{"relative path", "curl_command.txt", false, true},
+		{"unclean path", filepath.Join(dir, ".", "curl_command.txt"), false, true},
+		{"symlink", link, false, true},
+		{"forced", input, true, false},
+		{"other file", filepath.Join(dir, "decoded.txt"), false, false},
+		{"stdout", stdoutOutput, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkOutputPath(input, tt.output, tt.force)
+			if (err != nil) != tt.expectError {
+				t.Errorf("checkOutputPath(%q, %q, %v) error = %v, expectError %v", input, tt.output, tt.force, err, tt.expectError)
+			}
+		})
+	}
+}
+
+// TestRunTo tests that RunTo writes the same bytes Run returns to the writer.
+func TestRunTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		opts     Options
+		expected string
+	}{
+		{"json", `curl 'url' --data-raw $'{"a":1}'`, Options{}, "{\n  \"a\": 1\n}"},
+		{"raw format", `curl 'url' --data-raw $'\x00\xff'`, Options{Format: "hexstring"}, "00ff"},
+		{"text", `curl 'url' -H 'Content-Type: text/plain' --data-raw $'line\n'`, Options{NoTrim: true}, "line\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := RunTo(&buf, tt.command, tt.opts); err != nil {
+				t.Fatalf("RunTo() returned an unexpected error: %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("RunTo() wrote %q; want %q", buf.String(), tt.expected)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := RunTo(&buf, "curl 'url'", Options{}); err == nil || buf.Len() != 0 {
+		t.Errorf("RunTo() without a payload = (%v, %q written); want an error and nothing written", err, buf.String())
+	}
+}
+
+// TestGzipOutput tests that Options.GzipOutput writes an output file that
+// decompresses back to the expected body.
+func TestGzipOutput(t *testing.T) {
+	original := gzipBytes(t, `{"a":1}`)
+	command := "curl u --data-raw $'" + hexEscape(original) + "'"
+	tests := []struct {
+		name     string
+		opts     Options
+		expected string
+	}{
+		{"decoded body", Options{GzipOutput: true, GzipLevel: 9}, "{\n  \"a\": 1\n}"},
+		{"stored", Options{GzipOutput: true, GzipLevel: 0}, "{\n  \"a\": 1\n}"},
+		{"format", Options{GzipOutput: true, GzipLevel: 6, Format: "hexstring"}, "7b2261223a317d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), gzipOutputName("decoded.json"))
+			f, err := os.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := RunTo(f, command, tt.opts); err != nil {
+				t.Fatalf("RunTo() returned an unexpected error: %v", err)
+			}
+			f.Close()
+			written, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := decompressGzipData(written)
+			if err != nil {
+				t.Fatalf("the output file does not decompress: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("the output file decompresses to %q; want %q", got, tt.expected)
+			}
+		})
+	}
+
+	got, err := Run(command, Options{GzipOutput: true, NoDecompress: true})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, original) {
+		t.Errorf("Run() with -no-decompress = %x; want the original gzip stream %x", got, original)
+	}
+}
+
+// TestGzipOutputCombined tests that Options.GzipOutput gzips the combined
+// output of several commands once, leaving each inner result plain.
+func TestGzipOutputCombined(t *testing.T) {
+	nested := "curl 'https://inner.example' --data-raw $'{\"b\":2}'"
+	tests := []struct {
+		name     string
+		command  string
+		opts     Options
+		expected string
+	}{
+		{"--next", `curl 'https://a.example' --data-raw $'{"a":1}' --next 'https://b.example' --data-raw $'{"b":2}'`, Options{GzipOutput: true}, `"b": 2`},
+		{"-recurse", "curl 'https://outer.example' --data-raw $'" + hexEscape([]byte(nested)) + "'", Options{GzipOutput: true, Recurse: 1}, "--- nested curl command (depth 1) ---\n{\n  \"b\": 2\n}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := Run(tt.command, tt.opts)
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			got, err := decompressGzipData(output)
+			if err != nil {
+				t.Fatalf("the output does not decompress: %v", err)
+			}
+			if !strings.Contains(string(got), tt.expected) {
+				t.Errorf("the output decompresses to %q; want it to contain %q", got, tt.expected)
+			}
+			if bytes.Contains(got, []byte{0x1f, 0x8b}) {
+				t.Errorf("the output decompresses to %q; want no gzipped inner results", got)
+			}
+		})
+	}
+}
+
+// TestGzipOutputName tests the gzipOutputName function.
+func TestGzipOutputName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"decoded.json", "decoded.json.gz"},
+		{"decoded", "decoded.gz"},
+		{"decoded.json.gz", "decoded.json.gz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := gzipOutputName(tt.input); got != tt.expected {
+				t.Errorf("gzipOutputName(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestNormalizeNewlines tests the normalizeNewlines function.
+func TestNormalizeNewlines(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		newline  string
+		expected string
+	}{
+		{"CRLF to LF", "a\r\nb\r\nc", NewlineLF, "a\nb\nc"},
+		{"LF to CRLF", "a\nb\nc\n", NewlineCRLF, "a\r\nb\r\nc\r\n"},
+		{"mixed to CRLF", "a\r\nb\nc", NewlineCRLF, "a\r\nb\r\nc"},
+		{"lone CR kept", "a\rb\r\n", NewlineLF, "a\rb\n"},
+		{"keep", "a\r\nb\n", NewlineKeep, "a\r\nb\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeNewlines([]byte(tt.input), tt.newline); string(got) != tt.expected {
+				t.Errorf("normalizeNewlines(%q, %q) = %q; want %q", tt.input, tt.newline, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunNewline tests that -newline converts the line endings of a
+// multiline text body but leaves binary output alone.
+func TestRunNewline(t *testing.T) {
+	binary := "\\x00\\x01\\r\\n\\x02"
+	tests := []struct {
+		name     string
+		payload  string
+		newline  string
+		expected string
+	}{
+		{"CRLF to LF", `line one\r\nline two\r\nline three`, NewlineLF, "line one\nline two\nline three"},
+		{"LF to CRLF", `line one\nline two\nline three`, NewlineCRLF, "line one\r\nline two\r\nline three"},
+		{"keep", `line one\r\nline two`, NewlineKeep, "line one\r\nline two"},
+		{"binary untouched", binary, NewlineLF, "\x00\x01\r\n\x02"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run("curl 'u' -H 'Content-Type: text/plain' --data-raw $'"+tt.payload+"'", Options{Newline: tt.newline})
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
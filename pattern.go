@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxPatternOffsets caps how many offsets of -count-pattern matches are
+// reported; the count itself is never capped.
+const maxPatternOffsets = 20
+
+// parsePattern parses a -count-pattern value: "0x" followed by hex digits
+// for arbitrary bytes (0x0d0a), or a string matched as is. A string wrapped
+// in double quotes is unquoted first, so "0x00" looks for those four
+// characters.
+func parsePattern(s string) ([]byte, error) {
+	switch {
+	case len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"':
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %s is not a valid quoted string: %w", s, err)
+		}
+		s = unquoted
+	case strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X"):
+		pattern, err := hex.DecodeString(s[2:])
+		if err != nil {
+			return nil, fmt.Errorf("pattern %s is not valid hex: %w", s, err)
+		}
+		s = string(pattern)
+	}
+	if s == "" {
+		return nil, errors.New("empty pattern")
+	}
+	return []byte(s), nil
+}
+
+// findPattern counts the occurrences of pattern in data, overlapping ones
+// included ("aa" occurs 3 times in "aaaa"), and returns the offsets of the
+// first maxOffsets of them.
+func findPattern(data, pattern []byte, maxOffsets int) (count int, offsets []int) {
+	for start := 0; ; {
+		i := bytes.Index(data[start:], pattern)
+		if i < 0 {
+			return count, offsets
+		}
+		if count < maxOffsets {
+			offsets = append(offsets, start+i)
+		}
+		count++
+		start += i + 1
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// TestParsePattern tests the parsePattern function.
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    []byte
+		expectError bool
+	}{
+		{"string", "\r\n", []byte("\r\n"), false},
+		{"hex", "0x0d0A", []byte{0x0d, 0x0a}, false},
+		{"capital hex prefix", "0X00ff", []byte{0x00, 0xff}, false},
+		{"quoted string", `"0x00"`, []byte("0x00"), false},
+		{"quoted string with escapes", `"a\tb"`, []byte("a\tb"), false},
+		{"odd hex digits", "0x123", nil, true},
+		{"invalid hex", "0xzz", nil, true},
+		{"empty hex", "0x", nil, true},
+		{"empty quoted string", `""`, nil, true},
+		{"bad quoted string", `"a\qb"`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePattern(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("parsePattern(%q) error = %v, expectError %v", tt.input, err, tt.expectError)
+			}
+			if !bytes.Equal(got, tt.expected) {
+				t.Errorf("parsePattern(%q) = %q; want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestFindPattern tests the findPattern function.
+func TestFindPattern(t *testing.T) {
+	tests := []struct {
+		name            string
+		data            string
+		pattern         string
+		maxOffsets      int
+		expectedCount   int
+		expectedOffsets []int
+	}{
+		{"non-overlapping", "a,b,,c", ",", 10, 3, []int{1, 3, 4}},
+		{"overlapping", "aaaa", "aa", 10, 3, []int{0, 1, 2}},
+		{"overlapping delimiter", "\r\n\r\n\r\n", "\r\n\r\n", 10, 2, []int{0, 2}},
+		{"binary", "\x00\x01\x00\x01", "\x00\x01", 10, 2, []int{0, 2}},
+		{"offsets capped", "xxxxx", "x", 2, 5, []int{0, 1}},
+		{"no match", "abc", "d", 10, 0, nil},
+		{"pattern longer than data", "ab", "abc", 10, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, offsets := findPattern([]byte(tt.data), []byte(tt.pattern), tt.maxOffsets)
+			if count != tt.expectedCount || !reflect.DeepEqual(offsets, tt.expectedOffsets) {
+				t.Errorf("findPattern(%q, %q) = %d, %v; want %d, %v", tt.data, tt.pattern, count, offsets, tt.expectedCount, tt.expectedOffsets)
+			}
+		})
+	}
+}
+
+// TestDecodeCountPattern tests that Decode counts Options.CountPattern in the
+// decompressed body.
+func TestDecodeCountPattern(t *testing.T) {
+	command := "curl u --data-raw $'" + hexEscape(gzipBytes(t, "a|b|c||")) + "'"
+	res, err := Decode(command, Options{CountPattern: []byte("|")})
+	if err != nil {
+		t.Fatalf("Decode() returned an unexpected error: %v", err)
+	}
+	if res.PatternCount != 4 || !reflect.DeepEqual(res.PatternOffsets, []int{1, 3, 5, 6}) {
+		t.Errorf("Decode() counted %d at %v; want 4 at [1 3 5 6]", res.PatternCount, res.PatternOffsets)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultMaxDepth is the Options.MaxDepth used when none is set.
+const defaultMaxDepth = 8
+
+// maxDepth returns the depth limit of the recursive steps for opts.
+func maxDepth(opts Options) int {
+	if opts.MaxDepth > 0 {
+		return opts.MaxDepth
+	}
+	return defaultMaxDepth
+}
+
+// nestedCurlCommand returns the curl command in body when the body itself is
+// one that sends data, as when a captured request carries another request to
+// be replayed by a proxy.
+func nestedCurlCommand(body []byte) (string, bool) {
+	command, _, err := findCurlCommand(string(body))
+	if err != nil {
+		return "", false
+	}
+	tokens, err := tokenizeCurl(command)
+	if err != nil || len(tokens) == 0 {
+		return "", false
+	}
+	flags, _ := scanFlags(tokens[1:])
+	for _, f := range flags {
+		if curlDataFlags[f.Name] && f.HasValue {
+			return command, true
+		}
+	}
+	return "", false
+}
+
+// decodeNested decodes the curl commands nested in res's body, up to
+// opts.Recurse levels deep, linking each result to its parent's Nested and
+// appending its output to res.Output after a "--- nested curl command
+// (depth N) ---" line. A nested command that fails to decode is reported and
+// ends the recursion without failing the outer one; going past the maximum
+// depth, when -recurse allows more levels, fails with a DepthError.
+func decodeNested(res *DecodeResult, opts Options) error {
+	inner := opts
+	inner.Recurse, inner.InputFormat, inner.URLDecodeInput, inner.FindCurl, inner.RawInput = 0, "", false, false, false
+	parent := res
+	for depth := 1; ; depth++ {
+		command, ok := nestedCurlCommand(parent.Decompressed)
+		if !ok {
+			return nil
+		}
+		if limit := maxDepth(opts); depth > limit && depth <= opts.Recurse {
+			return &DepthError{Feature: "-recurse", Limit: limit}
+		}
+		if depth > opts.Recurse {
+			logger.Warn(fmt.Sprintf("The body at depth %d is another curl command; not decoding it past -recurse %d.", depth-1, opts.Recurse), field("depth", depth-1))
+			return nil
+		}
+		logger.Info(fmt.Sprintf("The body at depth %d is a curl command. Decoding it.", depth-1), field("depth", depth-1))
+		nested, err := decode(command, inner) // -gzip-output applies to the combined output only.
+		if err != nil {
+			logger.Warn(fmt.Sprintf("Could not decode the nested curl command at depth %d: %v", depth, err), field("depth", depth), field("error", err))
+			return nil
+		}
+		parent.Nested = nested
+		if len(res.Output) > 0 && !strings.HasSuffix(string(res.Output), "\n") {
+			res.Output = append(res.Output, '\n')
+		}
+		res.Output = append(res.Output, fmt.Sprintf("--- nested curl command (depth %d) ---\n", depth)...)
+		res.Output = append(res.Output, nested.Output...)
+		parent = nested
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestRunRecurse tests the -recurse option of Decode.
+func TestRunRecurse(t *testing.T) {
+	inner := "curl 'https://inner.example' -H 'Content-Type: application/json' --data-raw $'" + hexEscape(gzipBytes(t, `{"a":1}`)) + "'"
+	middle := "curl 'https://middle.example' --data-binary $'" + hexEscape(gzipBytes(t, inner)) + "'"
+	outer := "curl 'https://outer.example' --data-raw $'" + hexEscape(gzipBytes(t, middle)) + "'"
+	tests := []struct {
+		name           string
+		recurse        int
+		expectedDepth  int
+		expectedOutput []string
+	}{
+		{"disabled", 0, 0, nil},
+		{"one level", 1, 1, []string{"--- nested curl command (depth 1) ---\ncurl 'https://inner.example'"}},
+		{"all levels", 5, 2, []string{"--- nested curl command (depth 1) ---\n", "--- nested curl command (depth 2) ---\n{\n  \"a\": 1\n}"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Decode(outer, Options{Recurse: tt.recurse})
+			if err != nil {
+				t.Fatalf("Decode() returned an unexpected error: %v", err)
+			}
+			depth := 0
+			for r := res.Nested; r != nil; r = r.Nested {
+				depth++
+			}
+			if depth != tt.expectedDepth {
+				t.Errorf("Decode() nested %d levels deep; want %d", depth, tt.expectedDepth)
+			}
+			if !strings.HasPrefix(string(res.Output), "curl 'https://middle.example'") {
+				t.Errorf("Decode() output = %q; want it to start with the outer body", res.Output)
+			}
+			for _, want := range tt.expectedOutput {
+				if !strings.Contains(string(res.Output), want) {
+					t.Errorf("Decode() output = %q; want it to contain %q", res.Output, want)
+				}
+			}
+			if tt.recurse == 0 && strings.Contains(string(res.Output), "--- nested") {
+				t.Errorf("Decode() output = %q; want no nested results", res.Output)
+			}
+		})
+	}
+}
+
+// TestRunRecurseRawInput tests that -raw-input applies to the outer input
+// only, so a nested curl command is decoded as a command.
+func TestRunRecurseRawInput(t *testing.T) {
+	res, err := Decode(`curl \'https://inner.example\' --data-raw $\'hello\'`, Options{RawInput: true, Recurse: 1})
+	if err != nil {
+		t.Fatalf("Decode() returned an unexpected error: %v", err)
+	}
+	if res.Nested == nil {
+		t.Fatalf("Decode() output = %q; want a nested result", res.Output)
+	}
+	if got := string(res.Nested.Decompressed); got != "hello" {
+		t.Errorf("Decode() nested body = %q; want %q", got, "hello")
+	}
+}
+
+// TestNestedCurlCommand tests the nestedCurlCommand function.
+func TestNestedCurlCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected bool
+	}{
+		{"curl with data", "curl https://a -d 'x=1'", true},
+		{"curl with data-raw after a prompt", "$ curl https://a --data-raw $'x'", true},
+		{"curl without data", "curl https://a -H 'A: b'", false},
+		{"json mentioning curl", `{"tool":"curl","data":"-d x"}`, false},
+		{"plain text", "hello", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := nestedCurlCommand([]byte(tt.body)); got != tt.expected {
+				t.Errorf("nestedCurlCommand(%q) = %v; want %v", tt.body, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunMaxDepth tests that Options.MaxDepth stops the recursive steps with
+// a DepthError naming the option that went too deep.
+func TestRunMaxDepth(t *testing.T) {
+	inner := "curl 'https://inner.example' --data-raw $'x'"
+	outer := "curl 'https://outer.example' --data-raw $'" + hexEscape(gzipBytes(t, "curl 'https://middle.example' -d $'"+hexEscape([]byte(inner))+"'")) + "'"
+	wrapped := `curl 'u' --data-raw $'"\\"{\\\\\\"a\\\\\\":1}\\""'`
+	tests := []struct {
+		name            string
+		command         string
+		opts            Options
+		expectedFeature string
+	}{
+		{"recurse within the limit", outer, Options{Recurse: 5, MaxDepth: 2}, ""},
+		{"recurse past the limit", outer, Options{Recurse: 5, MaxDepth: 1}, "-recurse"},
+		{"recurse stopped by -recurse first", outer, Options{Recurse: 1, MaxDepth: 1}, ""},
+		{"unwrap within the limit", wrapped, Options{UnwrapJSONString: true, MaxDepth: 2}, ""},
+		{"unwrap past the limit", wrapped, Options{UnwrapJSONString: true, MaxDepth: 1}, "-unwrap-json-string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.command, tt.opts)
+			var depthErr *DepthError
+			if tt.expectedFeature == "" {
+				if err != nil {
+					t.Fatalf("Decode() returned an unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &depthErr) {
+				t.Fatalf("Decode() error = %v; want a DepthError", err)
+			}
+			if depthErr.Feature != tt.expectedFeature || depthErr.Limit != tt.opts.MaxDepth {
+				t.Errorf("Decode() error = %+v; want feature %s and limit %d", depthErr, tt.expectedFeature, tt.opts.MaxDepth)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxREPLLine bounds a single line read by runREPL; pasted commands with
+// large bodies easily exceed bufio.Scanner's 64 KiB default.
+const maxREPLLine = 64 << 20
+
+// runREPL reads commands from in, each ended by a blank line or EOF so that
+// multi-line pastes with backslash continuations work, decodes each with Run
+// and writes the result to out followed by a separator line. A failing
+// command is reported on out and does not end the loop; only a read error
+// does.
+func runREPL(in io.Reader, out io.Writer, opts Options) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxREPLLine)
+	var lines []string
+	decode := func() {
+		command := strings.TrimSpace(strings.Join(lines, "\n"))
+		lines = lines[:0]
+		if command == "" {
+			return
+		}
+		output, err := Run(command, opts)
+		if err != nil {
+			fmt.Fprintf(out, "error (exit code %d): %v\n", exitCodeFor(err), err)
+		} else {
+			out.Write(output)
+			if len(output) > 0 && output[len(output)-1] != '\n' {
+				io.WriteString(out, "\n")
+			}
+		}
+		io.WriteString(out, "---\n")
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			decode()
+			continue
+		}
+		lines = append(lines, line)
+	}
+	decode()
+	return scanner.Err()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// TestRunREPL tests the runREPL function.
+func TestRunREPL(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "one command per paragraph",
+			input:    "curl 'u' --data-raw $'{\"a\":1}'\n\ncurl 'u' -H 'Content-Type: text/plain' --data-raw $'hi'\n",
+			expected: "{\n  \"a\": 1\n}\n---\nhi\n---\n",
+		},
+		{
+			name:     "multi-line paste with continuations",
+			input:    "curl 'u' \\\n  -H 'Content-Type: text/plain' \\\n  --data-raw $'multi'\n\n",
+			expected: "multi\n---\n",
+		},
+		{
+			name:     "errors do not stop the loop",
+			input:    "curl 'u'\n\n\n\ncurl 'u' -H 'Content-Type: text/plain' --data-raw 'ok'",
+			expected: "error (exit code 2): extraction failed: failed to extract data-raw part\n---\nok\n---\n",
+		},
+		{name: "empty input", input: "\n \n", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := runREPL(strings.NewReader(tt.input), &out, Options{}); err != nil {
+				t.Fatalf("runREPL() returned an unexpected error: %v", err)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("runREPL() wrote %q; want %q", out.String(), tt.expected)
+			}
+		})
+	}
+
+	readErr := errors.New("boom")
+	if err := runREPL(iotest.ErrReader(readErr), &bytes.Buffer{}, Options{}); !errors.Is(err, readErr) {
+		t.Errorf("runREPL() with a failing reader error = %v; want %v", err, readErr)
+	}
+}
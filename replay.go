@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultRetryDelay is the delay before the first retry of a replayed request;
+// it doubles with every further attempt.
+const defaultRetryDelay = time.Second
+
+// replayClient sends replayed requests. It does not follow redirects, so the
+// response to the captured request itself is reported.
+var replayClient = &http.Client{
+	Timeout: 60 * time.Second,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// runReplay sends the request described by curlCommand and returns the
+// response body, decompressed according to its Content-Encoding and then
+// interpreted by its Content-Type like a captured body. The --cert, --key,
+// --cacert and -k options of a cURL command configure TLS.
+func runReplay(curlCommand string, opts Options) ([]byte, error) {
+	r, err := parseCommand(curlCommand, opts)
+	if err != nil {
+		return nil, err
+	}
+	req, err := r.ToHTTPRequest()
+	if err != nil {
+		return nil, &ExtractError{Err: err}
+	}
+	client := replayClient
+	if opts.InputFormat == "" || opts.InputFormat == inputFormatCurl {
+		tokens, err := tokenizeCurl(curlCommand)
+		if err != nil {
+			return nil, &ExtractError{Err: err}
+		}
+		tlsOpts, err := extractTLSOptions(tokens)
+		if err != nil {
+			return nil, &ExtractError{Err: err}
+		}
+		if tlsOpts.Insecure {
+			logger.Warn("The command uses -k/--insecure; the server certificate is not verified.")
+		}
+		if client, err = replayClientFor(tlsOpts); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := doWithRetry(client, req, opts.Retries, opts.RetryDelay)
+	if err != nil {
+		return nil, fmt.Errorf("replaying %s %s: %w", req.Method, req.URL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading the %s response body: %w", req.URL, err)
+	}
+	fmt.Fprintf(previews, "Replayed %s %s: %s (%d bytes)\n", req.Method, req.URL, resp.Status, len(body))
+
+	switch encoding := resp.Header.Get("Content-Encoding"); encoding {
+	case "gzip", "x-gzip", "deflate":
+		algorithm := algoGzip
+		if encoding == "deflate" {
+			algorithm = algoDeflate
+		}
+		decompressed, err := decompressData(algorithm, body)
+		if err != nil {
+			return nil, &DecompressError{Err: err}
+		}
+		body = decompressed
+	}
+	return interpretBody(resp.Header.Get("Content-Type"), body, opts)
+}
+
+// doWithRetry sends req, retrying up to retries more times on connection
+// errors and 5xx responses. The delay before the first retry is delay and
+// doubles for each following one. The request is reused; its body is rebuilt
+// from GetBody for every attempt. The last response is returned even if it is
+// a 5xx; only a connection error on the final attempt is an error.
+func doWithRetry(client *http.Client, req *http.Request, retries int, delay time.Duration) (*http.Response, error) {
+	attempts := retries + 1
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("rebuilding the request body: %w", err)
+			}
+			req.Body = body
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			logger.Info(fmt.Sprintf("Replay attempt %d/%d failed: %v", attempt, attempts, err), field("attempt", attempt), field("error", err))
+		} else {
+			logger.Info(fmt.Sprintf("Replay attempt %d/%d: %s", attempt, attempts, resp.Status), field("attempt", attempt), field("status", resp.StatusCode))
+			if resp.StatusCode < 500 {
+				return resp, nil
+			}
+		}
+		if attempt >= attempts {
+			if err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
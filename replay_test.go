@@ -0,0 +1,114 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestDoWithRetry tests that doWithRetry retries 5xx responses and connection
+// errors with a rebuilt body, and gives up after the configured retries.
+func TestDoWithRetry(t *testing.T) {
+	tests := []struct {
+		name             string
+		failures         int // Number of 503 responses before a 200.
+		retries          int
+		expectedStatus   int
+		expectedAttempts int
+	}{
+		{"success first time", 0, 2, http.StatusOK, 1},
+		{"recovers after 5xx", 2, 2, http.StatusOK, 3},
+		{"gives up and returns last 5xx", 3, 1, http.StatusServiceUnavailable, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu     sync.Mutex
+				bodies []string
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				mu.Lock()
+				bodies = append(bodies, string(body))
+				n := len(bodies)
+				mu.Unlock()
+				if n <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Write([]byte("ok"))
+			}))
+			defer server.Close()
+
+			r := &Request{Method: "POST", URL: server.URL, Body: []byte(`{"a":1}`)}
+			req, err := r.ToHTTPRequest()
+			if err != nil {
+				t.Fatalf("ToHTTPRequest() returned an unexpected error: %v", err)
+			}
+			resp, err := doWithRetry(server.Client(), req, tt.retries, time.Millisecond)
+			if err != nil {
+				t.Fatalf("doWithRetry() returned an unexpected error: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("doWithRetry() status = %d; want %d", resp.StatusCode, tt.expectedStatus)
+			}
+			if len(bodies) != tt.expectedAttempts {
+				t.Fatalf("server saw %d attempts; want %d", len(bodies), tt.expectedAttempts)
+			}
+			for i, body := range bodies {
+				if body != `{"a":1}` {
+					t.Errorf("attempt %d sent body %q; want the full body", i+1, body)
+				}
+			}
+		})
+	}
+}
+
+// TestDoWithRetryConnectionError tests that connection errors are retried and reported after the last attempt.
+func TestDoWithRetryConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	start := time.Now()
+	if _, err := doWithRetry(http.DefaultClient, req, 2, 5*time.Millisecond); err == nil {
+		t.Fatal("doWithRetry() against a closed server should have returned an error")
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("doWithRetry() took %v; want at least the 5ms+10ms backoff", elapsed)
+	}
+}
+
+// TestRunReplay tests that Run replays the request and decodes the compressed response.
+func TestRunReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != "POST" || r.Header.Get("X-Token") != "t" || string(body) != "q=1" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(gzipBytes(t, `{"ok":true}`))
+	}))
+	defer server.Close()
+
+	command := "curl '" + server.URL + "' -H 'X-Token: t' -H 'Accept-Encoding: gzip' --data-raw 'q=1'"
+	got, err := Run(command, Options{Replay: true, Canonical: true})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if !strings.Contains(string(got), `{"ok":true}`) {
+		t.Errorf("Run() = %q; want the decoded response", got)
+	}
+}
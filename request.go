@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Header is a single request header.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Headers is an ordered list of request headers as they appeared in the
+// command. Names may repeat.
+type Headers []Header
+
+// Get returns the value of the first header with the given name, compared
+// case-insensitively, or "" if there is none.
+func (h Headers) Get(name string) string {
+	for _, hdr := range h {
+		if strings.EqualFold(hdr.Name, name) {
+			return hdr.Value
+		}
+	}
+	return ""
+}
+
+// Values returns the values of all headers with the given name, compared case-insensitively.
+func (h Headers) Values(name string) []string {
+	var values []string
+	for _, hdr := range h {
+		if strings.EqualFold(hdr.Name, name) {
+			values = append(values, hdr.Value)
+		}
+	}
+	return values
+}
+
+// Request is the HTTP request described by a cURL command.
+type Request struct {
+	Method  string
+	URL     string
+	Headers Headers
+	// Body is the decoded (but not decompressed) request body; nil when the
+	// command sends no data.
+	Body []byte
+}
+
+// parseCurl parses a cURL command into a Request. Bodies given as $'...' are
+// decoded with decodeRawDataWith using opts; several data options, including
+// the custom ones in opts.DataFlags, are joined with '&' as cURL does. The
+// method is the -X one, else HEAD for -I/--head, else POST when there is a
+// body and GET otherwise.
+func parseCurl(command string, opts Options) (*Request, error) {
+	tokens, err := tokenizeCurl(command)
+	if err != nil {
+		return nil, err
+	}
+	// With custom data options the command usually starts with a wrapper's
+	// name rather than curl's.
+	if len(tokens) > 0 && (tokens[0].Value == "curl" || len(opts.DataFlags) > 0 && !strings.HasPrefix(tokens[0].Value, "-")) {
+		tokens = tokens[1:]
+	}
+	isData := withDataFlags(opts.DataFlags)
+	flags, positional := scanFlagsWith(tokens, isData)
+
+	r := &Request{}
+	if len(positional) > 0 {
+		r.URL = positional[0].Value
+	}
+	var bodyParts [][]byte
+	head := false
+	for _, f := range flags {
+		if (curlValueFlags[f.Name] || isData[f.Name]) && !f.HasValue {
+			return nil, fmt.Errorf("parseCurl: option %s is missing its value", f.Name)
+		}
+		value := f.Value.Value
+
+		switch {
+		case isData[f.Name]:
+			part := []byte(value)
+			if f.Value.ANSIC {
+				part, err = decodeRawDataWith(value, opts)
+				if err != nil {
+					return nil, &DecodeError{Err: err}
+				}
+			}
+			bodyParts = append(bodyParts, part)
+		case f.Name == "--head":
+			head = true
+		case f.Name == "--request":
+			r.Method = strings.ToUpper(value)
+		case f.Name == "--header":
+			if r.Headers, err = r.Headers.appendLine(value); err != nil {
+				return nil, fmt.Errorf("parseCurl: %w", err)
+			}
+		case f.Name == "--cookie":
+			r.Headers = append(r.Headers, Header{Name: "Cookie", Value: value})
+		case f.Name == "--user-agent":
+			r.Headers = append(r.Headers, Header{Name: "User-Agent", Value: value})
+		case f.Name == "--referer":
+			r.Headers = append(r.Headers, Header{Name: "Referer", Value: value})
+		case f.Name == "--url":
+			r.URL = value
+		}
+	}
+
+	if r.URL == "" {
+		return nil, fmt.Errorf("parseCurl: no URL found in command")
+	}
+	if bodyParts != nil {
+		r.Body = joinBodyParts(bodyParts)
+	}
+	if r.Method == "" {
+		switch {
+		case head:
+			r.Method = http.MethodHead
+		case r.Body != nil:
+			r.Method = "POST"
+		default:
+			r.Method = "GET"
+		}
+	}
+	return r, nil
+}
+
+// Origin returns the Origin header of r, which browsers send with CORS and
+// POST requests, or "" if there is none.
+func (r *Request) Origin() string {
+	return r.Headers.Get("Origin")
+}
+
+// Referer returns the Referer header of r, including one set with -e or
+// --referer, or "" if there is none.
+func (r *Request) Referer() string {
+	return r.Headers.Get("Referer")
+}
+
+// ToHTTPRequest builds the net/http request for r. Headers keep their order
+// and repeats; a Host header sets the request's Host and Content-Length is left
+// to the transport. The body is a bytes.Reader, so GetBody can rebuild it for
+// every attempt of a retried request. A HEAD request is sent without a body.
+func (r *Request) ToHTTPRequest() (*http.Request, error) {
+	var body io.Reader
+	if r.Body != nil && r.Method != http.MethodHead {
+		body = bytes.NewReader(r.Body)
+	}
+	req, err := http.NewRequest(r.Method, r.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range r.Headers {
+		switch {
+		case strings.EqualFold(h.Name, "Host"):
+			req.Host = h.Value
+		case strings.EqualFold(h.Name, "Content-Length"):
+		default:
+			req.Header.Add(h.Name, h.Value)
+		}
+	}
+	return req, nil
+}
+
+// joinBodyParts joins the values of several data options with '&', as cURL does.
+func joinBodyParts(parts [][]byte) []byte {
+	body := []byte{}
+	for i, part := range parts {
+		if i > 0 {
+			body = append(body, '&')
+		}
+		body = append(body, part...)
+	}
+	return body
+}
+
+// parseHeaderLine parses a "Name: value" header as given to -H.
+func parseHeaderLine(line string) (Header, error) {
+	name, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return Header{}, fmt.Errorf("malformed header %q", line)
+	}
+	return Header{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)}, nil
+}
+
+// appendLine parses a header line and appends it to h. A line starting with a
+// space or tab continues the previous header, as in RFC 7230 obsolete line
+// folding, and is joined to its value with a single space.
+func (h Headers) appendLine(line string) (Headers, error) {
+	if len(h) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+		if cont := strings.TrimSpace(line); cont != "" {
+			last := &h[len(h)-1]
+			if last.Value == "" {
+				last.Value = cont
+			} else {
+				last.Value += " " + cont
+			}
+		}
+		return h, nil
+	}
+	hdr, err := parseHeaderLine(line)
+	if err != nil {
+		return h, err
+	}
+	return append(h, hdr), nil
+}
+
+// extractHeaders returns the -H/--header values of a cURL command, folding
+// continuation values into the previous header, without decoding its body, so it also works for commands parseCurl would reject.
+func extractHeaders(command string) (Headers, error) {
+	tokens, err := tokenizeCurl(command)
+	if err != nil {
+		return nil, err
+	}
+	flags, _ := scanFlags(tokens)
+	var headers Headers
+	for _, f := range flags {
+		if f.Name != "--header" || !f.HasValue {
+			continue
+		}
+		if headers, err = headers.appendLine(f.Value.Value); err != nil {
+			return nil, err
+		}
+	}
+	return headers, nil
+}
+
+// checkContentLength compares the Content-Length header in headers, if there
+// is one, with the length of the decoded (still compressed) body and describes
+// the disagreement, which usually means a truncated capture. It returns "" when
+// they agree or there is no header.
+func checkContentLength(headers Headers, body []byte) string {
+	declared := headers.Get("Content-Length")
+	if declared == "" {
+		return ""
+	}
+	n, err := strconv.ParseInt(declared, 10, 64)
+	if err != nil || n < 0 {
+		return fmt.Sprintf("Content-Length %q is not a valid length; the body is %d bytes", declared, len(body))
+	}
+	if n != int64(len(body)) {
+		return fmt.Sprintf("Content-Length declares %d bytes but the decoded body is %d bytes; the capture may be truncated", n, len(body))
+	}
+	return ""
+}
+
+// applyContentLength runs checkContentLength on a decoded body: with
+// opts.StrictLength a mismatch is an ExtractError, otherwise it is added to
+// res.Warnings and logged.
+func applyContentLength(res *DecodeResult, headers Headers, body []byte, opts Options) error {
+	mismatch := checkContentLength(headers, body)
+	if mismatch == "" {
+		return nil
+	}
+	if opts.StrictLength {
+		return &ExtractError{Err: errors.New(mismatch)}
+	}
+	res.Warnings = append(res.Warnings, mismatch)
+	logger.Warn(mismatch, field("declared_length", headers.Get("Content-Length")), field("length", len(body)))
+	return nil
+}
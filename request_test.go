@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// TestHeadersGet tests the Headers.Get and Headers.Values methods.
+func TestHeadersGet(t *testing.T) {
+	h := Headers{{"Accept", "a"}, {"X-Multi", "1"}, {"x-multi", "2"}}
+	tests := []struct {
+		name           string
+		header         string
+		expectedGet    string
+		expectedValues []string
+	}{
+		{"exact case", "Accept", "a", []string{"a"}},
+		{"other case", "ACCEPT", "a", []string{"a"}},
+		{"repeated", "X-Multi", "1", []string{"1", "2"}},
+		{"missing", "Cookie", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.Get(tt.header); got != tt.expectedGet {
+				t.Errorf("Get(%q) = %q; want %q", tt.header, got, tt.expectedGet)
+			}
+			if got := h.Values(tt.header); !reflect.DeepEqual(got, tt.expectedValues) {
+				t.Errorf("Values(%q) = %q; want %q", tt.header, got, tt.expectedValues)
+			}
+		})
+	}
+}
+
+// TestRequestOriginReferer tests the Request.Origin and Request.Referer
+// methods on commands as copied from browsers.
+func TestRequestOriginReferer(t *testing.T) {
+	tests := []struct {
+		name            string
+		command         string
+		expectedOrigin  string
+		expectedReferer string
+	}{
+		{"chrome headers", `curl 'https://api.example.com/v1/events' -H 'origin: https://www.example.com' -H 'referer: https://www.example.com/checkout' --data-raw $'{}'`, "https://www.example.com", "https://www.example.com/checkout"},
+		{"referer option", `curl 'https://api.example.com/' -e 'https://www.example.com/'`, "", "https://www.example.com/"},
+		{"opaque origin", `curl 'https://api.example.com/' -H 'Origin: null'`, "null", ""},
+		{"neither", `curl 'https://api.example.com/'`, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := parseCurl(tt.command, Options{})
+			if err != nil {
+				t.Fatalf("parseCurl() returned an unexpected error: %v", err)
+			}
+			if got := r.Origin(); got != tt.expectedOrigin {
+				t.Errorf("Origin() = %q; want %q", got, tt.expectedOrigin)
+			}
+			if got := r.Referer(); got != tt.expectedReferer {
+				t.Errorf("Referer() = %q; want %q", got, tt.expectedReferer)
+			}
+		})
+	}
+}
+
+// TestHeadersAppendLine tests the Headers.appendLine method.
+func TestHeadersAppendLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected Headers
+	}{
+		{"single", []string{"Accept: a"}, Headers{{"Accept", "a"}}},
+		{"folded content type", []string{"Content-Type: text/plain;", " charset=utf-8"}, Headers{{"Content-Type", "text/plain; charset=utf-8"}}},
+		{"tab continuation", []string{"X-Long: a", "\tb", "  c"}, Headers{{"X-Long", "a b c"}}},
+		{"folds into the last header", []string{"Accept: a", "Content-Type: text/plain;", " charset=utf-8"}, Headers{{"Accept", "a"}, {"Content-Type", "text/plain; charset=utf-8"}}},
+		{"empty value", []string{"X-Empty:", " later"}, Headers{{"X-Empty", "later"}}},
+		{"leading space without a previous header", []string{" Accept: a"}, Headers{{"Accept", "a"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Headers
+			for _, line := range tt.lines {
+				var err error
+				if got, err = got.appendLine(line); err != nil {
+					t.Fatalf("appendLine(%q) returned an unexpected error: %v", line, err)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("appendLine(%q) = %q; want %q", tt.lines, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestParseCurl tests the parseCurl function.
+func TestParseCurl(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		expected    *Request
+		expectError bool
+	}{
+		{
+			name:     "simple GET",
+			command:  "curl 'https://example.com/a?b=1' -H 'Accept: */*' --compressed",
+			expected: &Request{Method: "GET", URL: "https://example.com/a?b=1", Headers: Headers{{"Accept", "*/*"}}},
+		},
+		{
+			name:     "ANSI-C body implies POST",
+			command:  "curl 'https://example.com' \\\n  -H 'Content-Type: text/plain' \\\n  --data-raw $'a\\nb\\x41'",
+			expected: &Request{Method: "POST", URL: "https://example.com", Headers: Headers{{"Content-Type", "text/plain"}}, Body: []byte("a\nbA")},
+		},
+		{
+			name:     "explicit method and cookie",
+			command:  "curl -X put --url https://example.com -b 'a=1' -A 'agent' -d 'x=1' -d 'y=2'",
+			expected: &Request{Method: "PUT", URL: "https://example.com", Headers: Headers{{"Cookie", "a=1"}, {"User-Agent", "agent"}}, Body: []byte("x=1&y=2")},
+		},
+		{
+			name:     "empty body",
+			command:  "curl https://example.com --data-raw ''",
+			expected: &Request{Method: "POST", URL: "https://example.com", Body: []byte{}},
+		},
+		{
+			name:     "attached short values",
+			command:  "curl -XPATCH https://example.com -H'A: b' -sd 'x'",
+			expected: &Request{Method: "PATCH", URL: "https://example.com", Headers: Headers{{"A", "b"}}, Body: []byte("x")},
+		},
+		{
+			name:     "folded header across -H options",
+			command:  "curl https://example.com -H 'Content-Type: text/plain;' -H ' charset=utf-8' -H 'Accept: */*'",
+			expected: &Request{Method: "GET", URL: "https://example.com", Headers: Headers{{"Content-Type", "text/plain; charset=utf-8"}, {"Accept", "*/*"}}},
+		},
+		{
+			name:     "head",
+			command:  "curl -I 'https://example.com'",
+			expected: &Request{Method: "HEAD", URL: "https://example.com"},
+		},
+		{
+			name:     "head overrides body-implies-POST",
+			command:  "curl --head https://example.com -d 'x'",
+			expected: &Request{Method: "HEAD", URL: "https://example.com", Body: []byte("x")},
+		},
+		{
+			name:     "explicit method beats head",
+			command:  "curl -sI -X OPTIONS https://example.com",
+			expected: &Request{Method: "OPTIONS", URL: "https://example.com"},
+		},
+		{name: "no URL", command: "curl -H 'A: b'", expectError: true},
+		{name: "missing value", command: "curl https://example.com -H", expectError: true},
+		{name: "malformed header", command: "curl https://example.com -H 'nocolon'", expectError: true},
+		{name: "bad escape", command: "curl https://example.com --data-raw $'\\x4G'", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCurl(tt.command, Options{})
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("parseCurl(%q) should have returned an error, but got nil", tt.command)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCurl(%q) returned an unexpected error: %v", tt.command, err)
+			}
+			if got.Method != tt.expected.Method || got.URL != tt.expected.URL || !reflect.DeepEqual(got.Headers, tt.expected.Headers) {
+				t.Errorf("parseCurl(%q) = %+v; want %+v", tt.command, got, tt.expected)
+			}
+			if !bytes.Equal(got.Body, tt.expected.Body) || (got.Body == nil) != (tt.expected.Body == nil) {
+				t.Errorf("parseCurl(%q).Body = %q; want %q", tt.command, got.Body, tt.expected.Body)
+			}
+		})
+	}
+}
+
+// TestToHTTPRequest tests the Request.ToHTTPRequest method.
+func TestToHTTPRequest(t *testing.T) {
+	r := &Request{
+		Method: "PUT",
+		URL:    "https://example.com/a?b=1",
+		Headers: Headers{
+			{Name: "Host", Value: "internal.example"},
+			{Name: "Content-Length", Value: "999"},
+			{Name: "X-Multi", Value: "1"},
+			{Name: "x-multi", Value: "2"},
+		},
+		Body: []byte("payload"),
+	}
+	req, err := r.ToHTTPRequest()
+	if err != nil {
+		t.Fatalf("ToHTTPRequest() returned an unexpected error: %v", err)
+	}
+	if req.Method != "PUT" || req.URL.String() != r.URL || req.Host != "internal.example" {
+		t.Errorf("ToHTTPRequest() = %s %s (Host %q)", req.Method, req.URL, req.Host)
+	}
+	if got := req.Header.Values("X-Multi"); len(got) != 2 || got[0] != "1" || got[1] != "2" {
+		t.Errorf("X-Multi values = %q; want [1 2]", got)
+	}
+	if req.Header.Get("Content-Length") != "" || req.ContentLength != 7 {
+		t.Errorf("Content-Length header %q, ContentLength %d; want none and 7", req.Header.Get("Content-Length"), req.ContentLength)
+	}
+	for i := 0; i < 2; i++ {
+		body, err := req.GetBody()
+		if err != nil {
+			t.Fatalf("GetBody() returned an unexpected error: %v", err)
+		}
+		if b, _ := io.ReadAll(body); string(b) != "payload" {
+			t.Errorf("GetBody() #%d = %q; want %q", i+1, b, "payload")
+		}
+	}
+
+	noBody, err := (&Request{Method: "GET", URL: "https://example.com"}).ToHTTPRequest()
+	if err != nil {
+		t.Fatalf("ToHTTPRequest() returned an unexpected error: %v", err)
+	}
+	if noBody.Body != nil || noBody.GetBody != nil {
+		t.Error("ToHTTPRequest() without a body should not set Body or GetBody")
+	}
+
+	head, err := (&Request{Method: "HEAD", URL: "https://example.com", Body: []byte("x")}).ToHTTPRequest()
+	if err != nil {
+		t.Fatalf("ToHTTPRequest() returned an unexpected error: %v", err)
+	}
+	if head.Body != nil || head.ContentLength != 0 {
+		t.Errorf("ToHTTPRequest() for HEAD sends a %d-byte body; want none", head.ContentLength)
+	}
+}
+
+// TestCheckContentLength tests the checkContentLength function.
+func TestCheckContentLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		headers  Headers
+		body     string
+		expected string
+	}{
+		{"matching", Headers{{Name: "content-length", Value: "5"}}, "hello", ""},
+		{"no header", nil, "hello", ""},
+		{"truncated", Headers{{Name: "Content-Length", Value: "10"}}, "hello", "Content-Length declares 10 bytes but the decoded body is 5 bytes; the capture may be truncated"},
+		{"longer body", Headers{{Name: "Content-Length", Value: "2"}}, "hello", "Content-Length declares 2 bytes but the decoded body is 5 bytes; the capture may be truncated"},
+		{"not a number", Headers{{Name: "Content-Length", Value: "five"}}, "hello", `Content-Length "five" is not a valid length; the body is 5 bytes`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkContentLength(tt.headers, []byte(tt.body)); got != tt.expected {
+				t.Errorf("checkContentLength() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestDecodeContentLength tests that Decode reports a Content-Length mismatch
+// as a warning, or as an ExtractError with StrictLength.
+func TestDecodeContentLength(t *testing.T) {
+	tests := []struct {
+		name             string
+		command          string
+		opts             Options
+		expectedWarnings []string
+		expectError      bool
+	}{
+		{"matching", "curl 'u' -H 'Content-Length: 7' --data-raw $'{\"a\":1}'", Options{}, nil, false},
+		{"matching strict", "curl 'u' -H 'Content-Length: 7' --data-raw $'{\"a\":1}'", Options{StrictLength: true}, nil, false},
+		{"mismatch warns", "curl 'u' -H 'Content-Length: 9' --data-raw $'{\"a\":1}'", Options{},
+			[]string{"Content-Length declares 9 bytes but the decoded body is 7 bytes; the capture may be truncated"}, false},
+		{"mismatch strict", "curl 'u' -H 'Content-Length: 9' --data-raw $'{\"a\":1}'", Options{StrictLength: true}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Decode(tt.command, tt.opts)
+			if tt.expectError {
+				var extractErr *ExtractError
+				if !errors.As(err, &extractErr) {
+					t.Errorf("Decode() error = %v; want an ExtractError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() returned an unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(res.Warnings, tt.expectedWarnings) {
+				t.Errorf("Decode() Warnings = %q; want %q", res.Warnings, tt.expectedWarnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// curlWordPattern matches the word curl anywhere in a line, since log lines
+// put commands after a timestamp or a message rather than in command position.
+var curlWordPattern = regexp.MustCompile("\\bcurl(?:[ \\t]|\\\\\\r?\\n)")
+
+// scanCurlCommands returns every curl command sending data (with one of the
+// data options or the custom ones in dataFlags) found in text, such as an
+// application log that records the commands of failed requests, and the line
+// each one starts on. Commands end like those of findCurlCommand; quotes are
+// followed, so a curl mentioned inside a body is not taken for a command.
+func scanCurlCommands(text string, dataFlags []string) (commands []string, lines []int) {
+	isData := withDataFlags(dataFlags)
+	for pos := 0; pos < len(text); {
+		loc := curlWordPattern.FindStringIndex(text[pos:])
+		if loc == nil {
+			break
+		}
+		start := pos + loc[0]
+		end, err := shellCommandEnd(text, start)
+		if err != nil {
+			pos = start + len("curl") // An unterminated quote; look for the next command.
+			continue
+		}
+		pos = max(end, start+len("curl"))
+		command := strings.TrimSpace(text[start:end])
+		tokens, err := tokenizeCurl(command)
+		if err != nil {
+			continue
+		}
+		flags, _ := scanFlagsWith(tokens[1:], isData)
+		for _, f := range flags {
+			if isData[f.Name] && f.HasValue {
+				commands = append(commands, command)
+				lines = append(lines, strings.Count(text[:start], "\n")+1)
+				break
+			}
+		}
+	}
+	return commands, lines
+}
+
+// runScan decodes every curl command sending data found in text with
+// decodeCommandList, recording the line each one starts on.
+func runScan(text string, opts Options) ([]byte, error) {
+	commands, lines := scanCurlCommands(text, opts.DataFlags)
+	if len(commands) == 0 {
+		return nil, &ExtractError{Err: fmt.Errorf("no curl command sending data found in the input")}
+	}
+	logger.Info(fmt.Sprintf("Found %d curl command(s) sending data in the input.", len(commands)), field("commands", len(commands)))
+	opts.Scan = false
+	return decodeCommandList(commands, lines, "the scanned input", opts)
+}
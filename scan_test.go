@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestScanCurlCommands tests the scanCurlCommands function.
+func TestScanCurlCommands(t *testing.T) {
+	log := "2024-05-01T10:00:00Z INFO started\n" +
+		"2024-05-01T10:00:01Z ERROR request failed, replay with: curl 'https://a' --data-raw $'x=1' (status 500)\n" +
+		"2024-05-01T10:00:02Z WARN curl without data: curl 'https://b'\n" +
+		"2024-05-01T10:00:03Z ERROR retry: curl 'https://c' \\\n  -H 'A: b' \\\n  --data-raw $'{\"note\":\"curl -d x\"}'\n" +
+		"2024-05-01T10:00:04Z ERROR broken: curl 'https://d' --data-raw $'unterminated\n"
+	commands, lines := scanCurlCommands(log, nil)
+	expectedCommands := []string{
+		"curl 'https://a' --data-raw $'x=1' (status 500",
+		"curl 'https://c' \\\n  -H 'A: b' \\\n  --data-raw $'{\"note\":\"curl -d x\"}'",
+	}
+	if !reflect.DeepEqual(commands, expectedCommands) || !reflect.DeepEqual(lines, []int{2, 4}) {
+		t.Errorf("scanCurlCommands() = %q, %v; want %q, [2 4]", commands, lines, expectedCommands)
+	}
+}
+
+// TestRunScan tests that -scan decodes every command embedded in a log into
+// a JSON array with the commands' line numbers.
+func TestRunScan(t *testing.T) {
+	log := "10:00:01 ERROR POST failed: curl 'https://a/1' -H 'Content-Type: application/json' --data-raw $'" + hexEscape(gzipBytes(t, `{"id":1}`)) + "'\n" +
+		"10:00:02 INFO unrelated line mentioning curl\n" +
+		"10:00:03 ERROR POST failed: curl 'https://a/2' --data-raw $'{\"id\":2}'\n" +
+		"10:00:04 ERROR POST failed: $ curl 'https://a/3' --data-raw $'\\xZZ'\n"
+	got, err := Run(log, Options{Scan: true})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	var entries []struct {
+		Index    int             `json:"index"`
+		Line     int             `json:"line"`
+		Output   json.RawMessage `json:"output"`
+		ExitCode int             `json:"exit_code"`
+	}
+	if err := json.Unmarshal(got, &entries); err != nil {
+		t.Fatalf("Run() output is not a JSON array: %v\n%s", err, got)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Run() decoded %d commands; want 3:\n%s", len(entries), got)
+	}
+	expected := []struct {
+		line     int
+		output   string
+		exitCode int
+	}{
+		{1, `{"id":1}`, 0},
+		{3, `{"id":2}`, 0},
+		{4, "", exitDecode},
+	}
+	for i, want := range expected {
+		e := entries[i]
+		var compact string
+		if len(e.Output) > 0 {
+			var v interface{}
+			json.Unmarshal(e.Output, &v)
+			b, _ := json.Marshal(v)
+			compact = string(b)
+		}
+		if e.Index != i || e.Line != want.line || compact != want.output || e.ExitCode != want.exitCode {
+			t.Errorf("entry %d = index %d, line %d, output %s, exit code %d; want line %d, output %s, exit code %d", i, e.Index, e.Line, compact, e.ExitCode, want.line, want.output, want.exitCode)
+		}
+	}
+
+	if _, err := Run("no commands here\ncurl 'https://a'\n", Options{Scan: true}); exitCodeFor(err) != exitExtract {
+		t.Errorf("Run() on a log without commands sending data = %v; want an ExtractError", err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"sort"
+)
+
+// SecretFinding is a likely secret found in a decoded body by scanSecrets.
+type SecretFinding struct {
+	// Type names the heuristic that matched, e.g. "aws_access_key_id".
+	Type string
+	// Offset and Length locate the secret in the decompressed body, in bytes.
+	Offset int
+	Length int
+}
+
+// secretPatterns are the regular expressions scanSecrets looks for, in the
+// order they take precedence when matches overlap.
+var secretPatterns = []struct {
+	Type    string
+	Pattern *regexp.Regexp
+}{
+	{"private_key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]+`)},
+	{"aws_access_key_id", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"github_token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"bearer_token", regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]{16,}=*`)},
+}
+
+// highEntropyToken matches the candidates for the entropy heuristic: long
+// runs of characters that API keys and base64 secrets are made of.
+var highEntropyToken = regexp.MustCompile(`[A-Za-z0-9+/_=-]{24,}`)
+
+// minSecretEntropy is the Shannon entropy, in bits per character, from which
+// a highEntropyToken is reported. Random base64 is close to 6 and English
+// words or identifiers stay well below 4.
+const minSecretEntropy = 4.5
+
+// redactedSecret replaces secrets in the output with -redact. It contains no
+// quotes or backslashes, so redacting inside a JSON string keeps it valid.
+const redactedSecret = "[REDACTED]"
+
+// scanSecrets looks for likely secrets in data: private keys, JWTs, AWS
+// access key ids, GitHub tokens, bearer tokens and other high-entropy
+// strings. Findings are sorted by offset and never overlap.
+func scanSecrets(data []byte) []SecretFinding {
+	var findings []SecretFinding
+	taken := func(start, end int) bool {
+		for _, f := range findings {
+			if start < f.Offset+f.Length && f.Offset < end {
+				return true
+			}
+		}
+		return false
+	}
+	for _, p := range secretPatterns {
+		for _, loc := range p.Pattern.FindAllIndex(data, -1) {
+			if !taken(loc[0], loc[1]) {
+				findings = append(findings, SecretFinding{Type: p.Type, Offset: loc[0], Length: loc[1] - loc[0]})
+			}
+		}
+	}
+	for _, loc := range highEntropyToken.FindAllIndex(data, -1) {
+		if !taken(loc[0], loc[1]) && shannonEntropy(data[loc[0]:loc[1]]) >= minSecretEntropy {
+			findings = append(findings, SecretFinding{Type: "high_entropy", Offset: loc[0], Length: loc[1] - loc[0]})
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Offset < findings[j].Offset })
+	return findings
+}
+
+// shannonEntropy returns the Shannon entropy of data's bytes in bits per byte.
+func shannonEntropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+	entropy := 0.0
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / float64(len(data))
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy
+}
+
+// redactSecrets returns a copy of data with every finding replaced by
+// redactedSecret. findings must be sorted by offset and must not overlap, as
+// scanSecrets returns them.
+func redactSecrets(data []byte, findings []SecretFinding) []byte {
+	var out bytes.Buffer
+	last := 0
+	for _, f := range findings {
+		out.Write(data[last:f.Offset])
+		out.WriteString(redactedSecret)
+		last = f.Offset + f.Length
+	}
+	out.Write(data[last:])
+	return out.Bytes()
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// snappyStreamMagic is the stream identifier chunk every snappy framed stream
+// starts with.
+var snappyStreamMagic = []byte{0xff, 0x06, 0x00, 0x00, 's', 'N', 'a', 'P', 'p', 'Y'}
+
+// Snappy framing chunk types.
+const (
+	snappyChunkCompressed   = 0x00
+	snappyChunkUncompressed = 0x01
+	snappyChunkPadding      = 0xfe
+	snappyChunkStreamID     = 0xff
+)
+
+// snappyMaxBlockSize is the largest uncompressed size of a framed chunk.
+const snappyMaxBlockSize = 65536
+
+// castagnoli is the CRC-32C table used by snappy's framing checksums.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// decompressSnappyData decompresses a snappy framed stream
+// (https://github.com/google/snappy/blob/main/framing_format.txt), verifying
+// the masked CRC-32C of every data chunk. Padding and skippable chunks are
+// skipped; reserved unskippable chunks are an error.
+//
+// Like decompressLZ4Data, this is a small implementation of the format so
+// that the tool keeps building without third-party modules.
+func decompressSnappyData(data []byte) ([]byte, error) {
+	var out []byte
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, errors.New("decompressSnappyData: truncated chunk header")
+		}
+		chunkType := data[0]
+		length := int(data[1]) | int(data[2])<<8 | int(data[3])<<16
+		if len(data)-4 < length {
+			return nil, fmt.Errorf("decompressSnappyData: chunk of %d bytes is truncated to %d", length, len(data)-4)
+		}
+		chunk := data[4 : 4+length]
+		data = data[4+length:]
+
+		switch {
+		case chunkType == snappyChunkStreamID:
+			if string(chunk) != string(snappyStreamMagic[4:]) {
+				return nil, errors.New("decompressSnappyData: bad stream identifier")
+			}
+		case chunkType == snappyChunkCompressed || chunkType == snappyChunkUncompressed:
+			if len(chunk) < 4 {
+				return nil, errors.New("decompressSnappyData: data chunk without a checksum")
+			}
+			checksum, payload := binary.LittleEndian.Uint32(chunk), chunk[4:]
+			if chunkType == snappyChunkCompressed {
+				var err error
+				if payload, err = decodeSnappyBlock(payload); err != nil {
+					return nil, fmt.Errorf("decompressSnappyData: %w", err)
+				}
+			}
+			if len(payload) > snappyMaxBlockSize {
+				return nil, fmt.Errorf("decompressSnappyData: chunk of %d bytes exceeds the %d-byte limit", len(payload), snappyMaxBlockSize)
+			}
+			if got := snappyMaskedCRC(payload); got != checksum {
+				return nil, fmt.Errorf("decompressSnappyData: chunk checksum %#08x does not match %#08x", got, checksum)
+			}
+			out = append(out, payload...)
+		case chunkType == snappyChunkPadding || chunkType >= 0x80:
+			// Padding and skippable chunks.
+		default:
+			return nil, fmt.Errorf("decompressSnappyData: reserved unskippable chunk type %#02x", chunkType)
+		}
+	}
+	return out, nil
+}
+
+// decompressSnappyBlock decompresses a raw snappy block, which has no magic
+// bytes and so is only tried when asked for with -snappy-raw.
+func decompressSnappyBlock(data []byte) ([]byte, error) {
+	out, err := decodeSnappyBlock(data)
+	if err != nil {
+		return nil, fmt.Errorf("decompressSnappyBlock: %w", err)
+	}
+	return out, nil
+}
+
+// snappyMaskedCRC is the checksum of the snappy framing format: the CRC-32C
+// of data, rotated and offset so that checksums of checksums stay useful.
+func snappyMaskedCRC(data []byte) uint32 {
+	c := crc32.Checksum(data, castagnoli)
+	return (c>>15 | c<<17) + 0xa282ead8
+}
+
+// decodeSnappyBlock decodes a snappy block: the uncompressed length as a
+// varint, followed by literals and back-references.
+func decodeSnappyBlock(src []byte) ([]byte, error) {
+	length, n := binary.Uvarint(src)
+	if n <= 0 || length > uint64(len(src))*255+64 {
+		return nil, errors.New("snappy block has an invalid length header")
+	}
+	src = src[n:]
+	dst := make([]byte, 0, length)
+	for len(src) > 0 {
+		tag := src[0]
+		var litLen, offset, copyLen int
+		switch tag & 0x03 {
+		case 0x00: // Literal.
+			litLen = int(tag >> 2)
+			src = src[1:]
+			if litLen >= 60 {
+				extra := litLen - 59
+				if len(src) < extra {
+					return nil, errors.New("snappy literal length is truncated")
+				}
+				litLen = 0
+				for i := extra - 1; i >= 0; i-- {
+					litLen = litLen<<8 | int(src[i])
+				}
+				src = src[extra:]
+			}
+			litLen++
+			if litLen > len(src) || uint64(len(dst)+litLen) > length {
+				return nil, errors.New("snappy literal runs past the end of the block")
+			}
+			dst = append(dst, src[:litLen]...)
+			src = src[litLen:]
+			continue
+		case 0x01:
+			if len(src) < 2 {
+				return nil, errors.New("snappy copy is truncated")
+			}
+			copyLen, offset = 4+int(tag>>2&0x07), int(tag&0xe0)<<3|int(src[1])
+			src = src[2:]
+		case 0x02:
+			if len(src) < 3 {
+				return nil, errors.New("snappy copy is truncated")
+			}
+			copyLen, offset = 1+int(tag>>2), int(binary.LittleEndian.Uint16(src[1:]))
+			src = src[3:]
+		case 0x03:
+			if len(src) < 5 {
+				return nil, errors.New("snappy copy is truncated")
+			}
+			copyLen, offset = 1+int(tag>>2), int(binary.LittleEndian.Uint32(src[1:]))
+			src = src[5:]
+		}
+		if offset <= 0 || offset > len(dst) {
+			return nil, fmt.Errorf("snappy copy offset %d is outside the %d bytes decoded so far", offset, len(dst))
+		}
+		if uint64(len(dst)+copyLen) > length {
+			return nil, errors.New("snappy copy runs past the end of the block")
+		}
+		for i := 0; i < copyLen; i++ { // Byte by byte: the source may overlap the destination.
+			dst = append(dst, dst[len(dst)-offset])
+		}
+	}
+	if uint64(len(dst)) != length {
+		return nil, fmt.Errorf("snappy block decoded to %d bytes, want %d", len(dst), length)
+	}
+	return dst, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+)
+
+// snappyLiteralBlock encodes content as a snappy block made only of literals.
+func snappyLiteralBlock(content []byte) []byte {
+	block := binary.AppendUvarint(nil, uint64(len(content)))
+	for len(content) > 0 {
+		n := min(len(content), 256)
+		if n <= 60 {
+			block = append(block, byte(n-1)<<2)
+		} else {
+			block = append(block, 60<<2, byte(n-1))
+		}
+		block = append(block, content[:n]...)
+		content = content[n:]
+	}
+	return block
+}
+
+// snappyFramed builds a snappy framed stream of content, storing every other
+// chunk uncompressed so that both data chunk types are exercised.
+func snappyFramed(content []byte) []byte {
+	stream := append([]byte{}, snappyStreamMagic...)
+	for i := 0; len(content) > 0 || i == 0; i++ {
+		n := min(len(content), snappyMaxBlockSize)
+		chunk, payload := byte(snappyChunkCompressed), snappyLiteralBlock(content[:n])
+		if i%2 == 1 {
+			chunk, payload = snappyChunkUncompressed, content[:n]
+		}
+		stream = append(stream, chunk, byte(len(payload)+4), byte((len(payload)+4)>>8), byte((len(payload)+4)>>16))
+		stream = binary.LittleEndian.AppendUint32(stream, snappyMaskedCRC(content[:n]))
+		stream = append(stream, payload...)
+		content = content[n:]
+	}
+	return stream
+}
+
+// TestDecompressSnappyData tests the decompressSnappyData function.
+func TestDecompressSnappyData(t *testing.T) {
+	large := bytes.Repeat([]byte(`{"seq":1,"event":"view"}`+"\n"), 6000)
+	hello := snappyFramed([]byte("hello"))
+	badChecksum := append([]byte{}, hello...)
+	badChecksum[len(snappyStreamMagic)+4] ^= 0xff
+	padded := append(append(append([]byte{}, hello...), snappyChunkPadding, 2, 0, 0, 0, 0), 0x80, 1, 0, 0, 'x')
+
+	tests := []struct {
+		name        string
+		input       []byte
+		expected    []byte
+		expectError bool
+	}{
+		{name: "round trip", input: snappyFramed([]byte(`{"a":1}`)), expected: []byte(`{"a":1}`)},
+		{name: "round trip over several chunks", input: snappyFramed(large), expected: large},
+		{name: "empty stream", input: snappyStreamMagic, expected: nil},
+		{name: "padding and skippable chunks", input: padded, expected: []byte("hello")},
+		{name: "bad checksum", input: badChecksum, expectError: true},
+		{name: "reserved unskippable chunk", input: append(append([]byte{}, hello...), 0x02, 0, 0, 0), expectError: true},
+		{name: "truncated chunk", input: hello[:len(hello)-1], expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decompressSnappyData(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("decompressSnappyData() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !tt.expectError && !bytes.Equal(got, tt.expected) {
+				t.Errorf("decompressSnappyData() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestDecodeSnappyBlock tests the decodeSnappyBlock function.
+func TestDecodeSnappyBlock(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expected    string
+		expectError bool
+	}{
+		{name: "literal", input: snappyLiteralBlock([]byte("hello")), expected: "hello"},
+		{name: "long literal", input: snappyLiteralBlock([]byte(strings.Repeat("x", 200))), expected: strings.Repeat("x", 200)},
+		// "abc" followed by a 1-byte-offset copy of length 5 at offset 3.
+		{name: "overlapping copy", input: []byte{8, 2 << 2, 'a', 'b', 'c', 0x01 | 1<<2, 3}, expected: "abcabcab"},
+		// "ab" followed by a 2-byte-offset copy of length 4 at offset 2.
+		{name: "two-byte offset copy", input: []byte{6, 1 << 2, 'a', 'b', 0x02 | 3<<2, 2, 0}, expected: "ababab"},
+		{name: "copy before any literal", input: []byte{4, 0x01, 1}, expectError: true},
+		{name: "length mismatch", input: []byte{9, 2 << 2, 'a', 'b', 'c'}, expectError: true},
+		{name: "no length", input: nil, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeSnappyBlock(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("decodeSnappyBlock() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !tt.expectError && string(got) != tt.expected {
+				t.Errorf("decodeSnappyBlock() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunSnappy tests that Run detects snappy framed bodies and decodes raw
+// blocks only with SnappyRaw.
+func TestRunSnappy(t *testing.T) {
+	logger = newLogger(io.Discard, logFormatText)
+	tests := []struct {
+		name     string
+		body     []byte
+		raw      bool
+		expected string
+	}{
+		{name: "framed", body: snappyFramed([]byte("hello snappy")), expected: "hello snappy"},
+		{name: "raw block", body: snappyLiteralBlock([]byte("hello snappy")), raw: true, expected: "hello snappy"},
+		{name: "raw block without SnappyRaw", body: snappyLiteralBlock([]byte("hi")), expected: "\x02\x04hi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := "curl https://example.com --data-raw $'" + encodeRawData(tt.body) + "'"
+			got, err := Run(cmd, Options{SnappyRaw: tt.raw})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// sseEvent is one Server-Sent Event. Data holds the event's JSON value when
+// its data is valid JSON and the data as a JSON string otherwise.
+type sseEvent struct {
+	Event string          `json:"event,omitempty"`
+	ID    string          `json:"id,omitempty"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// parseSSE splits a text/event-stream body into events following the SSE
+// framing rules: events are separated by blank lines, multiple data: lines are
+// joined with newlines, lines starting with ':' are comments and a single
+// space after the field's colon is dropped. Unlike a browser's last-event-ID,
+// an event's ID is only the id: line written in that event. ok is false when the body does
+// not look like an event stream (no data: line, or a line that is neither a
+// known field nor a comment), so callers can fall back to the raw body.
+func parseSSE(body []byte) (events []sseEvent, ok bool) {
+	text := strings.ReplaceAll(strings.ReplaceAll(string(body), "\r\n", "\n"), "\r", "\n")
+
+	var (
+		event, id string
+		data      []string
+		hasData   bool
+		sawData   bool
+	)
+	dispatch := func() {
+		if hasData {
+			joined := strings.Join(data, "\n")
+			raw := json.RawMessage(joined)
+			if !json.Valid(raw) {
+				raw, _ = json.Marshal(joined)
+			}
+			events = append(events, sseEvent{Event: event, ID: id, Data: raw})
+		}
+		event, id, data, hasData = "", "", nil, false
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			dispatch()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // Comment.
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			data, hasData, sawData = append(data, value), true, true
+		case "event":
+			event = value
+		case "id":
+			id = value
+		case "retry":
+		default:
+			return nil, false
+		}
+	}
+	dispatch()
+	return events, sawData
+}
+
+// formatSSE pretty-prints the events of an SSE body as a JSON array, or
+// returns the body unchanged when it does not look like an event stream.
+func formatSSE(body []byte, opts Options) ([]byte, error) {
+	events, ok := parseSSE(body)
+	if !ok {
+		fmt.Fprintln(previews, "Body does not look like a Server-Sent Events stream, saving raw processed data to output file.")
+		return body, nil
+	}
+	prettyJSON, err := json.MarshalIndent(events, "", jsonIndent(opts))
+	if err != nil {
+		return nil, fmt.Errorf("formatSSE: marshalling events: %w", err)
+	}
+	fmt.Fprintf(previews, "Parsed %d Server-Sent Events:\n", len(events))
+	fmt.Fprintln(previews, previewJSON(prettyJSON, opts))
+	return prettyJSON, nil
+}
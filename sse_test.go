@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseSSE tests the parseSSE function.
+func TestParseSSE(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		expected   []sseEvent
+		expectedOK bool
+	}{
+		{
+			name:  "multi-event stream",
+			input: ": keep-alive\nevent: update\nid: 1\ndata: {\"a\":1}\n\ndata: {\"b\":\ndata: 2}\n\ndata: plain text\n\n",
+			expected: []sseEvent{
+				{Event: "update", ID: "1", Data: []byte(`{"a":1}`)},
+				{Data: []byte("{\"b\":\n2}")},
+				{Data: []byte(`"plain text"`)},
+			},
+			expectedOK: true,
+		},
+		{
+			name:       "crlf and no trailing blank line",
+			input:      "retry: 100\r\ndata:{\"x\":true}",
+			expected:   []sseEvent{{Data: []byte(`{"x":true}`)}},
+			expectedOK: true,
+		},
+		{
+			name:       "event without data is dropped",
+			input:      "event: ping\n\ndata: 1\n\n",
+			expected:   []sseEvent{{Data: []byte(`1`)}},
+			expectedOK: true,
+		},
+		{name: "json body", input: `{"a":1}`, expectedOK: false},
+		{name: "no data lines", input: "event: x\n\n", expectedOK: false},
+		{name: "unknown field", input: "data: 1\nfoo: bar\n", expectedOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseSSE([]byte(tt.input))
+			if ok != tt.expectedOK {
+				t.Fatalf("parseSSE(%q) ok = %v; want %v", tt.input, ok, tt.expectedOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseSSE(%q) = %+v; want %+v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestRunSSE tests the -sse mode end to end, including the raw fallback.
+func TestRunSSE(t *testing.T) {
+	command := "curl 'url' --data-raw $'event: a\\ndata: {\"n\":1}\\n\\nevent: b\\ndata: {\"n\":2}\\n\\n'"
+	got, err := Run(command, Options{SSE: true})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	expected := "[\n  {\n    \"event\": \"a\",\n    \"data\": {\n      \"n\": 1\n    }\n  },\n  {\n    \"event\": \"b\",\n    \"data\": {\n      \"n\": 2\n    }\n  }\n]"
+	if string(got) != expected {
+		t.Errorf("Run() = %q; want %q", got, expected)
+	}
+
+	got, err = Run("curl 'url' --data-raw $'not an event stream'", Options{SSE: true})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if string(got) != "not an event stream" {
+		t.Errorf("Run() = %q; want the raw body", got)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// streamable reports whether RunTo may stream the body for opts: Stream must
+// be set and the output must be the decompressed body as is. Rather than
+// list every option that needs the whole body, it lists the ones known to
+// work with streaming, so an option added later disables streaming until it
+// is added here: those that only affect how the payload is found and
+// decoded, and settings of outputs other than the raw body, which the CLI
+// always sets. Any other option set in opts disables streaming.
+func streamable(opts Options) bool {
+	if !opts.Stream || opts.InputFormat != "" && opts.InputFormat != inputFormatCurl && opts.InputFormat != inputFormatB64Cmd ||
+		opts.Decompress != "" && opts.Decompress != DecompressAuto || opts.Newline != "" && opts.Newline != NewlineKeep {
+		return false
+	}
+	rest := opts
+	rest.Stream, rest.InputFormat, rest.Decompress, rest.Newline = false, "", "", ""
+	// Finding and decoding the payload.
+	rest.DataFlags, rest.URLDecodeInput, rest.FindCurl, rest.Env, rest.RawInput, rest.BinaryConcat = nil, false, false, false, false, false
+	rest.Dialect, rest.OnInvalid, rest.DisableOctal, rest.DisableHex, rest.DisableUnicode = "", "", false, false, false
+	rest.NoTrim, rest.Verbose, rest.StrictLength, rest.SnappyRaw = false, false, false, false
+	// Settings of other outputs.
+	rest.Indent, rest.Color, rest.CArrayWidth, rest.ReprWidth, rest.GzipLevel, rest.KeepGzipHeader = "", false, 0, 0, 0, false
+	rest.MaxDepth, rest.Retries, rest.RetryDelay = 0, 0, 0
+	return reflect.DeepEqual(rest, Options{})
+}
+
+// streamConflicts returns the flags set in opts that interpret the body,
+// which -stream writes as is, so the two cannot be combined.
+func streamConflicts(opts Options) []string {
+	var conflicts []string
+	for _, option := range []struct {
+		flag string
+		set  bool
+	}{
+		{"-require-json", opts.RequireJSON},
+		{"-extract", opts.Extract != ""},
+		{"-grep", opts.Grep != ""},
+		{"-fields", len(opts.Fields) > 0},
+		{"-sse", opts.SSE},
+		{"-grpc", opts.GRPC},
+		{"-grpcweb", opts.GRPCWeb},
+		{"-canonical", opts.Canonical},
+		{"-json-repair", opts.JSONRepair},
+		{"-unwrap-json-string", opts.UnwrapJSONString},
+	} {
+		if option.set {
+			conflicts = append(conflicts, option.flag)
+		}
+	}
+	return conflicts
+}
+
+// streamGzip decodes the payload of curlCommand and, when it is gzip
+// compressed, copies the gzip reader straight to w, so the decompressed body
+// is never held in memory. It reports whether it handled the command; for
+// anything else, including a body whose gzip header does not parse, it
+// writes nothing and leaves the command to the buffered path. Unlike that
+// path, it cannot fall back to the compressed bytes once it has started
+// writing, so corrupt data part way through is a DecompressError. The
+// Content-Length header is checked, as on the buffered path, before anything
+// is written.
+func streamGzip(w io.Writer, curlCommand string, opts Options) (bool, error) {
+	curlCommand, opts, err := prepareCommand(curlCommand, opts)
+	if err != nil {
+		return true, err
+	}
+	if commands, err := splitCurlNext(curlCommand, opts.DataFlags); err == nil && len(commands) > 1 && !opts.RawInput {
+		return false, nil
+	}
+	res := &DecodeResult{}
+	data, err := decodeCurlPayload(res, curlCommand, opts)
+	if err != nil {
+		return true, err
+	}
+	algorithm, skip := detectCompression(data)
+	if algorithm != algoGzip {
+		return false, nil
+	}
+	gzReader, err := gzip.NewReader(bytes.NewReader(data[skip:]))
+	if err != nil {
+		return false, nil
+	}
+	defer gzReader.Close()
+	var headers Headers
+	if !opts.RawInput {
+		if headers, err = extractHeaders(curlCommand); err != nil {
+			logger.Warn(fmt.Sprintf("Could not parse the command's headers, not checking Content-Length: %v", err), field("error", err))
+		}
+	}
+	if err := applyContentLength(res, headers, data, opts); err != nil {
+		return true, err
+	}
+	n, err := io.Copy(w, gzReader)
+	if err != nil {
+		return true, &DecompressError{Err: fmt.Errorf("streaming gzip data after %d bytes: %w", n, err)}
+	}
+	logger.Info(fmt.Sprintf("Streamed %d decompressed gzip bytes to the output.", n), field("algorithm", algorithm), field("length", n))
+	return true, nil
+}
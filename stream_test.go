@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestRunToStream tests that streaming a body with RunTo writes the same bytes
+// as the buffered Run with the same options.
+func TestRunToStream(t *testing.T) {
+	logger = newLogger(io.Discard, logFormatText)
+	large := strings.Repeat(`{"seq":1,"event":"view"}`+"\n", 20000)
+	tests := []struct {
+		name     string
+		command  string
+		streamed bool
+	}{
+		{"gzipped JSON stays raw", "curl 'u' --data-raw $'" + hexEscape(gzipBytes(t, `{"a":1}`)) + "'", true},
+		{"large gzip body", "curl 'u' --data-raw $'" + hexEscape(gzipBytes(t, large)) + "'", true},
+		{"concatenated gzip members", "curl 'u' --data-raw $'" + hexEscape(append(gzipBytes(t, "one "), gzipBytes(t, "two")...)) + "'", true},
+		{"leading whitespace", "curl 'u' --data-raw $'\\n " + hexEscape(gzipBytes(t, "hello")) + "'", true},
+		{"not compressed", "curl 'u' --data-raw $'plain text'", false},
+		{"zlib", "curl 'u' --data-raw $'\\x78\\x9c\\xcb\\x48\\xcd\\xc9\\xc9\\x07\\x00\\x06\\x2c\\x02\\x15'", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Stream: true}
+			buffered, err := Run(tt.command, opts)
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			var streamed bytes.Buffer
+			if err := RunTo(&streamed, tt.command, opts); err != nil {
+				t.Fatalf("RunTo() returned an unexpected error: %v", err)
+			}
+			if !bytes.Equal(streamed.Bytes(), buffered) {
+				t.Errorf("RunTo() wrote %q; Run() returned %q", streamed.Bytes(), buffered)
+			}
+			if handled, _ := streamGzip(io.Discard, tt.command, opts); handled != tt.streamed {
+				t.Errorf("streamGzip() handled = %v; want %v", handled, tt.streamed)
+			}
+		})
+	}
+}
+
+// TestRunToStreamInspect tests that -stream -inspect writes the inspect
+// table rather than streaming the decompressed body.
+func TestRunToStreamInspect(t *testing.T) {
+	logger = newLogger(io.Discard, logFormatText)
+	command := "curl 'https://example.com/api' --data-raw $'" + hexEscape(gzipBytes(t, `{"a":1}`)) + "'"
+	var out bytes.Buffer
+	if err := RunTo(&out, command, Options{Stream: true, Inspect: true}); err != nil {
+		t.Fatalf("RunTo() returned an unexpected error: %v", err)
+	}
+	if got := out.String(); !strings.HasPrefix(got, "method ") || !strings.Contains(got, "compression") || strings.Contains(got, `{"a":1}`) {
+		t.Errorf("RunTo() wrote %q; want the inspect table", got)
+	}
+}
+
+// TestStreamConflicts tests the streamConflicts function.
+func TestStreamConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		expected []string
+	}{
+		{"none", Options{Stream: true, Digest: "sha256"}, nil},
+		{"require-json", Options{RequireJSON: true}, []string{"-require-json"}},
+		{"extract and grpc", Options{Extract: "$.b", GRPC: true}, []string{"-extract", "-grpc"}},
+		{"fields", Options{Fields: []string{"a"}}, []string{"-fields"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := streamConflicts(tt.opts); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("streamConflicts() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestStreamGzipTruncated tests that a gzip body cut short while streaming is
+// a DecompressError.
+func TestStreamGzipTruncated(t *testing.T) {
+	logger = newLogger(io.Discard, logFormatText)
+	gzipped := gzipBytes(t, strings.Repeat("abc", 1000))
+	command := "curl 'u' --data-raw $'" + hexEscape(gzipped[:len(gzipped)/2]) + "'"
+	handled, err := streamGzip(io.Discard, command, Options{Stream: true})
+	var decompressErr *DecompressError
+	if !handled || !errors.As(err, &decompressErr) {
+		t.Errorf("streamGzip() = %v, %v; want true and a DecompressError", handled, err)
+	}
+}
+
+// TestStreamGzipContentLength tests that streaming checks the Content-Length
+// header as the buffered path does, failing before anything is written with
+// -strict-length.
+func TestStreamGzipContentLength(t *testing.T) {
+	logger = newLogger(io.Discard, logFormatText)
+	command := "curl 'u' -H 'Content-Length: 1' --data-raw $'" + hexEscape(gzipBytes(t, `{"a":1}`)) + "'"
+	tests := []struct {
+		name         string
+		strict       bool
+		expectedCode int
+		expected     string
+	}{
+		{"warning", false, exitOK, `{"a":1}`},
+		{"strict", true, exitExtract, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			handled, err := streamGzip(&out, command, Options{Stream: true, StrictLength: tt.strict})
+			if !handled {
+				t.Fatalf("streamGzip() did not handle the gzip body")
+			}
+			if got := exitCodeFor(err); got != tt.expectedCode {
+				t.Errorf("exitCodeFor(streamGzip()) = %d (err: %v); want %d", got, err, tt.expectedCode)
+			}
+			if out.String() != tt.expected {
+				t.Errorf("streamGzip() wrote %q; want %q", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+// TestStreamable tests that only the options known to work with streaming
+// leave it enabled.
+func TestStreamable(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		expected bool
+	}{
+		{"stream", Options{Stream: true}, true},
+		{"not requested", Options{}, false},
+		{"with a format", Options{Stream: true, Format: "hexstring"}, false},
+		{"with a digest", Options{Stream: true, Digest: "sha256"}, false},
+		{"with inspect", Options{Stream: true, Inspect: true}, false},
+		{"with CLI defaults", Options{Stream: true, Dialect: DialectPython, OnInvalid: OnInvalidError, Indent: "  ", CArrayWidth: 12, GzipLevel: defaultGzipLevel, MaxDepth: defaultMaxDepth, Decompress: DecompressAuto, Newline: NewlineKeep}, true},
+		{"with payload options", Options{Stream: true, DataFlags: []string{"--payload"}, NoTrim: true, StrictLength: true, InputFormat: inputFormatB64Cmd}, true},
+		{"with require-json", Options{Stream: true, RequireJSON: true}, false},
+		{"with extract", Options{Stream: true, Extract: "$.b"}, false},
+		{"with forced decompression", Options{Stream: true, Decompress: algoDeflate}, false},
+		{"with post-decode hook", Options{Stream: true, PostDecode: func(b []byte) ([]byte, error) { return b, nil }}, false},
+		{"httpraw input", Options{Stream: true, InputFormat: inputFormatHTTPRaw}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := streamable(tt.opts); got != tt.expected {
+				t.Errorf("streamable(%+v) = %v; want %v", tt.opts, got, tt.expected)
+			}
+		})
+	}
+}
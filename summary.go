@@ -0,0 +1,42 @@
+package main
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// summaryDigestLength is the number of hex digits of the SHA-256 shown by -summary.
+const summaryDigestLength = 12
+
+// summaryLine describes a decoded body in one tab-separated line for -summary:
+// its media type, decompressed size in bytes, the compression that was undone
+// ("none" if any) and a SHA-256 prefix. The media type is bodyMediaType's.
+func summaryLine(res *DecodeResult) (string, error) {
+	mediaType := bodyMediaType(res)
+	algorithm := res.Algorithm
+	if algorithm == algoNone {
+		algorithm = "none"
+	}
+	digest, err := digestOf("sha256", res.Decompressed)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{mediaType, strconv.Itoa(len(res.Decompressed)), algorithm, digest[:summaryDigestLength]}, "\t") + "\n", nil
+}
+
+// bodyMediaType returns the media type of the Content-Type header or, without
+// a usable one, the type sniffed from the decompressed body.
+func bodyMediaType(res *DecodeResult) string {
+	if res.ContentType != "" {
+		if mt, _, err := mime.ParseMediaType(res.ContentType); err == nil {
+			return mt
+		}
+	}
+	if res.IsJSON {
+		return "application/json"
+	}
+	mediaType, _, _ := strings.Cut(http.DetectContentType(res.Decompressed), ";")
+	return mediaType
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+// TestRunSummary tests the -summary output of Run.
+func TestRunSummary(t *testing.T) {
+	gzipped := hexEscape(gzipBytes(t, `{"a":1}`))
+	tests := []struct {
+		name     string
+		command  string
+		expected string
+	}{
+		{"gzipped json", "curl 'u' --data-raw $'" + gzipped + "'", "application/json\t7\tgzip\t015abd7f5cc5\n"},
+		{"content-type header wins", "curl 'u' -H 'Content-Type: text/plain; charset=utf-8' --data-raw $'" + gzipped + "'", "text/plain\t7\tgzip\t015abd7f5cc5\n"},
+		{"sniffed text", "curl 'u' --data-raw $'hello'", "text/plain\t5\tnone\t2cf24dba5fb0\n"},
+		{"sniffed binary", "curl 'u' --data-raw $'\\x00\\x01\\x02'", "application/octet-stream\t3\tnone\tae4b3280e56e\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(tt.command, Options{Summary: true})
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %q; want %q", got, tt.expected)
+			}
+			if !regexp.MustCompile(`^[^\t\n]+\t[0-9]+\t[a-z0-9]+\t[0-9a-f]{12}\n$`).Match(got) {
+				t.Errorf("Run() = %q is not a <type>\\t<bytes>\\t<algorithm>\\t<sha256-prefix> line", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// templateData is what a -template is executed against: the parsed request
+// and the decode result. Body is the final body that would otherwise have
+// been rendered, so .Raw and .Decompressed are also available.
+type templateData struct {
+	*DecodeResult
+	Method  string
+	URL     string
+	Headers Headers
+	Body    []byte
+}
+
+// renderTemplate executes the Go text/template text against data. Besides the
+// builtins, templates can use:
+//
+//	repr     the Python b'' representation of a []byte or string
+//	json     the JSON encoding of a value; a []byte that already is JSON is kept as is
+//	header   the value of the first request header with the given name
+func renderTemplate(text string, data templateData) ([]byte, error) {
+	tmpl, err := template.New("template").Option("missingkey=error").Funcs(template.FuncMap{
+		"repr":   templateRepr,
+		"json":   templateJSON,
+		"header": data.Headers.Get,
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing -template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("executing -template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// templateRepr is the repr template function.
+func templateRepr(v any) (string, error) {
+	switch v := v.(type) {
+	case []byte:
+		return reprBytes(v), nil
+	case string:
+		return reprBytes([]byte(v)), nil
+	default:
+		return "", fmt.Errorf("repr: want []byte or string, got %T", v)
+	}
+}
+
+// templateJSON is the json template function.
+func templateJSON(v any) (string, error) {
+	if b, ok := v.([]byte); ok {
+		if json.Valid(b) {
+			return string(b), nil
+		}
+		v = string(b)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+// TestRunTemplate tests the -template output of Run.
+func TestRunTemplate(t *testing.T) {
+	body := `{"a":1}`
+	command := "curl 'https://example.com/api' -H 'Content-Type: application/json' -H 'X-Trace: t1' --data-raw $'" + hexEscape(gzipBytes(t, body)) + "'"
+	tests := []struct {
+		name        string
+		template    string
+		expected    string
+		expectError bool
+	}{
+		{"method header and body length", `{{.Method}} {{.URL}} {{header "x-trace"}} {{len .Body}}`, "POST https://example.com/api t1 7", false},
+		{"decode result fields", `{{.Algorithm}} {{len .Raw}} {{.IsJSON}}`, "gzip " + strconv.Itoa(len(gzipBytes(t, body))) + " true", false},
+		{"repr", `{{repr .Body}}`, `b'{"a":1}'`, false},
+		{"json of a JSON body", `{"body":{{json .Body}},"url":{{json .URL}}}`, `{"body":{"a":1},"url":"https://example.com/api"}`, false},
+		{"range over headers", `{{range .Headers}}{{.Name}};{{end}}`, "Content-Type;X-Trace;", false},
+		{"missing header", `[{{header "Cookie"}}]`, "[]", false},
+		{"parse error", `{{.Method`, "", true},
+		{"unknown field", `{{.Nope}}`, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(command, Options{Template: tt.template})
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Run() expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Run() = %q; want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestTemplateJSON tests the templateJSON function.
+func TestTemplateJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{"json bytes kept", []byte(`[1, 2]`), `[1, 2]`},
+		{"other bytes quoted", []byte("a\"b"), `"a\"b"`},
+		{"string", "x", `"x"`},
+		{"headers", Headers{{"A", "b"}}, `[{"Name":"A","Value":"b"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := templateJSON(tt.value)
+			if err != nil {
+				t.Fatalf("templateJSON() returned an unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("templateJSON(%v) = %s; want %s", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+// Minimal JavaScript glue for the browser build, runnable with Node.js:
+//
+//   GOOS=js GOARCH=wasm go build -o decoder.wasm .
+//   node testdata/wasm/decode_curl.js decoder.wasm < curl_command.txt
+//
+// In a web page the same calls apply: load wasm_exec.js from
+// $(go env GOROOT)/lib/wasm, instantiate the module, start go.run() and then
+// call the global decodeCurl(command), which returns {result, error}.
+"use strict";
+
+const fs = require("fs");
+const path = require("path");
+const { execSync } = require("child_process");
+
+const goroot = process.env.GOROOT || execSync("go env GOROOT").toString().trim();
+require(path.join(goroot, "lib", "wasm", "wasm_exec.js"));
+
+async function main() {
+	const go = new Go();
+	const { instance } = await WebAssembly.instantiate(fs.readFileSync(process.argv[2]), go.importObject);
+	go.run(instance); // Registers decodeCurl and then blocks without returning.
+
+	const { result, error } = decodeCurl(fs.readFileSync(0, "utf8"));
+	if (error) {
+		console.error("decodeCurl failed: " + error);
+		process.exit(1);
+	}
+	console.log(result);
+	process.exit(0);
+}
+
+main();
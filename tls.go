@@ -0,0 +1,103 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// TLSOptions are the TLS settings of a cURL command that matter for
+// replaying it, e.g. against a service requiring mutual TLS.
+type TLSOptions struct {
+	// CertFile is the PEM client certificate given with --cert/-E.
+	CertFile string
+	// KeyFile is the PEM private key given with --key. When only CertFile is
+	// set, the key is read from the certificate file, as curl does.
+	KeyFile string
+	// CACertFile is the PEM bundle given with --cacert that replaces the
+	// system roots for verifying the server.
+	CACertFile string
+	// Insecure is set by -k/--insecure: the server certificate is not verified.
+	Insecure bool
+}
+
+// isZero reports whether o sets nothing, so the default TLS settings apply.
+func (o *TLSOptions) isZero() bool {
+	return *o == TLSOptions{}
+}
+
+// extractTLSOptions collects the --cert, --key, --cacert and -k/--insecure
+// options of a tokenized cURL command. A later option overrides an earlier
+// one, as in curl.
+func extractTLSOptions(tokens []Token) (*TLSOptions, error) {
+	flags, _ := scanFlags(tokens)
+	o := &TLSOptions{}
+	for _, f := range flags {
+		if !f.HasValue && (f.Name == "--cert" || f.Name == "--key" || f.Name == "--cacert") {
+			return nil, fmt.Errorf("option %s is missing its value", f.Name)
+		}
+		switch f.Name {
+		case "--cert":
+			o.CertFile = f.Value.Value
+		case "--key":
+			o.KeyFile = f.Value.Value
+		case "--cacert":
+			o.CACertFile = f.Value.Value
+		case "--insecure":
+			o.Insecure = true
+		}
+	}
+	if o.KeyFile != "" && o.CertFile == "" {
+		return nil, errors.New("--key given without --cert")
+	}
+	return o, nil
+}
+
+// tlsConfig builds the tls.Config for o: the client certificate and key
+// pair, the CA pool and InsecureSkipVerify.
+func (o *TLSOptions) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: o.Insecure}
+	if o.CertFile != "" {
+		keyFile := o.KeyFile
+		if keyFile == "" {
+			keyFile = o.CertFile
+		}
+		cert, err := tls.LoadX509KeyPair(o.CertFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading the client certificate %s: %w", o.CertFile, err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	if o.CACertFile != "" {
+		pem, err := os.ReadFile(o.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading the CA certificates: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates in %s", o.CACertFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// replayClientFor returns replayClient, or a copy of it whose transport uses
+// the TLS settings in o when the command has any.
+func replayClientFor(o *TLSOptions) (*http.Client, error) {
+	if o.isZero() {
+		return replayClient, nil
+	}
+	config, err := o.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+	client := *replayClient
+	client.Transport = transport
+	return &client, nil
+}
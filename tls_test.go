@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestExtractTLSOptions tests the extractTLSOptions function.
+func TestExtractTLSOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		expected    *TLSOptions
+		expectError bool
+	}{
+		{"none", "curl https://a -H 'A: b'", &TLSOptions{}, false},
+		{"cert", "curl https://a --cert client.pem", &TLSOptions{CertFile: "client.pem"}, false},
+		{"short cert", "curl https://a -E 'my client.pem'", &TLSOptions{CertFile: "my client.pem"}, false},
+		{"cert and key", "curl https://a --cert=c.pem --key k.pem", &TLSOptions{CertFile: "c.pem", KeyFile: "k.pem"}, false},
+		{"cacert", "curl https://a --cacert ca.pem", &TLSOptions{CACertFile: "ca.pem"}, false},
+		{"insecure", "curl https://a --insecure", &TLSOptions{Insecure: true}, false},
+		{"combined short options", "curl -skE c.pem https://a", &TLSOptions{CertFile: "c.pem", Insecure: true}, false},
+		{"later option wins", "curl https://a --cacert a.pem --cacert b.pem", &TLSOptions{CACertFile: "b.pem"}, false},
+		{"key without cert", "curl https://a --key k.pem", nil, true},
+		{"missing value", "curl https://a --cacert", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := tokenizeCurl(tt.command)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := extractTLSOptions(tokens)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("extractTLSOptions(%q) error = %v, expectError %v", tt.command, err, tt.expectError)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("extractTLSOptions(%q) = %+v; want %+v", tt.command, got, tt.expected)
+			}
+		})
+	}
+}
+
+// writeClientCert writes a self-signed client certificate and its key as PEM
+// files in dir and returns their paths.
+func writeClientCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "replay client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// TestRunReplayTLS tests that replaying honours the command's TLS options
+// against a server that requires a client certificate.
+func TestRunReplayTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"client":"` + r.TLS.PeerCertificates[0].Subject.CommonName + `"}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	certFile, keyFile := writeClientCert(t, dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		options     string
+		expectError bool
+	}{
+		{"cert, key and cacert", " --cert '" + certFile + "' --key '" + keyFile + "' --cacert '" + caFile + "'", false},
+		{"cert, key and insecure", " -k -E '" + certFile + "' --key '" + keyFile + "'", false},
+		{"no client certificate", " -k", true},
+		{"server not trusted", " --cert '" + certFile + "' --key '" + keyFile + "'", true},
+		{"missing key file", " -k --cert '" + certFile + "' --key '" + filepath.Join(dir, "missing.key") + "'", true},
+		{"cacert without certificates", " -k --cacert '" + keyFile + "'", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run("curl '"+server.URL+"'"+tt.options, Options{Replay: true, Canonical: true})
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Run() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !tt.expectError && string(got) != `{"client":"replay client"}` {
+				t.Errorf("Run() = %q; want the server to see the client certificate", got)
+			}
+		})
+	}
+}
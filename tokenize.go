@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Token is one shell word of a cURL command.
+type Token struct {
+	// Value is the word with shell quoting removed. When ANSIC is set, Value is
+	// still in ANSI-C escaped form (as inside $'...') and must be run through
+	// decodeRawDataWith to obtain the bytes; any unquoted or '...'/"..." parts
+	// of the same word are re-escaped so that decoding reproduces them verbatim.
+	Value string
+	// ANSIC reports whether the word contains a $'...' segment.
+	ANSIC bool
+	// Start and End are the byte offsets of the whole word in the command.
+	Start int
+	End   int
+}
+
+// tokenizeCurl splits a cURL command into shell words the way bash would for
+// the quoting styles browsers produce when copying a request as cURL: '...',
+// "...", $'...', $"...", backslash escapes and backslash-newline line
+// continuations. Variable expansion, globbing and command substitution are not performed.
+func tokenizeCurl(command string) ([]Token, error) {
+	var (
+		tokens  []Token
+		word    strings.Builder // Current word in plain (unescaped) form.
+		ansiC   strings.Builder // Current word in ANSI-C escaped form.
+		isANSIC bool
+		inWord  bool
+		start   int
+	)
+	// appendPlain adds literal text to the current word.
+	appendPlain := func(s string) {
+		word.WriteString(s)
+		ansiC.WriteString(escapeANSIC(s))
+	}
+	flush := func(end int) {
+		if !inWord {
+			return
+		}
+		t := Token{Value: word.String(), Start: start, End: end}
+		if isANSIC {
+			t.Value, t.ANSIC = ansiC.String(), true
+		}
+		tokens = append(tokens, t)
+		word.Reset()
+		ansiC.Reset()
+		isANSIC, inWord = false, false
+	}
+	begin := func(i int) {
+		if !inWord {
+			inWord, start = true, i
+		}
+	}
+
+	for i := 0; i < len(command); {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			flush(i)
+			i++
+		case c == '\\':
+			if i+1 >= len(command) {
+				return nil, fmt.Errorf("tokenizeCurl: trailing backslash at byte %d", i)
+			}
+			if command[i+1] == '\n' { // Line continuation.
+				flush(i)
+				i += 2
+				continue
+			}
+			if command[i+1] == '\r' && i+2 < len(command) && command[i+2] == '\n' {
+				flush(i)
+				i += 3
+				continue
+			}
+			begin(i)
+			appendPlain(command[i+1 : i+2])
+			i += 2
+		case c == '\'':
+			begin(i)
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("tokenizeCurl: unterminated ' quote starting at byte %d", i)
+			}
+			appendPlain(command[i+1 : i+1+end])
+			i += end + 2
+		case c == '$' && i+1 < len(command) && command[i+1] == '\'':
+			begin(i)
+			j := i + 2
+			for ; j < len(command) && command[j] != '\''; j++ {
+				if command[j] == '\\' {
+					j++ // An escaped character (including \') never ends the quote.
+				}
+			}
+			if j >= len(command) {
+				return nil, fmt.Errorf("tokenizeCurl: unterminated $' quote starting at byte %d", i)
+			}
+			raw := command[i+2 : j]
+			// The plain form is best effort; callers decode the ANSI-C form.
+			word.WriteString(raw)
+			ansiC.WriteString(raw)
+			isANSIC = true
+			i = j + 1
+		case c == '"' || (c == '$' && i+1 < len(command) && command[i+1] == '"'):
+			// $"..." is bash's locale-translated string; without a message
+			// catalog it is an ordinary double-quoted string.
+			begin(i)
+			quote := "\""
+			if c == '$' {
+				quote = "$\""
+				i++
+			}
+			j := i + 1
+			var sb strings.Builder
+			for ; j < len(command) && command[j] != '"'; j++ {
+				if command[j] == '\\' && j+1 < len(command) {
+					switch command[j+1] {
+					case '"', '\\', '$', '`':
+						j++
+					case '\n':
+						j++
+						continue
+					}
+				}
+				sb.WriteByte(command[j])
+			}
+			if j >= len(command) {
+				return nil, fmt.Errorf("tokenizeCurl: unterminated %s quote starting at byte %d", quote, i-len(quote)+1)
+			}
+			appendPlain(sb.String())
+			i = j + 1
+		default:
+			begin(i)
+			appendPlain(command[i : i+1])
+			i++
+		}
+	}
+	flush(len(command))
+	return tokens, nil
+}
+
+// escapeANSIC escapes s so that decodeRawData turns it back into the same text.
+func escapeANSIC(s string) string {
+	if !strings.ContainsAny(s, "\\'") {
+		return s
+	}
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
+
+// unwrapContinuations removes the line wrapping some tools insert inside long
+// $'...' payloads: a backslash directly followed by a newline (LF or CRLF),
+// together with one space before the backslash and the indentation of the next
+// line. Escape sequences are skipped pairwise, so an escaped backslash (\\)
+// before a newline and the \n escape itself are left alone. It reports how
+// many continuations were removed.
+func unwrapContinuations(s string) (string, int) {
+	if !strings.Contains(s, "\\\n") && !strings.Contains(s, "\\\r\n") {
+		return s, 0
+	}
+	out := make([]byte, 0, len(s))
+	removed := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			out = append(out, s[i])
+			continue
+		}
+		next := i + 1
+		if s[next] == '\r' && next+1 < len(s) && s[next+1] == '\n' {
+			next++
+		}
+		if s[next] != '\n' {
+			out = append(out, s[i], s[i+1]) // Keep the escape pair intact.
+			i++
+			continue
+		}
+		// Drop the space the wrapper put before the backslash, the newline and the indentation.
+		if len(out) > 0 && out[len(out)-1] == ' ' {
+			out = out[:len(out)-1]
+		}
+		i = next
+		for i+1 < len(s) && (s[i+1] == ' ' || s[i+1] == '\t') {
+			i++
+		}
+		removed++
+	}
+	return string(out), removed
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+// TestTokenizeCurl tests the tokenizeCurl function.
+func TestTokenizeCurl(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		expected    []string
+		ansiC       []bool
+		expectError bool
+	}{
+		{"simple words", "curl https://x", []string{"curl", "https://x"}, []bool{false, false}, false},
+		{"single quotes", "curl 'a b' 'c\\d'", []string{"curl", "a b", "c\\d"}, []bool{false, false, false}, false},
+		{"double quotes", `curl "a \"b\" \\ \x"`, []string{"curl", `a "b" \ \x`}, []bool{false, false}, false},
+		{"ansi-c kept escaped", `-d $'a\nb\'c'`, []string{"-d", `a\nb\'c`}, []bool{false, true}, false},
+		{"line continuation", "curl 'u' \\\n  -H 'A: b'", []string{"curl", "u", "-H", "A: b"}, []bool{false, false, false, false}, false},
+		{"crlf continuation", "curl 'u' \\\r\n  -X POST", []string{"curl", "u", "-X", "POST"}, []bool{false, false, false, false}, false},
+		{"backslash escape", `a\ b`, []string{"a b"}, []bool{false}, false},
+		{"escaped quote does not end ansi-c", `--data-raw $'it\'s here' -H 'X: y'`, []string{"--data-raw", `it\'s here`, "-H", "X: y"}, []bool{false, true, false, false}, false},
+		{"mixed segments re-escaped", `$'x\n''y\z'`, []string{`x\ny\\z`}, []bool{true}, false},
+		{"empty quotes", "curl ''", []string{"curl", ""}, []bool{false, false}, false},
+		{"localized string is double-quoted", `-d $"a\nb \"c\" \\"`, []string{"-d", `a\nb "c" \`}, []bool{false, false}, false},
+		{"dollar inside a word", `a$"b"`, []string{"ab"}, []bool{false}, false},
+		{"lone dollar", `$ $x`, []string{"$", "$x"}, []bool{false, false}, false},
+		{"unterminated single", "curl 'a", nil, nil, true},
+		{"unterminated double", `curl "a`, nil, nil, true},
+		{"unterminated localized", `curl $"a`, nil, nil, true},
+		{"unterminated ansi-c", `curl $'a\'`, nil, nil, true},
+		{"trailing backslash", `curl \`, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tokenizeCurl(tt.command)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("tokenizeCurl(%q) should have returned an error, but got nil", tt.command)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("tokenizeCurl(%q) returned an unexpected error: %v", tt.command, err)
+			}
+			var values []string
+			var ansiC []bool
+			for _, tok := range got {
+				values = append(values, tok.Value)
+				ansiC = append(ansiC, tok.ANSIC)
+			}
+			if !reflect.DeepEqual(values, tt.expected) || !reflect.DeepEqual(ansiC, tt.ansiC) {
+				t.Errorf("tokenizeCurl(%q) = %q %v; want %q %v", tt.command, values, ansiC, tt.expected, tt.ansiC)
+			}
+		})
+	}
+}
+
+// TestTokenizeCurlOffsets tests that tokens report the byte range of the whole word.
+func TestTokenizeCurlOffsets(t *testing.T) {
+	command := `curl 'u' --data-raw $'a\'b' -H "X: y"`
+	got, err := tokenizeCurl(command)
+	if err != nil {
+		t.Fatalf("tokenizeCurl(%q) returned an unexpected error: %v", command, err)
+	}
+	expected := []string{"curl", "'u'", "--data-raw", `$'a\'b'`, "-H", `"X: y"`}
+	if len(got) != len(expected) {
+		t.Fatalf("tokenizeCurl(%q) returned %d tokens; want %d", command, len(got), len(expected))
+	}
+	for i, tok := range got {
+		if src := command[tok.Start:tok.End]; src != expected[i] {
+			t.Errorf("token %d spans %q; want %q", i, src, expected[i])
+		}
+	}
+}
+
+// TestUnwrapContinuations tests the unwrapContinuations function.
+func TestUnwrapContinuations(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		expected        string
+		expectedRemoved int
+	}{
+		{"nothing to do", `\x1f\x8b\n`, `\x1f\x8b\n`, 0},
+		{"wrapped hex run", "\\x1f\\x8b \\\n  \\x08\\x00", `\x1f\x8b\x08\x00`, 1},
+		{"crlf wrap", "ab \\\r\n\tcd", "abcd", 1},
+		{"no space before backslash", "ab\\\ncd", "abcd", 1},
+		{"two wraps", "a \\\n  b \\\n  c", "abc", 2},
+		{"escaped backslash before newline", "a\\\\\nb", "a\\\\\nb", 0},
+		{"newline escape is not a wrap", `a\nb`, `a\nb`, 0},
+		{"trailing backslash", `a\`, `a\`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, removed := unwrapContinuations(tt.input)
+			if got != tt.expected || removed != tt.expectedRemoved {
+				t.Errorf("unwrapContinuations(%q) = (%q, %d); want (%q, %d)", tt.input, got, removed, tt.expected, tt.expectedRemoved)
+			}
+		})
+	}
+}
+
+// TestRunUnwrapsWrappedGzip is a regression test for a Chrome export whose
+// gzip payload was wrapped across lines with " \" continuations.
+func TestRunUnwrapsWrappedGzip(t *testing.T) {
+	escaped := hexEscape(gzipBytes(t, `{"wrapped":true}`))
+	wrapped := escaped[:40] + " \\\n  " + escaped[40:80] + " \\\n  " + escaped[80:]
+	command := "curl 'https://example.com/collect' \\\n" +
+		"  -H 'content-encoding: gzip' \\\n" +
+		"  --data-raw $'" + wrapped + "' \\\n" +
+		"  --compressed"
+	got, err := Run(command, Options{})
+	if err != nil {
+		t.Fatalf("Run() returned an unexpected error: %v", err)
+	}
+	if expected := "{\n  \"wrapped\": true\n}"; string(got) != expected {
+		t.Errorf("Run() = %q; want %q", got, expected)
+	}
+}
+
+// TestRunEscapedQuote is a regression test for a $'...' payload holding an
+// escaped quote: the payload must not end at \' and the option after it must
+// still be parsed.
+func TestRunEscapedQuote(t *testing.T) {
+	command := `curl 'u' --data-raw $'it\'s here' -H 'Content-Type: text/plain'`
+	res, err := Decode(command, Options{})
+	if err != nil {
+		t.Fatalf("Decode(%q) returned an unexpected error: %v", command, err)
+	}
+	if string(res.Output) != "it's here" || res.ContentType != "text/plain" {
+		t.Errorf("Decode(%q) = %q with Content-Type %q; want %q with %q", command, res.Output, res.ContentType, "it's here", "text/plain")
+	}
+}
+
+// TestRunLocalizedString tests that a $"..." payload is taken verbatim while
+// a $'...' payload with the same text has its ANSI-C escapes decoded.
+func TestRunLocalizedString(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		expected string
+	}{
+		{"ansi-c", `curl 'u' --data-raw $'{"a":"x\ty"}'`, "{\"a\":\"x\ty\"}"},
+		{"localized", `curl 'u' --data-raw $"{\"a\":\"x\ty\"}"`, `{"a":"x\ty"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(tt.command, Options{Format: "hexstring"})
+			if err != nil {
+				t.Fatalf("Run(%q) returned an unexpected error: %v", tt.command, err)
+			}
+			if expected := hex.EncodeToString([]byte(tt.expected)); string(got) != expected {
+				t.Errorf("Run(%q) = %s; want %s", tt.command, got, expected)
+			}
+		})
+	}
+}
+
+// FuzzTokenizeCurl checks that tokenizeCurl returns an error rather than
+// panicking on arbitrary input, and that token offsets stay within it.
+func FuzzTokenizeCurl(f *testing.F) {
+	for _, seed := range []string{
+		"curl 'https://example.com' -H 'A: b' --data-raw $'\\x1f\\x8b'",
+		"curl \"a\\\"b\" $\"c\" d\\ e \\\n -d x",
+		"curl $'unterminated",
+		"curl 'a",
+		"curl \\",
+		"curl $'\\'' \"\\\\\" ''",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, command string) {
+		tokens, err := tokenizeCurl(command)
+		if err != nil {
+			return
+		}
+		for _, tok := range tokens {
+			if tok.Start < 0 || tok.End > len(command) || tok.Start > tok.End {
+				t.Errorf("tokenizeCurl(%q) returned token %+v outside the input", command, tok)
+			}
+		}
+	})
+}
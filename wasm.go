@@ -0,0 +1,32 @@
+//go:build js && wasm
+
+package main
+
+import "syscall/js"
+
+// DecodeCurl runs a cURL command through Run with the default options for the
+// browser build. Errors cannot cross into JavaScript, so both the output and
+// the error message are returned as strings; err is empty on success. Binary
+// output that is not valid UTF-8 is not representable as a JavaScript string
+// and will contain replacement characters.
+func DecodeCurl(command string) (result string, err string) {
+	output, runErr := Run(command, Options{})
+	if runErr != nil {
+		return "", runErr.Error()
+	}
+	return string(output), ""
+}
+
+// main exposes DecodeCurl to JavaScript as the global decodeCurl(command),
+// which returns an object {result, error}, and keeps the Go runtime alive so
+// the page can call it repeatedly.
+func main() {
+	js.Global().Set("decodeCurl", js.FuncOf(func(this js.Value, args []js.Value) any {
+		if len(args) != 1 || args[0].Type() != js.TypeString {
+			return map[string]any{"result": "", "error": "decodeCurl expects a single string argument"}
+		}
+		result, err := DecodeCurl(args[0].String())
+		return map[string]any{"result": result, "error": err}
+	}))
+	select {}
+}
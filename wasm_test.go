@@ -0,0 +1,34 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDecodeCurl tests the DecodeCurl function of the browser build.
+// Run with: GOOS=js GOARCH=wasm go test -exec="$(go env GOROOT)/lib/wasm/go_js_wasm_exec" .
+func TestDecodeCurl(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		expected string
+		errorMsg string
+	}{
+		{"json body", `curl 'url' --data-raw $'{"a":1}'`, "{\n  \"a\": 1\n}", ""},
+		{"missing payload", "curl 'url'", "", "extraction failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := DecodeCurl(tt.command)
+			if result != tt.expected {
+				t.Errorf("DecodeCurl(%q) result = %q; want %q", tt.command, result, tt.expected)
+			}
+			if (tt.errorMsg == "") != (err == "") || !strings.Contains(err, tt.errorMsg) {
+				t.Errorf("DecodeCurl(%q) err = %q; want %q", tt.command, err, tt.errorMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	xmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	xmlAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\n", "&#xA;", "\t", "&#x9;")
+)
+
+// looksLikeXML reports whether data starts, after leading whitespace, with an
+// XML declaration, a doctype, a comment or a tag.
+func looksLikeXML(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) < 2 || data[0] != '<' {
+		return false
+	}
+	c := data[1]
+	return c == '?' || c == '!' || c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// reindentXML re-indents an XML document with two spaces per level. Text-only
+// elements stay on one line, whitespace-only text between elements is
+// dropped and namespace prefixes are kept as written. With html set the input
+// is parsed leniently, as HTML: void elements such as <br> are closed
+// automatically and HTML entities are accepted.
+func reindentXML(data []byte, html bool) ([]byte, error) {
+	// Token checks that elements are balanced (and, for HTML, closes void
+	// elements) but replaces prefixes with namespace URLs, so XML is checked
+	// with Token and then read again with RawToken to keep the prefixes.
+	var tokens []xml.Token
+	hasElement := false
+	for _, raw := range []bool{false, true} {
+		if raw && html {
+			break
+		}
+		decoder := xml.NewDecoder(bytes.NewReader(data))
+		if html {
+			decoder.Strict = false
+			decoder.AutoClose = xml.HTMLAutoClose
+			decoder.Entity = xml.HTMLEntity
+		}
+		tokens = tokens[:0]
+		for {
+			next := decoder.Token
+			if raw {
+				next = decoder.RawToken
+			}
+			token, err := next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			token = xml.CopyToken(token)
+			switch t := token.(type) {
+			case xml.StartElement:
+				hasElement = true
+				if html { // HTML has no prefixes; drop the namespace URLs Token filled in.
+					t.Name.Space = ""
+					token = t
+				}
+			case xml.EndElement:
+				if html {
+					t.Name.Space = ""
+					token = t
+				}
+			}
+			tokens = append(tokens, token)
+		}
+	}
+	if !hasElement {
+		return nil, errors.New("no XML element found")
+	}
+
+	var out bytes.Buffer
+	depth := 0
+	newline := func() {
+		if out.Len() > 0 {
+			out.WriteByte('\n')
+		}
+		out.WriteString(strings.Repeat("  ", depth))
+	}
+	for i := 0; i < len(tokens); i++ {
+		switch t := tokens[i].(type) {
+		case xml.StartElement:
+			newline()
+			out.WriteString("<" + xmlName(t.Name))
+			for _, attr := range t.Attr {
+				fmt.Fprintf(&out, ` %s="%s"`, xmlName(attr.Name), xmlAttrEscaper.Replace(attr.Value))
+			}
+			// <a></a> becomes <a/>, and <a>text</a> stays on one line.
+			if i+1 < len(tokens) {
+				if _, ok := tokens[i+1].(xml.EndElement); ok {
+					out.WriteString("/>")
+					i++
+					continue
+				}
+			}
+			if i+2 < len(tokens) {
+				text, isText := tokens[i+1].(xml.CharData)
+				_, isEnd := tokens[i+2].(xml.EndElement)
+				if isText && isEnd {
+					out.WriteString(">" + xmlTextEscaper.Replace(string(text)) + "</" + xmlName(t.Name) + ">")
+					i += 2
+					continue
+				}
+			}
+			out.WriteString(">")
+			depth++
+		case xml.EndElement:
+			if depth > 0 {
+				depth--
+			}
+			newline()
+			out.WriteString("</" + xmlName(t.Name) + ">")
+		case xml.CharData:
+			text := strings.TrimSpace(string(t))
+			if text == "" {
+				continue
+			}
+			newline()
+			out.WriteString(xmlTextEscaper.Replace(text))
+		case xml.Comment:
+			newline()
+			out.WriteString("<!--" + string(t) + "-->")
+		case xml.ProcInst:
+			newline()
+			out.WriteString("<?" + t.Target)
+			if len(t.Inst) > 0 {
+				out.WriteString(" " + string(t.Inst))
+			}
+			out.WriteString("?>")
+		case xml.Directive:
+			newline()
+			out.WriteString("<!" + string(t) + ">")
+		}
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// xmlName returns name as written in the document, with its prefix if any.
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// formatXML re-indents an XML body, returning it unchanged when it cannot be
+// parsed.
+func formatXML(data []byte, opts Options) ([]byte, error) {
+	return formatMarkup(data, false, opts)
+}
+
+// formatMarkup re-indents an XML or (with html set) HTML body, falling back
+// to the raw body when it cannot be parsed.
+func formatMarkup(data []byte, html bool, opts Options) ([]byte, error) {
+	kind := "XML"
+	if html {
+		kind = "HTML"
+	}
+	indented, err := reindentXML(data, html)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("Could not parse the body as %s, saving raw processed data: %v", kind, err), field("error", err))
+		return data, nil
+	}
+	fmt.Fprintf(previews, "Parsed %s data:\n", kind)
+	if len(indented) > 500 {
+		fmt.Fprintln(previews, string(indented[:500])+"...")
+	} else {
+		fmt.Fprint(previews, string(indented))
+	}
+	return indented, nil
+}
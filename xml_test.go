@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// TestReindentXML tests the reindentXML function.
+func TestReindentXML(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		html        bool
+		expected    string
+		expectError bool
+	}{
+		{
+			name:  "simple document",
+			input: `<?xml version="1.0" encoding="UTF-8"?><order id="7"><!-- note --><item sku="a&amp;b">Tea &lt;green&gt;</item><item sku="c"/><empty></empty></order>`,
+			expected: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n" +
+				"<order id=\"7\">\n" +
+				"  <!-- note -->\n" +
+				"  <item sku=\"a&amp;b\">Tea &lt;green&gt;</item>\n" +
+				"  <item sku=\"c\"/>\n" +
+				"  <empty/>\n" +
+				"</order>\n",
+		},
+		{
+			name:     "existing indentation is normalized",
+			input:    "<a>\n\t\t<b>1</b>\n    <c><d>2</d></c>\n</a>",
+			expected: "<a>\n  <b>1</b>\n  <c>\n    <d>2</d>\n  </c>\n</a>\n",
+		},
+		{
+			name:     "namespace prefixes are kept",
+			input:    `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><m:Ping xmlns:m="urn:x"/></soap:Body></soap:Envelope>`,
+			expected: "<soap:Envelope xmlns:soap=\"http://schemas.xmlsoap.org/soap/envelope/\">\n  <soap:Body>\n    <m:Ping xmlns:m=\"urn:x\"/>\n  </soap:Body>\n</soap:Envelope>\n",
+		},
+		{
+			name:     "html with void elements and entities",
+			input:    `<!DOCTYPE html><html><body><p>a&nbsp;b<br>c</p></body></html>`,
+			html:     true,
+			expected: "<!DOCTYPE html>\n<html>\n  <body>\n    <p>\n      a b\n      <br/>\n      c\n    </p>\n  </body>\n</html>\n",
+		},
+		{
+			name:     "xhtml namespace is not printed as a prefix",
+			input:    `<html xmlns="http://www.w3.org/1999/xhtml"><p>x</p></html>`,
+			html:     true,
+			expected: "<html xmlns=\"http://www.w3.org/1999/xhtml\">\n  <p>x</p>\n</html>\n",
+		},
+		{name: "mismatched tags", input: "<a><b></a>", expectError: true},
+		{name: "not markup", input: "plain text", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reindentXML([]byte(tt.input), tt.html)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("reindentXML(%q) error = %v; expectError %v", tt.input, err, tt.expectError)
+			}
+			if !tt.expectError && string(got) != tt.expected {
+				t.Errorf("reindentXML(%q) =\n%s\nwant\n%s", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestReindentXMLRoundTrip tests that re-indenting keeps the document's
+// elements, attributes and text, and that it is idempotent.
+func TestReindentXMLRoundTrip(t *testing.T) {
+	input := []byte(`<feed xmlns="http://www.w3.org/2005/Atom"><title type="text">News &amp; "views"</title><entry><id>1</id><link href="/a?x=1&amp;y=2"/></entry></feed>`)
+	once, err := reindentXML(input, false)
+	if err != nil {
+		t.Fatalf("reindentXML() returned an unexpected error: %v", err)
+	}
+	twice, err := reindentXML(once, false)
+	if err != nil {
+		t.Fatalf("reindentXML() over its own output returned an unexpected error: %v", err)
+	}
+	if !bytes.Equal(once, twice) {
+		t.Errorf("reindentXML() is not idempotent:\n%s\nthen\n%s", once, twice)
+	}
+
+	// significantTokens returns the document's tokens without whitespace-only text.
+	significantTokens := func(data []byte) []xml.Token {
+		var tokens []xml.Token
+		decoder := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			token, err := decoder.Token()
+			if errors.Is(err, io.EOF) {
+				return tokens
+			}
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", data, err)
+			}
+			if text, ok := token.(xml.CharData); ok && len(bytes.TrimSpace(text)) == 0 {
+				continue
+			}
+			tokens = append(tokens, xml.CopyToken(token))
+		}
+	}
+	if got, want := significantTokens(once), significantTokens(input); !reflect.DeepEqual(got, want) {
+		t.Errorf("reindented document tokens = %v; want %v", got, want)
+	}
+}
+
+// TestInterpretBodyXML tests that XML bodies are re-indented by Content-Type and by sniffing.
+func TestInterpretBodyXML(t *testing.T) {
+	body := []byte(`<a><b>1</b></a>`)
+	expected := "<a>\n  <b>1</b>\n</a>\n"
+	for _, contentType := range []string{"application/xml", "text/xml; charset=utf-8", "application/atom+xml", ""} {
+		got, err := interpretBody(contentType, body, Options{})
+		if err != nil {
+			t.Fatalf("interpretBody(%q) returned an unexpected error: %v", contentType, err)
+		}
+		if string(got) != expected {
+			t.Errorf("interpretBody(%q) = %q; want %q", contentType, got, expected)
+		}
+	}
+	if got, _ := interpretBody("application/xml", []byte("<a>"), Options{}); string(got) != "<a>" {
+		t.Errorf("interpretBody() of malformed XML = %q; want the raw body", got)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// yamlField is a member of a JSON object, kept in document order.
+type yamlField struct {
+	Key   string
+	Value any
+}
+
+// formatYAML renders a JSON body as YAML (block style, two-space indent),
+// keeping the order of object keys. Bodies that are not JSON fail with a
+// NotJSONError.
+func formatYAML(data []byte, opts Options) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	value, err := decodeOrderedJSON(dec)
+	if err == nil {
+		if _, extra := dec.Token(); extra != io.EOF {
+			err = errors.New("unexpected data after the top-level value")
+		}
+	}
+	if err != nil {
+		return nil, &NotJSONError{Err: fmt.Errorf("-format yaml needs a JSON body: %w", err)}
+	}
+	var sb strings.Builder
+	writeYAML(&sb, value, 0)
+	return []byte(sb.String()), nil
+}
+
+// decodeOrderedJSON reads the next JSON value from dec, with objects as
+// []yamlField so that their key order is kept and numbers as json.Number.
+func decodeOrderedJSON(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		fields := []yamlField{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, yamlField{Key: key.(string), Value: value})
+		}
+		_, err := dec.Token() // '}'
+		return fields, err
+	case json.Delim('['):
+		items := []any{}
+		for dec.More() {
+			item, err := decodeOrderedJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		_, err := dec.Token() // ']'
+		return items, err
+	default:
+		return tok, nil
+	}
+}
+
+// writeYAML writes value as YAML at the given indentation. Scalars and empty
+// collections are written inline, without a trailing newline; collections
+// start on the current line and end with a newline.
+func writeYAML(sb *strings.Builder, value any, indent int) {
+	pad := strings.Repeat("  ", indent)
+	switch v := value.(type) {
+	case []yamlField:
+		if len(v) == 0 {
+			sb.WriteString("{}\n")
+			return
+		}
+		for i, f := range v {
+			if i > 0 {
+				sb.WriteString(pad)
+			}
+			sb.WriteString(yamlString(f.Key) + ":")
+			writeYAMLChild(sb, f.Value, indent+1)
+		}
+	case []any:
+		if len(v) == 0 {
+			sb.WriteString("[]\n")
+			return
+		}
+		for i, item := range v {
+			if i > 0 {
+				sb.WriteString(pad)
+			}
+			sb.WriteString("-")
+			if isYAMLCollection(item) {
+				// A nested collection starts on the dash's line.
+				sb.WriteString(" ")
+				writeYAML(sb, item, indent+1)
+			} else {
+				writeYAMLChild(sb, item, indent+1)
+			}
+		}
+	default:
+		sb.WriteString(yamlScalar(v) + "\n")
+	}
+}
+
+// writeYAMLChild writes the value of a mapping key or sequence entry: inline
+// after a space when it is a scalar or empty, and on the following lines
+// otherwise.
+func writeYAMLChild(sb *strings.Builder, value any, indent int) {
+	if !isYAMLCollection(value) {
+		sb.WriteString(" ")
+		writeYAML(sb, value, indent)
+		return
+	}
+	sb.WriteString("\n" + strings.Repeat("  ", indent))
+	writeYAML(sb, value, indent)
+}
+
+// isYAMLCollection reports whether value is a non-empty object or array.
+func isYAMLCollection(value any) bool {
+	switch v := value.(type) {
+	case []yamlField:
+		return len(v) > 0
+	case []any:
+		return len(v) > 0
+	}
+	return false
+}
+
+// yamlScalar renders a JSON scalar as a YAML scalar.
+func yamlScalar(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		return yamlString(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// yamlPlainUnsafe matches strings that YAML would read as something other
+// than the same string when written unquoted: booleans, nulls and numbers in
+// YAML 1.1 or 1.2, or text starting with an indicator character.
+var yamlPlainUnsafe = regexp.MustCompile(`(?i)^(?:y|n|yes|no|true|false|on|off|null|~|[-+]?(?:\.inf|\.nan)|[-+]?[0-9][0-9_.:eE+-]*|0x[0-9a-f_]+|0o?[0-7_]+|\.[0-9].*)$|^[-?:,\[\]{}#&*!|>'"%@` + "`" + `]`)
+
+// yamlString renders s as a plain YAML scalar when that reads back as the
+// same string, and double-quoted otherwise.
+func yamlString(s string) string {
+	plain := s != "" && s == strings.TrimSpace(s) && !yamlPlainUnsafe.MatchString(s) &&
+		!strings.Contains(s, ": ") && !strings.Contains(s, " #") && !strings.HasSuffix(s, ":")
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f || r == '\uFEFF' {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return s
+	}
+	return strconv.Quote(s)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestFormatYAML tests the formatYAML function.
+func TestFormatYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"flat object keeps key order", `{"b":1,"a":"x","c":true,"d":null}`, "b: 1\na: x\nc: true\nd: null\n"},
+		{"nested objects", `{"user":{"name":"Ann","address":{"city":"Oslo","zip":"0150"}}}`,
+			"user:\n  name: Ann\n  address:\n    city: Oslo\n    zip: \"0150\"\n"},
+		{"arrays", `{"items":[1,2.5,"three"],"empty":[],"none":{}}`, "items:\n  - 1\n  - 2.5\n  - three\nempty: []\nnone: {}\n"},
+		{"array of objects", `[{"id":1,"tags":["a","b"]},{"id":2}]`, "- id: 1\n  tags:\n    - a\n    - b\n- id: 2\n"},
+		{"nested arrays", `[[1,2],[]]`, "- - 1\n  - 2\n- []\n"},
+		{"scalar", `"hi"`, "hi\n"},
+		{"strings that need quotes", `{"s":["yes","123","a: b","","- x"," pad","line\nbreak","#c","ok"]}`,
+			"s:\n  - \"yes\"\n  - \"123\"\n  - \"a: b\"\n  - \"\"\n  - \"- x\"\n  - \" pad\"\n  - \"line\\nbreak\"\n  - \"#c\"\n  - ok\n"},
+		{"keys that need quotes", `{"true":1,"a:":2,"":3}`, "\"true\": 1\n\"a:\": 2\n\"\": 3\n"},
+		{"large numbers are kept verbatim", `{"num":12345678901234567890}`, "num: 12345678901234567890\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatYAML([]byte(tt.input), Options{})
+			if err != nil {
+				t.Fatalf("formatYAML() returned an unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("formatYAML(%s) =\n%s\nwant\n%s", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestFormatYAMLNotJSON tests that formatYAML rejects bodies that are not JSON.
+func TestFormatYAMLNotJSON(t *testing.T) {
+	for _, input := range []string{"hello", `{"a":1} trailing`, `{"a":`, ""} {
+		_, err := formatYAML([]byte(input), Options{})
+		var notJSON *NotJSONError
+		if !errors.As(err, &notJSON) {
+			t.Errorf("formatYAML(%q) error = %v; want a NotJSONError", input, err)
+		}
+	}
+}